@@ -0,0 +1,506 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// frameHeaderSize is the size of the length and checksum preceding each
+// record of the append-only log.
+const frameHeaderSize = 8
+
+// record formats, stored in the first byte of the payload
+const (
+	recordPlain byte = iota
+	recordEncrypted
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorruptRecord is returned for records that were not written completely.
+var errCorruptRecord = errors.New("cache: corrupt log record")
+
+// logRecord is the serialized form of a modification of the cache.
+type logRecord struct {
+	Remove  bool
+	Key     string
+	Value   interface{}
+	Expires int64
+	TTL     time.Duration
+	Sliding bool
+	// Synced marks the end of the entries sent to a replica when it connects
+	Synced bool
+}
+
+// appendLog appends modifications of the cache to a file.
+type appendLog struct {
+	path       string
+	syncEvery  time.Duration
+	encryption *encryption
+	f          *os.File
+	size       int64
+
+	// rewriteSize is the minimum size of the log before it is rewritten,
+	// zero disables rewriting
+	rewriteSize int64
+	// baseSize is the size of the log after the last rewrite
+	baseSize  int64
+	rewriting bool
+	// pending holds the last record of each key appended during a rewrite
+	pending  map[string][]byte
+	rewrites sync.WaitGroup
+
+	stop chan struct{}
+	done chan struct{}
+	sync.Mutex
+}
+
+func newAppendLog(path string, syncEvery time.Duration) *appendLog {
+	return &appendLog{
+		path:      path,
+		syncEvery: syncEvery,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// openLog replays the append-only log of the cache and opens it for
+// appending. On failure the error is passed to the error handler and the
+// cache continues without log.
+func (c *Cache) openLog() {
+	l := c.aof
+	c.aof = nil
+
+	if err := l.open(c); err != nil {
+		c.handleError(err)
+		return
+	}
+
+	c.aof = l
+	c.logf(slog.LevelInfo, "append-only log replayed", "path", l.path, "size", l.size)
+
+	if l.syncEvery > 0 {
+		go l.run(c)
+	} else {
+		close(l.done)
+	}
+}
+
+// open replays the log into c and truncates an incompletely written record at
+// its end.
+func (l *appendLog) open(c *Cache) error {
+	if c.encryption != nil && c.encryption.err != nil {
+		return c.encryption.err
+	}
+	l.encryption = c.encryption
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	valid, err := replay(c, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	l.f = f
+	l.size = valid
+	l.baseSize = valid
+
+	return nil
+}
+
+// replay applies the records read from r to c and returns the length of the
+// valid part of the log.
+func replay(c *Cache, r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+
+	var valid int64
+	for {
+		rec, n, err := readRecord(br, c.encryption)
+		if err == io.EOF || err == errCorruptRecord {
+			return valid, nil
+		}
+		if err != nil {
+			return valid, err
+		}
+		valid += n
+
+		if rec.Remove || rec.Expires != 0 && time.Now().UnixNano() >= rec.Expires {
+			c.discard(rec.Key)
+			continue
+		}
+
+		c.restore(&snapshotEntry{
+			Key:     rec.Key,
+			Value:   rec.Value,
+			Expires: rec.Expires,
+			TTL:     rec.TTL,
+			Sliding: rec.Sliding,
+		})
+	}
+}
+
+func readRecord(r io.Reader, enc *encryption) (*logRecord, int64, error) {
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, errCorruptRecord
+		}
+		return nil, 0, err
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, errCorruptRecord
+	}
+
+	if len(payload) == 0 || crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, errCorruptRecord
+	}
+
+	data := payload[1:]
+	if payload[0] == recordEncrypted {
+		if enc == nil {
+			return nil, 0, ErrNoEncryptionKey
+		}
+		if enc.err != nil {
+			return nil, 0, enc.err
+		}
+
+		var err error
+		if data, err = unseal(enc.aead, data, nil); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var rec logRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rec); err != nil {
+		return nil, 0, err
+	}
+
+	return &rec, int64(frameHeaderSize + len(payload)), nil
+}
+
+// encodeRecord returns the framed serialized form of rec, encrypted if enc is
+// not nil.
+func encodeRecord(rec *logRecord, enc *encryption) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+		return nil, err
+	}
+
+	format, data := recordPlain, buf.Bytes()
+	if enc != nil {
+		if enc.err != nil {
+			return nil, enc.err
+		}
+
+		var err error
+		if data, err = seal(enc.aead, data, nil); err != nil {
+			return nil, err
+		}
+		format = recordEncrypted
+	}
+
+	frame := make([]byte, frameHeaderSize+1+len(data))
+	frame[frameHeaderSize] = format
+	copy(frame[frameHeaderSize+1:], data)
+
+	payload := frame[frameHeaderSize:]
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.Checksum(payload, crcTable))
+
+	return frame, nil
+}
+
+// append writes a record to the log, syncing it to disk right away unless
+// the log is synced periodically.
+func (l *appendLog) append(rec *logRecord) error {
+	frame, err := encodeRecord(rec, l.encryption)
+	if err != nil {
+		return err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if _, err := l.f.Write(frame); err != nil {
+		return err
+	}
+	l.size += int64(len(frame))
+
+	// only the last record of a key is needed to restore it
+	if l.rewriting {
+		l.pending[rec.Key] = frame
+	}
+
+	if l.syncEvery <= 0 {
+		return l.f.Sync()
+	}
+
+	return nil
+}
+
+// beginRewrite marks the log as being rewritten and reports whether the
+// caller should perform the rewrite. With force false the rewrite is only
+// started once the log has outgrown both the rewrite size and twice its size
+// after the previous rewrite.
+func (l *appendLog) beginRewrite(force bool) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.rewriting {
+		return false
+	}
+
+	if !force && (l.rewriteSize <= 0 || l.size < l.rewriteSize || l.size < 2*l.baseSize) {
+		return false
+	}
+
+	l.rewriting = true
+	l.pending = make(map[string][]byte)
+	l.rewrites.Add(1)
+
+	return true
+}
+
+// endRewrite replaces the log with the rewritten file f of the given size,
+// after appending the records logged during the rewrite. If err is not nil
+// the rewrite is aborted instead, f may be nil then. After a failed rewrite
+// the next automatic one waits until the log doubled in size again.
+func (l *appendLog) endRewrite(f *os.File, size int64, err error) error {
+	l.Lock()
+	defer l.Unlock()
+	defer l.rewrites.Done()
+
+	pending := l.pending
+	l.rewriting = false
+	l.pending = nil
+
+	if err == nil {
+		for _, frame := range pending {
+			if _, err = f.Write(frame); err != nil {
+				break
+			}
+			size += int64(len(frame))
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), l.path)
+	}
+	if err != nil {
+		if f != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+		l.baseSize = l.size
+		return err
+	}
+
+	l.f.Close()
+	l.f = f
+	l.size = size
+	l.baseSize = size
+
+	return nil
+}
+
+// RewriteLog compacts the append-only log to the records needed to restore
+// the entries currently in the cache. The cache can be modified during the
+// rewrite. Logs are also rewritten automatically in the background once they
+// outgrow the size set by WithLogRewrite. Without log RewriteLog does nothing.
+func (c *Cache) RewriteLog() error {
+	if c.aof == nil || !c.aof.beginRewrite(true) {
+		return nil
+	}
+
+	return c.rewriteLog()
+}
+
+// rewriteLog writes the current entries to a new log file and replaces the
+// current log with it. The rewrite has to be started with beginRewrite.
+func (c *Cache) rewriteLog() error {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	l := c.aof
+
+	f, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".rewrite*")
+	if err != nil {
+		return l.endRewrite(nil, 0, err)
+	}
+
+	var size int64
+	w := bufio.NewWriter(f)
+
+	for i := 0; i < c.len() && err == nil; i++ {
+		for _, se := range c.snapshotShard(c.shard(i)) {
+			var frame []byte
+			frame, err = encodeRecord(&logRecord{
+				Key:     se.Key,
+				Value:   se.Value,
+				Expires: se.Expires,
+				TTL:     se.TTL,
+				Sliding: se.Sliding,
+			}, l.encryption)
+			if err != nil {
+				break
+			}
+
+			if _, err = w.Write(frame); err != nil {
+				break
+			}
+			size += int64(len(frame))
+		}
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+
+	return l.endRewrite(f, size, err)
+}
+
+// rewriteLogIfDue starts a background rewrite of the log once it has grown
+// large enough.
+func (c *Cache) rewriteLogIfDue() {
+	if !c.aof.beginRewrite(false) {
+		return
+	}
+
+	go func() {
+		if err := c.rewriteLog(); err != nil {
+			c.handleError(err)
+		}
+	}()
+}
+
+func (l *appendLog) run(c *Cache) {
+	defer close(l.done)
+
+	t := time.NewTicker(l.syncEvery)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			l.Lock()
+			err := l.f.Sync()
+			l.Unlock()
+			if err != nil {
+				c.handleError(err)
+			}
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// close waits for running rewrites, syncs and closes the log file.
+func (l *appendLog) close() error {
+	close(l.stop)
+	<-l.done
+	l.rewrites.Wait()
+
+	l.Lock()
+	defer l.Unlock()
+
+	if err := l.f.Sync(); err != nil {
+		l.f.Close()
+		return err
+	}
+
+	return l.f.Close()
+}
+
+// logSet appends the current state of an entry to the log, if there is one,
+// and passes it to connected replicas. The shard of the entry has to be
+// locked.
+func (c *Cache) logSet(key string, e *entry) {
+	if c.aof == nil && c.replicas.active.Load() == 0 {
+		return
+	}
+
+	rec := &logRecord{
+		Key:     key,
+		Value:   e.value,
+		Expires: e.expires.Load(),
+		TTL:     e.ttl,
+		Sliding: e.sliding,
+	}
+	c.replicas.send(rec)
+
+	if c.aof == nil {
+		return
+	}
+
+	if err := c.aof.append(rec); err != nil {
+		c.handleError(err)
+		return
+	}
+
+	c.rewriteLogIfDue()
+}
+
+// logRemove appends the removal of a key to the log, if there is one, and
+// passes it to connected replicas.
+func (c *Cache) logRemove(key string) {
+	if c.aof == nil && c.replicas.active.Load() == 0 {
+		return
+	}
+
+	rec := &logRecord{Remove: true, Key: key}
+	c.replicas.send(rec)
+
+	if c.aof == nil {
+		return
+	}
+
+	if err := c.aof.append(rec); err != nil {
+		c.handleError(err)
+		return
+	}
+
+	c.rewriteLogIfDue()
+}
+
+// discard deletes an entry without writing the removal through to the Store
+// or the log.
+func (c *Cache) discard(key string) {
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; ok {
+		e.stop()
+		delete(s.Entries, key)
+		c.record(EventRemove, key, nil)
+		c.tags.untag(key)
+		recycle(e)
+	}
+}
@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAppendOnlyLog(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	c := New(WithAppendOnlyLog(path, 0))
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+	for i := 0; i < 50; i++ {
+		c.Remove(key + strconv.Itoa(i))
+	}
+	c.Set(key+"0", value+"New")
+	c.SetWithTTL(key, value, time.Minute)
+	c.SetWithTTL(key+"Expired", value, 10*time.Millisecond)
+
+	if err := c.Close(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	c = New(WithAppendOnlyLog(path, time.Second))
+	defer c.Close()
+
+	if v, ok := c.Get(key + "0"); !ok || v != value+"New" {
+		t.Error("Expected", value+"New", "got", v)
+		t.Fail()
+	}
+
+	for i := 1; i < 50; i++ {
+		if _, ok := c.Get(key + strconv.Itoa(i)); ok {
+			t.Error("Removed element should not have been restored.")
+			t.Fail()
+		}
+	}
+
+	for i := 50; i < 100; i++ {
+		if _, ok := c.Get(key + strconv.Itoa(i)); !ok {
+			t.Error("Element should have been restored.")
+			t.Fail()
+		}
+	}
+
+	if _, ok := c.Get(key + "Expired"); ok {
+		t.Error("Expired element should not have been restored.")
+		t.Fail()
+	}
+
+	s := c.getShard(key)
+	if e := s.Entries[key]; e == nil || e.ttl != time.Minute {
+		t.Error("Expiry should have been restored.")
+		t.Fail()
+	}
+}
+
+func TestAppendOnlyLogTornWrite(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	c := New(WithAppendOnlyLog(path, 0))
+	c.Set(key, value)
+	c.Set(key+"1", value)
+	c.Close()
+
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-3)
+
+	var errs []error
+	c = New(WithAppendOnlyLog(path, 0), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	if len(errs) != 0 {
+		t.Error("Unexpected errors", errs)
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Complete record should have been restored.")
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key + "1"); ok {
+		t.Error("Incomplete record should have been skipped.")
+		t.Fail()
+	}
+
+	c.Set(key+"2", value)
+	c.Close()
+
+	c = New(WithAppendOnlyLog(path, 0))
+	defer c.Close()
+
+	if _, ok := c.Get(key + "2"); !ok {
+		t.Error("Records appended after truncation should have been restored.")
+		t.Fail()
+	}
+}
+
+func TestRewriteLog(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	c := New(WithAppendOnlyLog(path, time.Second))
+
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 100; j++ {
+			c.Set(key+strconv.Itoa(j), value+strconv.Itoa(i))
+		}
+	}
+	for j := 0; j < 50; j++ {
+		c.Remove(key + strconv.Itoa(j))
+	}
+
+	before, _ := os.Stat(path)
+
+	if err := c.RewriteLog(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	after, _ := os.Stat(path)
+
+	if after.Size()*10 > before.Size() {
+		t.Errorf("Expected log to shrink from %d bytes. Got %d bytes", before.Size(), after.Size())
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	c.Close()
+
+	c = New(WithAppendOnlyLog(path, 0))
+	defer c.Close()
+
+	for j := 0; j < 50; j++ {
+		if _, ok := c.Get(key + strconv.Itoa(j)); ok {
+			t.Error("Removed element should not have been restored.")
+			t.Fail()
+		}
+	}
+
+	for j := 50; j < 100; j++ {
+		if v, ok := c.Get(key + strconv.Itoa(j)); !ok || v != value+"9" {
+			t.Error("Expected", value+"9", "got", v)
+			t.Fail()
+		}
+	}
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Element appended after rewrite should have been restored.")
+		t.Fail()
+	}
+}
+
+func TestRewriteLogFailure(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	dir := filepath.Join(t.TempDir(), "log")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "cache.aof")
+
+	c := New(WithAppendOnlyLog(path, time.Second))
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	// the rewritten log cannot be created without the directory
+	os.RemoveAll(dir)
+
+	if err := c.RewriteLog(); err == nil {
+		t.Error("Expected an error rewriting the log.")
+		t.Fail()
+	}
+
+	c.aof.Lock()
+	defer c.aof.Unlock()
+
+	if c.aof.baseSize != c.aof.size {
+		t.Errorf("Expected the next rewrite to wait for %d bytes. Got %d", 2*c.aof.size, 2*c.aof.baseSize)
+		t.Fail()
+	}
+}
+
+func TestWithLogRewrite(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	c := New(WithAppendOnlyLog(path, time.Second), WithLogRewrite(16<<10))
+
+	for i := 0; i < 1000; i++ {
+		c.Set(key, value+strconv.Itoa(i))
+	}
+
+	c.Close()
+
+	info, _ := os.Stat(path)
+	if info.Size() > 16<<10 {
+		t.Errorf("Expected log to be rewritten. Got %d bytes", info.Size())
+		t.Fail()
+	}
+
+	c = New(WithAppendOnlyLog(path, 0))
+	defer c.Close()
+
+	if v, ok := c.Get(key); !ok || v != value+"999" {
+		t.Error("Expected", value+"999", "got", v)
+		t.Fail()
+	}
+}
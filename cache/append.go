@@ -0,0 +1,51 @@
+package cache
+
+import "errors"
+
+// ErrWrongType is returned by operations on values of a specific type if the
+// value stored with a key has another type.
+var ErrWrongType = errors.New("cache: value has the wrong type")
+
+// Append appends data to the string or []byte stored with the given key and
+// returns the new length of the value. Missing keys are stored with a copy of
+// data and the default ttl of the cache, existing entries keep their ttl and
+// the type of their value. Reading and storing happen atomically. []byte
+// values are copied rather than modified in place, so values returned by Get
+// before are not changed.
+func (c *Cache) Append(key string, data []byte) (n int, err error) {
+	defer func() {
+		if err == nil {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		value := append([]byte(nil), data...)
+		if err := c.storeSet(key, value, c.defaultTTL); err != nil {
+			return 0, err
+		}
+		c.set(s, key, value, c.defaultTTL)
+		return len(value), nil
+	}
+
+	var value interface{}
+	switch v := e.value.(type) {
+	case string:
+		value, n = v+string(data), len(v)+len(data)
+	case []byte:
+		b := make([]byte, 0, len(v)+len(data))
+		value, n = append(append(b, v...), data...), len(v)+len(data)
+	default:
+		return 0, ErrWrongType
+	}
+
+	if err := c.replace(s, key, e, value); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
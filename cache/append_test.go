@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if n, err := c.Append(key, []byte("test")); err != nil || n != 4 {
+		t.Errorf("Expected length 4. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	before, _ := c.Get(key)
+	if n, err := c.Append(key, []byte("Value")); err != nil || n != 9 {
+		t.Errorf("Expected length 9. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if v, _ := c.Get(key); !bytes.Equal(v.([]byte), []byte(value)) {
+		t.Errorf("Expected %s got %s", value, v)
+		t.Fail()
+	}
+
+	if !bytes.Equal(before.([]byte), []byte("test")) {
+		t.Errorf("Previously returned value should not change, got %s", before)
+		t.Fail()
+	}
+
+	c.Set("string", "test")
+	c.Append("string", []byte("Value"))
+	if v, _ := c.Get("string"); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	c.Set("int", 1)
+	if _, err := c.Append("int", []byte("test")); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
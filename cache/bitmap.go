@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// maxBitOffset limits the size of bitmaps to 1MB. Bitmaps are copied on
+// every change, so unlike Redis they are kept small.
+const maxBitOffset = 1<<23 - 1
+
+var errBitOffset = errors.New("cache: bit offset is out of range")
+
+// SetBit sets the bit at offset of the []byte stored with the given key to
+// value, 0 or 1, and returns the previous bit. Bit 0 is the most significant
+// bit of the first byte. The value grows as needed, missing keys are stored
+// with the default ttl of the cache. Changing a bit copies the value, so it
+// costs O(n) in its size, setting a bit to its current value costs nothing.
+// If the value stored with the key is not a []byte ErrWrongType is returned.
+func (c *Cache) SetBit(key string, offset int64, value int) (old int, err error) {
+	if offset < 0 || offset > maxBitOffset || value&^1 != 0 {
+		return 0, errBitOffset
+	}
+
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		b, err := asBitmap(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		i, mask := offset/8, byte(0x80>>(offset%8))
+		if i < int64(len(b)) && b[i]&mask != 0 {
+			old = 1
+		}
+		if ok && old == value {
+			return nil, errUnchanged
+		}
+
+		n := len(b)
+		if int(i) >= n {
+			n = int(i) + 1
+		}
+		set := make([]byte, n)
+		copy(set, b)
+
+		if value == 1 {
+			set[i] |= mask
+		} else {
+			set[i] &^= mask
+		}
+
+		return set, nil
+	})
+
+	return old, err
+}
+
+// GetBit returns the bit at offset of the []byte stored with the given key.
+// Bits beyond the value and of missing keys are 0.
+func (c *Cache) GetBit(key string, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errBitOffset
+	}
+
+	b, err := c.bitmap(key)
+	if err != nil || offset/8 >= int64(len(b)) {
+		return 0, err
+	}
+
+	if b[offset/8]&(0x80>>(offset%8)) != 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// BitCount returns the number of bits set in the []byte stored with the
+// given key.
+func (c *Cache) BitCount(key string) (int, error) {
+	b, err := c.bitmap(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, x := range b {
+		n += bits.OnesCount8(x)
+	}
+
+	return n, nil
+}
+
+// bitmap returns the []byte stored with the given key counting the access
+// like Get.
+func (c *Cache) bitmap(key string) ([]byte, error) {
+	v, ok := c.get(key)
+	return asBitmap(v, ok)
+}
+
+func asBitmap(v interface{}, ok bool) ([]byte, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	b, isBytes := v.([]byte)
+	if !isBytes {
+		return nil, ErrWrongType
+	}
+
+	return b, nil
+}
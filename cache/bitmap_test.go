@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBitmap(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	for _, offset := range []int64{0, 7, 100} {
+		if old, err := c.SetBit(key, offset, 1); err != nil || old != 0 {
+			t.Errorf("Expected previous bit 0. Got %d, %v", old, err)
+			t.Fail()
+		}
+	}
+
+	if old, _ := c.SetBit(key, 7, 0); old != 1 {
+		t.Errorf("Expected previous bit 1. Got %d", old)
+		t.Fail()
+	}
+
+	if bit, err := c.GetBit(key, 100); err != nil || bit != 1 {
+		t.Errorf("Expected bit 1. Got %d, %v", bit, err)
+		t.Fail()
+	}
+
+	if bit, _ := c.GetBit(key, 1000); bit != 0 {
+		t.Errorf("Expected bit 0. Got %d", bit)
+		t.Fail()
+	}
+
+	if n, err := c.BitCount(key); err != nil || n != 2 {
+		t.Errorf("Expected 2 bits. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	v, _ := c.Get(key)
+	if b := v.([]byte); len(b) != 13 || b[0] != 0x80 {
+		t.Errorf("Unexpected bitmap %x", b)
+		t.Fail()
+	}
+
+	if _, err := c.SetBit(key, -1, 1); err == nil {
+		t.Error("Negative offset should fail.")
+		t.Fail()
+	}
+
+	if _, err := c.SetBit(key, maxBitOffset+1, 1); err == nil {
+		t.Error("Offset beyond the maximum should fail.")
+		t.Fail()
+	}
+
+	_, version, _ := c.GetWithVersion(key)
+	if old, _ := c.SetBit(key, 100, 1); old != 1 {
+		t.Errorf("Expected previous bit 1. Got %d", old)
+		t.Fail()
+	}
+	if _, v, _ := c.GetWithVersion(key); v != version {
+		t.Error("Unchanged bitmap should not have been stored again.")
+		t.Fail()
+	}
+}
+
+func TestBitmapAppend(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+	c.Append(key, []byte{0x01})
+	c.SetBit(key, 0, 1)
+
+	if v, _ := c.Get(key); !bytes.Equal(v.([]byte), []byte{0x81}) {
+		t.Errorf("Expected 81 got %x", v)
+		t.Fail()
+	}
+
+	c.Set(key, "testValue")
+	if _, err := c.BitCount(key); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+)
+
+// Default parameters of bloom filters created by BFAdd.
+const (
+	DefaultBloomCapacity  = 100
+	DefaultBloomErrorRate = 0.01
+)
+
+// ErrKeyExists is returned by BFReserve if a value is stored with the key
+// already.
+var ErrKeyExists = errors.New("cache: key exists")
+
+var errBloomErrorRate = errors.New("cache: bloom filter error rate has to be between 0 and 1")
+
+// BloomFilter is the value stored by the bloom filter operations BFReserve
+// and BFAdd. BFAdd copies its bits (see modify).
+type BloomFilter struct {
+	Bits []uint64
+	// Hashes is the number of bits set per item
+	Hashes int
+}
+
+func init() {
+	gob.Register(&BloomFilter{})
+}
+
+// newBloomFilter returns an empty bloom filter with a false positive rate of
+// errorRate once capacity items are added.
+func newBloomFilter(capacity int, errorRate float64) *BloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	m := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &BloomFilter{
+		Bits:   make([]uint64, (int(m)+63)/64),
+		Hashes: k,
+	}
+}
+
+// BFReserve stores an empty bloom filter with the given key that has a false
+// positive rate of errorRate once capacity items are added. The filter gets
+// the default ttl of the cache. If a value is stored with the key already
+// ErrKeyExists is returned.
+func (c *Cache) BFReserve(key string, capacity int, errorRate float64) error {
+	if errorRate <= 0 || errorRate >= 1 {
+		return errBloomErrorRate
+	}
+
+	return c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		if ok {
+			return nil, ErrKeyExists
+		}
+		return newBloomFilter(capacity, errorRate), nil
+	})
+}
+
+// BFAdd adds the item to the bloom filter stored with the given key and
+// reports whether it was added, i.e. the filter did not contain it before.
+// Missing keys are stored as new filters with DefaultBloomCapacity and
+// DefaultBloomErrorRate and the default ttl of the cache. If the value
+// stored with the key is not a bloom filter ErrWrongType is returned.
+func (c *Cache) BFAdd(key string, item string) (added bool, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		f, err := asBloomFilter(v, ok)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			f = newBloomFilter(DefaultBloomCapacity, DefaultBloomErrorRate)
+		}
+
+		set := &BloomFilter{
+			Bits:   append([]uint64(nil), f.Bits...),
+			Hashes: f.Hashes,
+		}
+		added = set.add(item)
+
+		return set, nil
+	})
+
+	return added, err
+}
+
+// BFExists reports whether the item may have been added to the bloom filter
+// stored with the given key. False positives occur at about the error rate
+// of the filter, false negatives do not occur.
+func (c *Cache) BFExists(key string, item string) (bool, error) {
+	v, ok := c.get(key)
+	f, err := asBloomFilter(v, ok)
+	if err != nil || f == nil {
+		return false, err
+	}
+
+	return f.contains(item), nil
+}
+
+// add sets the bits of the item and reports whether any of them was unset.
+func (f *BloomFilter) add(item string) bool {
+	added := false
+	f.each(item, func(i uint64) {
+		if f.Bits[i/64]&(1<<(i%64)) == 0 {
+			f.Bits[i/64] |= 1 << (i % 64)
+			added = true
+		}
+	})
+	return added
+}
+
+// contains reports whether all bits of the item are set.
+func (f *BloomFilter) contains(item string) bool {
+	contains := true
+	f.each(item, func(i uint64) {
+		if f.Bits[i/64]&(1<<(i%64)) == 0 {
+			contains = false
+		}
+	})
+	return contains
+}
+
+// each calls fn with the bit indexes of the item, derived from two hashes
+// by double hashing.
+func (f *BloomFilter) each(item string, fn func(i uint64)) {
+	m := uint64(len(f.Bits)) * 64
+	h1 := hllHash(item)
+	h2 := h1>>32 | h1<<32 | 1
+
+	for i := 0; i < f.Hashes; i++ {
+		fn((h1 + uint64(i)*h2) % m)
+	}
+}
+
+func asBloomFilter(v interface{}, ok bool) (*BloomFilter, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	f, isBloomFilter := v.(*BloomFilter)
+	if !isBloomFilter {
+		return nil, ErrWrongType
+	}
+
+	return f, nil
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	if added, err := c.BFAdd(key, "a"); err != nil || !added {
+		t.Error("Item should have been added.", err)
+		t.Fail()
+	}
+
+	if added, _ := c.BFAdd(key, "a"); added {
+		t.Error("Item should have been in the filter.")
+		t.Fail()
+	}
+
+	if ok, err := c.BFExists(key, "a"); err != nil || !ok {
+		t.Error("Item should exist.", err)
+		t.Fail()
+	}
+
+	if ok, _ := c.BFExists("missing", "a"); ok {
+		t.Error("Item should not exist in a missing filter.")
+		t.Fail()
+	}
+}
+
+func TestBloomFilterErrorRate(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	if err := c.BFReserve(key, 1000, 0.01); err != nil {
+		t.Error("Failed to reserve filter:", err)
+		t.FailNow()
+	}
+
+	if err := c.BFReserve(key, 1000, 0.01); !errors.Is(err, ErrKeyExists) {
+		t.Error("Expected ErrKeyExists got", err)
+		t.Fail()
+	}
+
+	for i := 0; i < 1000; i++ {
+		c.BFAdd(key, strconv.Itoa(i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		if ok, _ := c.BFExists(key, strconv.Itoa(i)); !ok {
+			t.Error("Expected no false negatives, missing", i)
+			t.FailNow()
+		}
+	}
+
+	positives := 0
+	for i := 1000; i < 11000; i++ {
+		if ok, _ := c.BFExists(key, strconv.Itoa(i)); ok {
+			positives++
+		}
+	}
+
+	if positives > 200 {
+		t.Errorf("Expected about 100 false positives. Got %d", positives)
+		t.Fail()
+	}
+}
+
+func TestBloomFilterWrongType(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	if _, err := c.BFAdd(key, value); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,38 @@
+package cache
+
+import "time"
+
+// GetBytes retrieves the []byte value stored with the given key like Get,
+// e.g. for proxies passing cached payloads through. The stored slice is
+// returned without copying and without allocating, so it must not be
+// modified. false is returned if there is no value or it is not a []byte.
+func (c *Cache) GetBytes(key string) ([]byte, bool) {
+	v, ok := c.Get(key)
+	b, isBytes := v.([]byte)
+
+	return b, ok && isBytes
+}
+
+// GetInto appends the []byte value stored with the given key to buf[:0] like
+// GetBytes and returns the result, so callers reusing buffers get a copy
+// they may modify without allocating once buf is large enough.
+func (c *Cache) GetInto(key string, buf []byte) ([]byte, bool) {
+	b, ok := c.GetBytes(key)
+	if !ok {
+		return buf[:0], false
+	}
+
+	return append(buf[:0], b...), true
+}
+
+// SetBytes stores the []byte value with the given key like Set. The value is
+// stored without copying, so it must not be modified afterwards.
+func (c *Cache) SetBytes(key string, value []byte) {
+	c.Set(key, value)
+}
+
+// SetBytesWithTTL stores the []byte value with the given key like
+// SetWithTTL without copying it.
+func (c *Cache) SetBytesWithTTL(key string, value []byte, ttl time.Duration) {
+	c.SetWithTTL(key, value, ttl)
+}
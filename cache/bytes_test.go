@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetBytes(t *testing.T) {
+	key := "testKey"
+	value := []byte("testValue")
+
+	c := New()
+	c.SetBytes(key, value)
+
+	if v, ok := c.GetBytes(key); !ok || !bytes.Equal(v, value) {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	c.Set(key, "testValue")
+	if _, ok := c.GetBytes(key); ok {
+		t.Error("Values other than []byte should not be returned.")
+		t.Fail()
+	}
+
+	if _, ok := c.GetBytes("missing"); ok {
+		t.Error("Element should not have been found.")
+		t.Fail()
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	key := "testKey"
+	value := []byte("testValue")
+
+	c := New()
+	c.SetBytes(key, value)
+
+	buf := make([]byte, 3, 64)
+	v, ok := c.GetInto(key, buf)
+	if !ok || !bytes.Equal(v, value) {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+	if &v[0] != &buf[:1][0] {
+		t.Error("Expected the value to be copied into the buffer.")
+		t.Fail()
+	}
+
+	// the copy may be modified without changing the stored value
+	v[0] = 'T'
+	if stored, _ := c.GetBytes(key); !bytes.Equal(stored, value) {
+		t.Error("Expected", value, "got", stored)
+		t.Fail()
+	}
+
+	if v, ok := c.GetInto("missing", buf); ok || len(v) != 0 {
+		t.Error("Element should not have been found.")
+		t.Fail()
+	}
+}
+
+func TestGetBytesAllocs(t *testing.T) {
+	key := "testKey"
+	value := []byte("testValue")
+
+	c := New()
+	c.SetBytes(key, value)
+
+	buf := make([]byte, 0, 64)
+	allocs := testing.AllocsPerRun(100, func() {
+		c.GetBytes(key)
+		c.GetInto(key, buf)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations. Got %f", allocs)
+		t.Fail()
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// GetBytesKey retrieves the value stored with the key given as []byte like
+// Get. Hits do not convert the key to a string, so callers parsing keys from
+// network buffers look up values without allocating. The key may be modified
+// after GetBytesKey returned.
+func (c *Cache) GetBytesKey(key []byte) (interface{}, bool) {
+	if c.latency != nil {
+		defer c.latency.get.since(time.Now())
+	}
+
+	// k shares the memory of key and must not be retained
+	k := unsafe.String(unsafe.SliceData(key), len(key))
+
+	s := c.rlockShard(k)
+	if s.hot != nil {
+		s.hot.add(strings.Clone(k))
+	}
+
+	e, ok := s.Entries[k]
+	if ok && !e.expired() {
+		if c.refreshAhead > 0 {
+			c.refreshIfDue(strings.Clone(k), e)
+		}
+		e.renew()
+		s.stats.countHit()
+		v := e.value
+		s.RUnlock()
+		return v, true
+	}
+	s.stats.countMiss()
+	s.RUnlock()
+
+	if c.loader == nil {
+		return nil, false
+	}
+
+	v, err := c.load(context.Background(), string(key))
+
+	return v, err == nil
+}
+
+// SetBytesKey stores the value with the key given as []byte like Set. The
+// key is copied once to be stored in the cache.
+func (c *Cache) SetBytesKey(key []byte, value interface{}) {
+	c.Set(string(key), value)
+}
+
+// SetBytesKeyWithTTL stores the value with the key given as []byte like
+// SetWithTTL.
+func (c *Cache) SetBytesKeyWithTTL(key []byte, value interface{}, ttl time.Duration) {
+	c.SetWithTTL(string(key), value, ttl)
+}
+
+// RemoveBytesKey deletes the value stored with the key given as []byte like
+// Remove.
+func (c *Cache) RemoveBytesKey(key []byte) {
+	c.Remove(string(key))
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBytesKey(t *testing.T) {
+	key := []byte("testKey")
+	value := "testValue"
+
+	c := New()
+	c.SetBytesKey(key, value)
+
+	if v, ok := c.GetBytesKey(key); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if v, _ := c.Get("testKey"); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	// the key must not be retained by the cache
+	copy(key, "otherKe")
+	if _, ok := c.Get("testKey"); !ok {
+		t.Error("Stored key should not change with the buffer.")
+		t.Fail()
+	}
+
+	c.SetBytesKeyWithTTL(key, value, time.Hour)
+	c.RemoveBytesKey(key)
+	if _, ok := c.GetBytesKey(key); ok {
+		t.Error("Value should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestGetBytesKeyAllocs(t *testing.T) {
+	key := []byte("testKey")
+	value := "testValue"
+
+	c := New()
+	c.SetBytesKey(key, value)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c.GetBytesKey(key)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations. Got %f", allocs)
+		t.Fail()
+	}
+}
+
+func TestGetBytesKeyLoader(t *testing.T) {
+	value := "testValue"
+
+	c := New(WithLoader(LoaderFunc(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return value + key, 0, nil
+	})))
+
+	if v, ok := c.GetBytesKey([]byte("1")); !ok || v != value+"1" {
+		t.Error("Expected", value+"1", "got", v)
+		t.Fail()
+	}
+}
@@ -1,176 +1,1079 @@
 package cache
 
 import (
-	"hash/fnv"
+	"context"
+	"log/slog"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var (
-	shards = 64
-)
+// DefaultShards is the number of shards of a cache unless set with WithShards
+// or WithAutoShards.
+const DefaultShards = 64
+
+// MaxShards is the maximum number of shards of a cache.
+const MaxShards = 1 << (64 - scanHashBits)
 
 type entry struct {
 	value interface{}
-	exit  chan struct{}
+	// version changes whenever a value is stored in the entry
+	version uint64
+	// ttl is the ttl the entry was stored with, zero if it does not expire
+	ttl time.Duration
+	// sliding entries are renewed with their ttl on every Get
+	sliding bool
+	// expires holds the expiry time in unix nanoseconds, zero if the entry
+	// does not expire
+	expires atomic.Int64
+	exit    chan struct{}
+	// refreshing is set while the entry is refreshed in the background
+	refreshing atomic.Bool
+	// accessed holds the time of the last access in unix nanoseconds
+	accessed atomic.Int64
+	// shared entries are used outside the shard lock and not recycled
+	shared bool
 }
 
 type shard struct {
-	Entries map[string]entry
-	Stats   *Stats
+	Entries map[string]*entry
+	// stats is nil if stats are disabled
+	stats *counters
+	// hot is nil unless hot keys are tracked
+	hot *hotKeys
+	// capacity is the maximum number of entries, zero if unlimited
+	capacity int
+	// peak is the largest number of entries since the map was created
+	peak int
+	// hint is the number of entries the map is allocated for
+	hint int
+	// moved is the shard table the entries were migrated to by Resize, nil
+	// while the shard is in use
+	moved []*shard
 	sync.RWMutex
 }
 
+// counters hold the access statistics of a shard. They are updated
+// atomically, so Get can count hits and misses holding only a read lock.
+type counters struct {
+	hits    atomic.Int64
+	misses  atomic.Int64
+	set     atomic.Int64
+	removed atomic.Int64
+	expired atomic.Int64
+	evicted atomic.Int64
+}
+
 // Stats represents access statistics of a Cache.
 type Stats struct {
-	Hits    int       `json:"hits"`
-	Misses  int       `json:"misses"`
-	Set     int       `json:"set"`
-	Removed int       `json:"removed"`
-	Uptime  time.Time `json:"uptime"`
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+	Set    int `json:"set"`
+	// Removed counts explicit removals, entries removed because their ttl
+	// elapsed are counted in Expired and entries removed because the cache
+	// was full in Evicted
+	Removed int `json:"removed"`
+	Expired int `json:"expired"`
+	Evicted int `json:"evicted"`
+	// Entries is the current number of entries
+	Entries int `json:"entries"`
+	// HitRatio is the fraction of Get calls finding a value
+	HitRatio float64   `json:"hit_ratio"`
+	Uptime   time.Time `json:"uptime"`
+	// Windows holds the hits and misses of the last minute, 5 minutes and
+	// 15 minutes if enabled with WithWindowedStats
+	Windows []WindowStats `json:"windows,omitempty"`
+	// Latency holds the latencies of operations if enabled with
+	// WithLatencyTracking
+	Latency *Latencies `json:"latency,omitempty"`
+	// Layers holds the stats of each tier of a Layered cache
+	Layers []*Stats `json:"layers,omitempty"`
 }
 
+// NoExpiration can be passed as ttl to SetWithTTL to store a value without
+// expiry regardless of the default ttl of the cache.
+const NoExpiration time.Duration = -1
+
 // Cache is a thread safe structure to store and retrieve arbitrary values.
-type Cache []*shard
+type Cache struct {
+	// shards is replaced by Resize, whole cache operations hold resizing
+	// read locked to see the same shards throughout
+	shards   atomic.Pointer[[]*shard]
+	resizing sync.RWMutex
+	// shardCount is the number of shards set with an option, zero for the
+	// default
+	shardCount    int
+	hasher        Hasher
+	created       time.Time
+	statsDisabled bool
+	windows       *windows
+	shrinker      *shrinker
+	// hotKeys is the number of hot keys tracked per shard
+	hotKeys int
+	latency *latencies
+	sizer   Sizer
+
+	defaultTTL time.Duration
+	ttlJitter  float64
+	staleGrace time.Duration
+	refresh    RefreshFunc
+	// refreshAhead is the fraction of the ttl of an entry before its expiry
+	// in which a Get triggers a refresh
+	refreshAhead float64
+
+	loads    group
+	loader   Loader
+	failures *Cache
+
+	store   Store
+	writer  *writer
+	onError func(error)
+	logger  *slog.Logger
+
+	maxEntries int
+	// capacityHint is the number of entries expected in the cache
+	capacityHint int
+	evictHooks   []evictHook
+	onRemove     func(key string, value interface{}, reason Reason)
+	// evictions counts the evictions since the last warning at the time
+	// evictionWarned in unix nanoseconds
+	evictions      atomic.Int64
+	evictionWarned atomic.Int64
+
+	snapshots *autoSnapshot
+	aof       *appendLog
+	// aofRewriteSize is the size the append-only log is rewritten at
+	aofRewriteSize int64
+	encryption     *encryption
+	compression    Compression
+
+	replicas      *replicas
+	subscriptions *subscriptions
+	keyspace      *keyspace
+	bus           InvalidationBus
+	quotas        quotas
+	tags          *tagIndex
+
+	// versions is the last version assigned to an entry
+	versions atomic.Uint64
+}
+
+// New returns a reference to a new Cache configured with the given options.
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		created:       time.Now().UTC(),
+		replicas:      newReplicas(),
+		subscriptions: newSubscriptions(),
+		keyspace:      newKeyspace(),
+		tags:          newTagIndex(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.hasher == nil {
+		c.hasher = NewMapHasher()
+	}
+	if c.shardCount <= 0 {
+		c.shardCount = DefaultShards
+	}
+	shards := c.newShards(c.shardCount)
+	c.shards.Store(&shards)
+	if c.encryption == nil {
+		c.encryption = encryptionFromEnv()
+	}
+	if c.writer != nil && c.store == nil {
+		c.writer = nil
+	}
+	if c.writer != nil {
+		c.writer.start(c.store, c.handleError)
+	}
+	if c.snapshots != nil {
+		go c.snapshots.run(c)
+	}
+	if c.windows != nil {
+		c.windows.start(c)
+	}
+	if c.shrinker != nil {
+		go c.shrinker.run(c)
+	}
+	if c.aof != nil {
+		c.aof.rewriteSize = c.aofRewriteSize
+		c.openLog()
+	}
+	if c.bus != nil {
+		if err := c.bus.Subscribe(c.removeLocal); err != nil {
+			c.handleError(err)
+		}
+	}
+	return c
+}
 
-// New returns a reference to a new Cache
-func New() *Cache {
-	c := make(Cache, shards)
-	for i := 0; i < shards; i++ {
-		c[i] = newShard()
+func newShard(hint int, stats bool) *shard {
+	s := &shard{
+		Entries: make(map[string]*entry, hint),
+		hint:    hint,
 	}
-	return &c
+	if stats {
+		s.stats = &counters{}
+	}
+	return s
 }
 
-func newShard() *shard {
-	return &shard{
-		Entries: make(map[string]entry),
-		Stats:   &Stats{Uptime: time.Now().UTC()},
+// newShards returns n empty shards sharing the entries allowed by
+// WithMaxEntries and expected by WithCapacityHint.
+func (c *Cache) newShards(n int) []*shard {
+	shards := make([]*shard, n)
+	for i := range shards {
+		shards[i] = newShard((c.capacityHint+n-1)/n, !c.statsDisabled)
+		if c.hotKeys > 0 {
+			shards[i].hot = newHotKeys(c.hotKeys)
+		}
+		if c.maxEntries > 0 {
+			shards[i].capacity = (c.maxEntries + n - 1) / n
+		}
 	}
+	return shards
 }
 
-// Set stores the value with the given key.
+// Set stores the value with the given key. If the cache has a default ttl the
+// value is removed automatically after it elapsed.
 func (c *Cache) Set(key string, value interface{}) {
-	s := c.getShard(key)
-	s.Lock()
+	// deferred first to run after the shard is unlocked
+	defer c.invalidateOthers(key)
+	if c.latency != nil {
+		defer c.latency.set.since(time.Now())
+	}
+
+	s := c.lockShard(key)
 	defer s.Unlock()
 
-	e, ok := s.Entries[key]
+	if !c.writeThrough(key, value, c.defaultTTL) {
+		return
+	}
+
+	c.set(s, key, value, c.defaultTTL)
+}
+
+// SetWithTTL stores the value with the given key and removes it automatically after
+// ttl seconds. The default ttl of the cache is overridden, NoExpiration stores
+// the value without expiry.
+func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	defer c.invalidateOthers(key)
+	if c.latency != nil {
+		defer c.latency.set.since(time.Now())
+	}
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if !c.writeThrough(key, value, ttl) {
+		return
+	}
+
+	c.set(s, key, value, ttl)
+}
+
+// set stores the value with the given key in the shard, which has to be
+// locked for writing. Values with a ttl of zero or less do not expire.
+func (c *Cache) set(s *shard, key string, value interface{}, ttl time.Duration) {
+	e := c.entry(s, key)
 	// make sure to exit the ttl go routine of a previously stored value
 	// before overwriting it
-	if ok && e.exit != nil {
-		e.exit <- struct{}{}
-	}
+	e.stop()
 
 	e.value = value
-	s.Entries[key] = e
+	e.version = c.versions.Add(1)
+	if ttl > 0 {
+		c.expireAfter(key, e, ttl)
+	}
+	c.logSet(key, e)
+	c.publish(EventSet, key, value)
 
-	s.Stats.Set++
+	s.stats.countSet()
 }
 
-// SetWithTTL stores the value with the given key and removes it automatically after
-// ttl seconds.
-func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
-	s := c.getShard(key)
-	s.Lock()
+// GetOrSet returns the value stored with the given key and true if there is
+// one. Otherwise it stores the given value like Set and returns it with
+// false. Both happen atomically.
+func (c *Cache) GetOrSet(key string, value interface{}) (actual interface{}, loaded bool) {
+	defer func() {
+		if !loaded {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; ok && !e.expired() {
+		e.renew()
+		s.stats.countHit()
+		return e.value, true
+	}
+
+	s.stats.countMiss()
+	if !c.writeThrough(key, value, c.defaultTTL) {
+		return value, false
+	}
+	c.set(s, key, value, c.defaultTTL)
+
+	return value, false
+}
+
+// SetIfAbsent stores the value with the given key like Set, unless a value
+// is stored with the key already. It reports whether the value was stored.
+// Checking and storing happen atomically.
+func (c *Cache) SetIfAbsent(key string, value interface{}) bool {
+	return c.SetIfAbsentWithTTL(key, value, c.defaultTTL)
+}
+
+// SetIfAbsentWithTTL stores the value with the given key like SetWithTTL,
+// unless a value is stored with the key already. It reports whether the
+// value was stored.
+func (c *Cache) SetIfAbsentWithTTL(key string, value interface{}, ttl time.Duration) (stored bool) {
+	defer func() {
+		if stored {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; ok && !e.expired() {
+		return false
+	}
+
+	if !c.writeThrough(key, value, ttl) {
+		return false
+	}
+	c.set(s, key, value, ttl)
+
+	return true
+}
+
+// Replace stores the value with the given key only if a value is stored
+// with the key already, keeping the ttl of the existing entry. It reports
+// whether the value was stored. Checking and storing happen atomically.
+func (c *Cache) Replace(key string, value interface{}) (stored bool) {
+	defer func() {
+		if stored {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
 	defer s.Unlock()
 
 	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		return false
+	}
+
+	return c.replace(s, key, e, value) == nil
+}
+
+// replace stores the value in an existing entry keeping its ttl. The shard
+// has to be locked for writing.
+func (c *Cache) replace(s *shard, key string, e *entry, value interface{}) error {
+	ttl := time.Duration(0)
+	if e.expires.Load() != 0 {
+		ttl = e.remaining()
+	}
+	if err := c.storeSet(key, value, ttl); err != nil {
+		return err
+	}
+
+	e.value = value
+	e.version = c.versions.Add(1)
+	c.logSet(key, e)
+	c.publish(EventSet, key, value)
+
+	s.stats.countSet()
+
+	return nil
+}
+
+// ReplaceWithTTL stores the value with the given key like SetWithTTL, but
+// only if a value is stored with the key already. It reports whether the
+// value was stored.
+func (c *Cache) ReplaceWithTTL(key string, value interface{}, ttl time.Duration) (stored bool) {
+	defer func() {
+		if stored {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; !ok || e.expired() {
+		return false
+	}
+
+	if !c.writeThrough(key, value, ttl) {
+		return false
+	}
+	c.set(s, key, value, ttl)
+
+	return true
+}
+
+// Swap stores the value with the given key like Set and returns the value
+// stored with the key before, if there was one. Both happen atomically.
+func (c *Cache) Swap(key string, value interface{}) (old interface{}, existed bool) {
+	defer c.invalidateOthers(key)
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; ok && !e.expired() {
+		old, existed = e.value, true
+	}
+
+	if !c.writeThrough(key, value, c.defaultTTL) {
+		return old, existed
+	}
+	c.set(s, key, value, c.defaultTTL)
+
+	return old, existed
+}
+
+// SetWithSlidingTTL stores the value with the given key and removes it
+// automatically once it has not been retrieved with Get for ttl seconds.
+func (c *Cache) SetWithSlidingTTL(key string, value interface{}, ttl time.Duration) {
+	defer c.invalidateOthers(key)
+	if c.latency != nil {
+		defer c.latency.set.since(time.Now())
+	}
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if !c.writeThrough(key, value, ttl) {
+		return
+	}
+
+	e := c.entry(s, key)
 	// make sure to exit the ttl go routine of a previously stored value
 	// before overwriting it
-	if ok && e.exit != nil {
-		e.exit <- struct{}{}
-	}
+	e.stop()
 
 	e.value = value
-	e.exit = make(chan struct{}, 1)
+	e.version = c.versions.Add(1)
+	e.sliding = true
+	c.expireAfter(key, e, ttl)
+	c.logSet(key, e)
+	c.publish(EventSet, key, value)
 
-	s.Entries[key] = e
-	s.Stats.Set++
+	s.stats.countSet()
+}
+
+// Touch sets the ttl of an existing entry without modifying its value. A ttl
+// set previously is replaced, entries with sliding expiration are renewed
+// with the new ttl from now on. If no value is stored with the given key
+// false is returned.
+func (c *Cache) Touch(key string, ttl time.Duration) bool {
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		return false
+	}
+
+	sliding := e.sliding
+	e.stop()
+	e.sliding = sliding
+	c.expireAfter(key, e, ttl)
+	c.logSet(key, e)
+
+	return true
+}
+
+// Persist removes the ttl of an existing entry so it is kept until it gets
+// removed explicitly. If no value is stored with the given key false is
+// returned.
+func (c *Cache) Persist(key string) bool {
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		return false
+	}
+
+	e.stop()
+	c.logSet(key, e)
+
+	return true
+}
+
+// entry returns the entry stored with the given key, adding an empty one if
+// there is none. Adding an entry to a full shard evicts another one. The
+// shard has to be locked for writing.
+func (c *Cache) entry(s *shard, key string) *entry {
+	e, ok := s.Entries[key]
+	if !ok {
+		if s.capacity > 0 && len(s.Entries) >= s.capacity {
+			c.evict(s)
+		}
+		e = newEntry()
+		e.accessed.Store(time.Now().UnixNano())
+		s.Entries[key] = e
+		s.peak = max(s.peak, len(s.Entries))
+	}
+	return e
+}
+
+// stop makes the ttl go routine of the entry exit, if there is one, and
+// resets its expiry.
+func (e *entry) stop() {
+	if e.exit != nil {
+		close(e.exit)
+		e.exit = nil
+	}
+	e.ttl = 0
+	e.sliding = false
+	e.expires.Store(0)
+}
+
+// remaining returns the time left until the entry expires.
+func (e *entry) remaining() time.Duration {
+	return time.Until(time.Unix(0, e.expires.Load()))
+}
+
+// renew records an access of the entry and pushes the expiry of an entry
+// with sliding expiration back by its ttl. It only modifies atomic fields and
+// is safe to call with the shard locked for reading.
+func (e *entry) renew() {
+	now := time.Now()
+	e.accessed.Store(now.UnixNano())
+	if e.sliding {
+		e.expires.Store(now.Add(e.ttl).UnixNano())
+	}
+}
+
+// expired reports whether the expiry of the entry has passed. Expired entries
+// are kept during the stale grace period of the cache.
+func (e *entry) expired() bool {
+	exp := e.expires.Load()
+	return exp != 0 && time.Now().UnixNano() >= exp
+}
+
+// expireAfter sets the expiry of the entry and starts its ttl go routine. The
+// shard has to be locked for writing.
+func (c *Cache) expireAfter(key string, e *entry, ttl time.Duration) {
+	c.expireAt(key, e, ttl, time.Now().Add(c.jitter(ttl)))
+}
+
+// expireAt sets the expiry of an entry stored with the given ttl to a fixed
+// point in time and starts its ttl go routine. The shard has to be locked for
+// writing.
+func (c *Cache) expireAt(key string, e *entry, ttl time.Duration, expires time.Time) {
+	e.ttl = ttl
+	e.expires.Store(expires.UnixNano())
+	e.exit = make(chan struct{})
 
 	// wait for the timeout concurrently
-	go func() {
-		for {
-			select {
-			case <-time.After(ttl):
-				c.Remove(key)
-				return
-			case <-e.exit:
-				c.Remove(key)
+	go c.expire(key, e.exit, time.Until(expires)+c.staleGrace)
+}
+
+// jitter randomizes ttl by up to the configured fraction in both directions.
+func (c *Cache) jitter(ttl time.Duration) time.Duration {
+	if c.ttlJitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration((rand.Float64()*2-1)*c.ttlJitter*float64(ttl))
+}
+
+// expire waits for ttl to elapse and removes the entry with the given key
+// afterwards, unless exit gets closed first.
+func (c *Cache) expire(key string, exit chan struct{}, ttl time.Duration) {
+	t := time.NewTimer(ttl)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			// the entry might have been renewed in the meantime
+			d := c.removeExpired(key, exit)
+			if d <= 0 {
 				return
 			}
+			t.Reset(d)
+		case <-exit:
+			return
 		}
-	}()
+	}
+}
+
+// removeExpired deletes the entry with the given key if it is still owned by
+// the ttl go routine listening on exit and its expiry including the stale
+// grace period has passed. Otherwise the time left until then is returned.
+func (c *Cache) removeExpired(key string, exit chan struct{}) time.Duration {
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.exit != exit {
+		return 0
+	}
+
+	if d := e.remaining() + c.staleGrace; d > 0 {
+		return d
+	}
+
+	e.exit = nil
+	delete(s.Entries, key)
+	c.replicas.send(&logRecord{Remove: true, Key: key})
+	c.publish(EventExpire, key, e.value)
+	c.logf(slog.LevelDebug, "entry expired", "key", key)
+	c.removed(key, e.value, Expired)
+	s.stats.countExpire()
+	recycle(e)
+
+	return 0
 }
 
 func (c *Cache) getShard(key string) *shard {
-	h := fnv.New32()
-	h.Write([]byte(key))
-	return (*c)[uint(h.Sum32())%uint(shards)]
+	return c.shard(c.shardIndex(key))
+}
+
+// lockShard returns the shard holding key locked for writing. If Resize
+// migrated the entries of the shard meanwhile, the shard they were moved to
+// is locked instead.
+func (c *Cache) lockShard(key string) *shard {
+	h := c.hasher.Hash(key)
+	shards := *c.shards.Load()
+	for {
+		s := shards[h%uint64(len(shards))]
+		s.Lock()
+		if s.moved == nil {
+			return s
+		}
+		shards = s.moved
+		s.Unlock()
+	}
+}
+
+// rlockShard returns the shard holding key read locked like lockShard.
+func (c *Cache) rlockShard(key string) *shard {
+	h := c.hasher.Hash(key)
+	shards := *c.shards.Load()
+	for {
+		s := shards[h%uint64(len(shards))]
+		s.RLock()
+		if s.moved == nil {
+			return s
+		}
+		shards = s.moved
+		s.RUnlock()
+	}
+}
+
+// shardIndex returns the index of the shard holding key.
+func (c *Cache) shardIndex(key string) int {
+	return int(c.hasher.Hash(key) % uint64(c.len()))
 }
 
 // Get retrieves a value stored with a specific key. If no value is available
-// nil and false will be returned.
+// nil and false will be returned. Entries stored with a sliding ttl are
+// renewed. If the cache has a Loader, missing values are loaded and false is
+// only returned if loading fails.
 func (c *Cache) Get(key string) (interface{}, bool) {
-	s := c.getShard(key)
-	s.RLock()
+	if c.latency != nil {
+		defer c.latency.get.since(time.Now())
+	}
+
+	if v, ok := c.get(key); ok || c.loader == nil {
+		return v, ok
+	}
+
+	v, err := c.load(context.Background(), key)
+
+	return v, err == nil
+}
+
+func (c *Cache) get(key string) (interface{}, bool) {
+	s := c.rlockShard(key)
 	defer s.RUnlock()
 
-	v, ok := s.Entries[key]
+	if s.hot != nil {
+		s.hot.add(key)
+	}
 
-	if ok {
-		s.Stats.Hits++
-		return v.value, true
+	e, ok := s.Entries[key]
+
+	if ok && !e.expired() {
+		c.refreshIfDue(key, e)
+		e.renew()
+		s.stats.countHit()
+		return e.value, true
+	}
+
+	s.stats.countMiss()
+
+	return nil, false
+}
+
+// Peek returns the value stored with the given key like Get, but without
+// counting a hit or miss, renewing sliding entries, updating the recency used
+// for eviction or invoking the Loader.
+func (c *Cache) Peek(key string) (interface{}, bool) {
+	s := c.rlockShard(key)
+	defer s.RUnlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+// Has reports whether a value is stored with the given key. Like Peek it
+// does not count as an access.
+func (c *Cache) Has(key string) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// TTL returns the time left until the value stored with the given key
+// expires, NoExpiration if it does not expire. If no value is stored with the
+// given key false is returned. Unlike Get it does not count as an access.
+func (c *Cache) TTL(key string) (time.Duration, bool) {
+	s := c.rlockShard(key)
+	defer s.RUnlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		return 0, false
 	}
 
-	s.Stats.Misses++
+	if e.expires.Load() == 0 {
+		return NoExpiration, true
+	}
+
+	return e.remaining(), true
+}
 
-	return nil, ok
+// DefaultTTL returns the ttl Set uses for new values, zero if they do not
+// expire.
+func (c *Cache) DefaultTTL() time.Duration {
+	return c.defaultTTL
+}
+
+// Len returns the number of entries in the cache, excluding expired entries
+// kept for their stale grace period.
+func (c *Cache) Len() int {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	n := 0
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.RLock()
+		for _, e := range s.Entries {
+			if !e.expired() {
+				n++
+			}
+		}
+		s.RUnlock()
+	}
+
+	return n
 }
 
 // Remove deletes a value stored with the given key from the cache.
 // In case no value exists no action is performed.
 func (c *Cache) Remove(key string) {
-	s := c.getShard(key)
-	s.Lock()
+	c.remove(key)
+}
+
+// remove deletes a value like Remove and reports whether there was one.
+func (c *Cache) remove(key string) bool {
+	ok, _ := c.removeCtx(context.Background(), key)
+	return ok
+}
+
+// removeCtx deletes a value like remove, passing ctx to a ContextStore, and
+// returns the error of the Store.
+func (c *Cache) removeCtx(ctx context.Context, key string) (bool, error) {
+	defer c.invalidateOthers(key)
+	if c.latency != nil {
+		defer c.latency.remove.since(time.Now())
+	}
+
+	s := c.lockShard(key)
 	defer s.Unlock()
 
+	if err := c.storeRemoveCtx(ctx, key); err != nil {
+		return false, err
+	}
+
 	e, ok := s.Entries[key]
 	if ok {
-		if e.exit != nil {
-			e.exit <- struct{}{}
+		e.stop()
+		delete(s.Entries, key)
+		c.logRemove(key)
+		c.publish(EventRemove, key, e.value)
+		c.removed(key, e.value, Removed)
+		s.stats.countRemove()
+		recycle(e)
+	}
+
+	return ok, nil
+}
+
+// GetAndDelete returns the value stored with the given key like Get and
+// deletes it like Remove. Both happen atomically, so only one of several
+// concurrent callers gets the value. The Loader of the cache is not invoked
+// for missing keys. If the value cannot be removed from the Store it is kept
+// and false is returned.
+func (c *Cache) GetAndDelete(key string) (value interface{}, ok bool) {
+	defer func() {
+		if ok {
+			c.invalidateOthers(key)
 		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		s.stats.countMiss()
+		return nil, false
+	}
+
+	if !c.removeThrough(key) {
+		return nil, false
+	}
+	s.stats.countHit()
+
+	e.stop()
+	delete(s.Entries, key)
+	c.logRemove(key)
+	c.publish(EventRemove, key, e.value)
+	c.removed(key, e.value, Removed)
+	s.stats.countRemove()
+
+	value = e.value
+	recycle(e)
+
+	return value, true
+}
+
+// removeLocal deletes an entry removed elsewhere, e.g. on the primary or by
+// another instance, without writing the removal through to the Store.
+func (c *Cache) removeLocal(key string) {
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; ok {
+		e.stop()
 		delete(s.Entries, key)
-		s.Stats.Removed++
+		c.logRemove(key)
+		c.publish(EventRemove, key, e.value)
+		c.removed(key, e.value, Removed)
+		s.stats.countRemove()
+		recycle(e)
 	}
 }
 
+// len and shard access the current shards. Callers iterating all shards
+// hold resizing read locked, so Resize does not replace them meanwhile.
 func (c *Cache) len() int {
-	return len(*c)
+	return len(*c.shards.Load())
 }
 
 func (c *Cache) shard(n int) *shard {
-	return (*c)[n]
+	return (*c.shards.Load())[n]
 }
 
-// GetStats returns Stats for this cache instance.
+// GetStats returns Stats for this cache instance. Counters are read without
+// locking and shards are only read locked to count their entries, so
+// collecting stats does not block readers. As shards are read one after
+// another the stats are not an atomic snapshot of the whole cache.
 func (c *Cache) GetStats() *Stats {
-	s := Stats{}
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	s := Stats{Uptime: c.created}
 
 	for i := 0; i < c.len(); i++ {
-		shrd := c.shard(i)
-		shrd.Lock()
+		shrd := c.shard(i).getStats()
 
-		if i == 0 {
-			s.Uptime = shrd.Stats.Uptime
-		}
-		s.Hits += shrd.Stats.Hits
-		s.Misses += shrd.Stats.Misses
-		s.Set += shrd.Stats.Set
-		s.Removed += shrd.Stats.Removed
+		s.Hits += shrd.Hits
+		s.Misses += shrd.Misses
+		s.Set += shrd.Set
+		s.Removed += shrd.Removed
+		s.Expired += shrd.Expired
+		s.Evicted += shrd.Evicted
+		s.Entries += shrd.Entries
+	}
+	s.SetHitRatio()
 
-		shrd.Unlock()
+	if c.windows != nil {
+		s.Windows = c.windows.stats(time.Now(), s.Hits, s.Misses)
+	}
+	if c.latency != nil {
+		s.Latency = c.latency.snapshot()
 	}
 
 	return &s
 }
+
+// GetShardStats returns the Stats of each shard, e.g. to detect shards
+// holding more keys or receiving more accesses than others. Like GetStats it
+// does not block readers.
+func (c *Cache) GetShardStats() []Stats {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	stats := make([]Stats, c.len())
+	for i := range stats {
+		stats[i] = c.shard(i).getStats()
+		stats[i].Uptime = c.created
+		stats[i].SetHitRatio()
+	}
+
+	return stats
+}
+
+// countHit, countMiss, countSet, countRemove, countExpire and countEvict
+// increment the respective counter unless stats are disabled.
+func (s *counters) countHit() {
+	if s != nil {
+		s.hits.Add(1)
+	}
+}
+
+func (s *counters) countMiss() {
+	if s != nil {
+		s.misses.Add(1)
+	}
+}
+
+func (s *counters) countSet() {
+	if s != nil {
+		s.set.Add(1)
+	}
+}
+
+func (s *counters) countRemove() {
+	if s != nil {
+		s.removed.Add(1)
+	}
+}
+
+func (s *counters) countExpire() {
+	if s != nil {
+		s.expired.Add(1)
+	}
+}
+
+func (s *counters) countEvict() {
+	if s != nil {
+		s.evicted.Add(1)
+	}
+}
+
+// ResetStats sets all counters to zero, e.g. to measure the stats of an
+// interval. Uptime and the number of entries are kept. Windowed stats start
+// over as well.
+func (c *Cache) ResetStats() {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	for i := 0; i < c.len(); i++ {
+		c.shard(i).stats.reset()
+	}
+
+	if c.windows != nil {
+		c.windows.reset(time.Now())
+	}
+	if c.latency != nil {
+		c.latency.reset()
+	}
+}
+
+// reset sets all counters to zero.
+func (s *counters) reset() {
+	if s == nil {
+		return
+	}
+
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.set.Store(0)
+	s.removed.Store(0)
+	s.expired.Store(0)
+	s.evicted.Store(0)
+}
+
+// getStats returns the counters and number of entries of the shard.
+func (s *shard) getStats() Stats {
+	s.RLock()
+	entries := len(s.Entries)
+	s.RUnlock()
+
+	if s.stats == nil {
+		return Stats{Entries: entries}
+	}
+
+	return Stats{
+		Hits:    int(s.stats.hits.Load()),
+		Misses:  int(s.stats.misses.Load()),
+		Set:     int(s.stats.set.Load()),
+		Removed: int(s.stats.removed.Load()),
+		Expired: int(s.stats.expired.Load()),
+		Evicted: int(s.stats.evicted.Load()),
+		Entries: entries,
+	}
+}
+
+// SetHitRatio sets HitRatio to the fraction of Hits in Hits and Misses, for
+// Layer implementations computing their Stats.
+func (s *Stats) SetHitRatio() {
+	s.HitRatio = 0
+	if n := s.Hits + s.Misses; n > 0 {
+		s.HitRatio = float64(s.Hits) / float64(n)
+	}
+}
+
+// Close stops the background work of the cache, flushes pending writes to
+// the Store, saves a final automatic snapshot, disconnects replicas and closes
+// subscriptions. The cache must not be used after it has been closed.
+func (c *Cache) Close() error {
+	c.replicas.close()
+	c.subscriptions.close()
+	c.keyspace.close()
+
+	if c.windows != nil {
+		c.windows.close()
+	}
+
+	if c.shrinker != nil {
+		c.shrinker.close()
+	}
+
+	if c.writer != nil {
+		c.writer.close()
+	}
+
+	var err error
+	if c.snapshots != nil {
+		err = c.snapshots.close(c)
+	}
+
+	if c.aof != nil {
+		if e := c.aof.close(); err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
@@ -3,6 +3,8 @@ package cache
 import (
 	"math/rand"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -149,6 +151,11 @@ func TestStats(t *testing.T) {
 		t.Fail()
 	}
 
+	if s.Entries != 100 {
+		t.Errorf("Expected 100 entries. Got %d", s.Entries)
+		t.Fail()
+	}
+
 	for i := 0; i < 100; i++ {
 		c.Remove(key + strconv.Itoa(i))
 	}
@@ -165,6 +172,11 @@ func TestStats(t *testing.T) {
 		t.Fail()
 	}
 
+	if s.Entries != 0 {
+		t.Errorf("Expected no entries. Got %d", s.Entries)
+		t.Fail()
+	}
+
 	for i := 0; i < 100; i++ {
 		c.Get(key + strconv.Itoa(i))
 	}
@@ -181,6 +193,177 @@ func TestStats(t *testing.T) {
 		t.Fail()
 	}
 
+	if s.HitRatio != 0.5 {
+		t.Errorf("Expected a hit ratio of 0.5. Got %f", s.HitRatio)
+		t.Fail()
+	}
+}
+
+func TestStatsConcurrent(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Get(key)
+				c.Get("testOtherKey")
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := c.GetStats()
+	if s.Hits != 1000 || s.Misses != 1000 {
+		t.Errorf("Expected 1000 hits and misses. Got %d and %d", s.Hits, s.Misses)
+		t.Fail()
+	}
+}
+
+func TestGetShardStats(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+	c.Get(key)
+
+	stats := c.GetShardStats()
+	if len(stats) != DefaultShards {
+		t.Errorf("Expected %d shards. Got %d", DefaultShards, len(stats))
+		t.FailNow()
+	}
+
+	entries := 0
+	for i, s := range stats {
+		entries += s.Entries
+		if c.shard(i) == c.getShard(key) && (s.Entries != 1 || s.Hits != 1 || s.HitRatio != 1) {
+			t.Errorf("Unexpected stats of the shard of the key %+v", s)
+			t.Fail()
+		}
+	}
+
+	if entries != 1 {
+		t.Errorf("Expected 1 entry. Got %d", entries)
+		t.Fail()
+	}
+}
+
+func TestResetStats(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithWindowedStats())
+	defer c.Close()
+
+	c.Set(key, value)
+	c.Get(key)
+	c.Get("testOtherKey")
+
+	uptime := c.GetStats().Uptime
+
+	c.ResetStats()
+	c.Get(key)
+
+	s := c.GetStats()
+	if s.Hits != 1 || s.Misses != 0 || s.Set != 0 {
+		t.Errorf("Expected counters to be reset. Got %+v", s)
+		t.Fail()
+	}
+
+	if s.Entries != 1 || s.Uptime != uptime {
+		t.Errorf("Expected entries and uptime to be kept. Got %+v", s)
+		t.Fail()
+	}
+
+	if s.Windows[0].Hits != 1 || s.Windows[0].Misses != 0 {
+		t.Errorf("Expected windowed stats to be reset. Got %+v", s.Windows[0])
+		t.Fail()
+	}
+}
+
+func TestWithStatsDisabled(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithStatsDisabled())
+	c.Set(key, value)
+	c.Get(key)
+	c.Get("testOtherKey")
+	c.ResetStats()
+
+	s := c.GetStats()
+	if s.Hits != 0 || s.Misses != 0 || s.Set != 0 {
+		t.Errorf("Expected no counts. Got %+v", s)
+		t.Fail()
+	}
+
+	if s.Entries != 1 {
+		t.Errorf("Expected 1 entry. Got %d", s.Entries)
+		t.Fail()
+	}
+}
+
+func TestLen(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithStaleGrace(time.Minute))
+
+	for i := 0; i < 10; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	if n := c.Len(); n != 11 {
+		t.Errorf("Expected 11 entries. Got %d", n)
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	c.Remove(key + "0")
+
+	if n := c.Len(); n != 9 {
+		t.Errorf("Expected 9 entries. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestPeek(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.SetWithSlidingTTL(key, value, 50*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if v, ok := c.Peek(key); i < 2 && (!ok || v != value) {
+			t.Error("Expected", value, "got", v)
+			t.Fail()
+		}
+	}
+
+	if c.Has(key) {
+		t.Error("Peek should not have renewed the entry.")
+		t.Fail()
+	}
+
+	if c.Has("testOtherKey") {
+		t.Error("Missing key should not have been found.")
+		t.Fail()
+	}
+
+	if s := c.GetStats(); s.Hits != 0 || s.Misses != 0 {
+		t.Errorf("Expected no hits or misses. Got %d and %d", s.Hits, s.Misses)
+		t.Fail()
+	}
 }
 
 func TestStatsTTL(t *testing.T) {
@@ -231,8 +414,13 @@ func TestStatsTTL(t *testing.T) {
 		t.Fail()
 	}
 
-	if s.Removed != 100 || s.Removed != s.Set {
-		t.Errorf("Expected 100 values to be removed. Got %d", s.Removed)
+	if s.Expired != 100 || s.Expired != s.Set {
+		t.Errorf("Expected 100 values to be expired. Got %d", s.Expired)
+		t.Fail()
+	}
+
+	if s.Removed != 0 {
+		t.Errorf("Expected expired values not to count as removed. Got %d", s.Removed)
 		t.Fail()
 	}
 
@@ -333,3 +521,438 @@ func BenchmarkCacheTTLParallel(b *testing.B) {
 		}
 	})
 }
+
+func TestTouch(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if c.Touch(key, time.Millisecond) {
+		t.Error("Touch should fail for a missing key.")
+		t.Fail()
+	}
+
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	if !c.Touch(key, 50*time.Millisecond) {
+		t.Error("Could not touch test element in cache.")
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	v, ok := c.Get(key)
+	if !ok {
+		t.Error("Element should not have been removed.")
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	c.Touch(key, 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestPersist(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if c.Persist(key) {
+		t.Error("Persist should fail for a missing key.")
+		t.Fail()
+	}
+
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	if !c.Persist(key) {
+		t.Error("Could not persist test element in cache.")
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Element should not have been removed.")
+		t.Fail()
+	}
+}
+
+func TestTouchPersistExpired(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithStaleGrace(time.Minute))
+
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if c.Touch(key, time.Hour) {
+		t.Error("Touch should fail for an expired key.")
+		t.Fail()
+	}
+
+	if c.Persist(key) {
+		t.Error("Persist should fail for an expired key.")
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Expired element should not have been revived.")
+		t.Fail()
+	}
+}
+
+func TestTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if _, ok := c.TTL(key); ok {
+		t.Error("TTL should fail for a missing key.")
+		t.Fail()
+	}
+
+	c.Set(key, value)
+
+	if ttl, ok := c.TTL(key); !ok || ttl != NoExpiration {
+		t.Error("Expected", NoExpiration, "got", ttl)
+		t.Fail()
+	}
+
+	c.SetWithTTL(key, value, time.Minute)
+
+	if ttl, ok := c.TTL(key); !ok || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Error("Expected remaining ttl, got", ttl)
+		t.Fail()
+	}
+
+	if c.GetStats().Hits != 0 {
+		t.Error("TTL should not count as a hit.")
+		t.Fail()
+	}
+}
+
+func TestSetOverwritesTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+	c.Set(key, value)
+	c.Set(key, value)
+	c.Set(key, value)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Element should not have been removed.")
+		t.Fail()
+	}
+}
+
+func TestSetWithSlidingTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	ttl := 30 * time.Millisecond
+
+	c := New()
+
+	c.SetWithSlidingTTL(key, value, ttl)
+
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		if _, ok := c.Get(key); !ok {
+			t.Error("Element should have been renewed.")
+			t.Fail()
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestGetOrSet(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	v, loaded := c.GetOrSet(key, value)
+	if loaded {
+		t.Error("Value should have been stored.")
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	v, loaded = c.GetOrSet(key, "otherValue")
+	if !loaded {
+		t.Error("Value should have been loaded.")
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestGetOrSetParallel(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	var wg sync.WaitGroup
+	var stored int32
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, loaded := c.GetOrSet(key, i); !loaded {
+				atomic.AddInt32(&stored, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stored != 1 {
+		t.Errorf("Expected value to be stored once. Got %d", stored)
+		t.Fail()
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if !c.SetIfAbsent(key, value) {
+		t.Error("Value should have been stored.")
+		t.Fail()
+	}
+
+	if c.SetIfAbsent(key, "otherValue") {
+		t.Error("Value should not have been stored.")
+		t.Fail()
+	}
+
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	c.SetWithTTL("expiring", value, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if !c.SetIfAbsentWithTTL("expiring", "otherValue", time.Hour) {
+		t.Error("Value should have replaced the expired entry.")
+		t.Fail()
+	}
+}
+
+func TestSetIfAbsentParallel(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	var wg sync.WaitGroup
+	var stored int32
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if c.SetIfAbsent(key, i) {
+				atomic.AddInt32(&stored, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stored != 1 {
+		t.Errorf("Expected value to be stored once. Got %d", stored)
+		t.Fail()
+	}
+}
+
+func TestReplace(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if c.Replace(key, value) {
+		t.Error("Value should not have been stored.")
+		t.Fail()
+	}
+
+	if c.Has(key) {
+		t.Error("Replace should not add entries.")
+		t.Fail()
+	}
+
+	c.SetWithTTL(key, value, time.Hour)
+	if !c.Replace(key, "otherValue") {
+		t.Error("Value should have been stored.")
+		t.Fail()
+	}
+
+	items := c.Items()
+	if items[key].Value != "otherValue" {
+		t.Error("Expected otherValue got", items[key].Value)
+		t.Fail()
+	}
+
+	if ttl := items[key].TTL; ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Error("Expected the ttl to be kept, got", ttl)
+		t.Fail()
+	}
+
+	if !c.ReplaceWithTTL(key, value, NoExpiration) {
+		t.Error("Value should have been stored.")
+		t.Fail()
+	}
+
+	if ttl := c.Items()[key].TTL; ttl != NoExpiration {
+		t.Error("Expected no expiration got", ttl)
+		t.Fail()
+	}
+}
+
+func TestGetAndDelete(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if _, ok := c.GetAndDelete(key); ok {
+		t.Error("Expected no value.")
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	if v, ok := c.GetAndDelete(key); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if c.Has(key) {
+		t.Error("Value should have been deleted.")
+		t.Fail()
+	}
+}
+
+func TestGetAndDeleteParallel(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	var wg sync.WaitGroup
+	var claimed int32
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := c.GetAndDelete(key); ok {
+				atomic.AddInt32(&claimed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed != 1 {
+		t.Errorf("Expected value to be claimed once. Got %d", claimed)
+		t.Fail()
+	}
+}
+
+func TestSwap(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if old, existed := c.Swap(key, value); existed || old != nil {
+		t.Error("Expected no previous value, got", old)
+		t.Fail()
+	}
+
+	if old, existed := c.Swap(key, "otherValue"); !existed || old != value {
+		t.Error("Expected", value, "got", old)
+		t.Fail()
+	}
+
+	if v, _ := c.Get(key); v != "otherValue" {
+		t.Error("Expected otherValue got", v)
+		t.Fail()
+	}
+}
+
+func BenchmarkCacheStatsDisabledParallel(b *testing.B) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithStatsDisabled())
+	c.Set(key, value)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Get(key)
+		}
+	})
+}
+
+func BenchmarkGetStatsParallel(b *testing.B) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				c.GetStats()
+			}
+		}
+	}()
+	defer close(done)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Get(key)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec serializes values for layers and files keeping them outside of the
+// process memory.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// GobCodec encodes values with encoding/gob. Concrete types of stored values
+// have to be registered with gob.Register.
+type GobCodec struct{}
+
+// Marshal encodes v.
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a value encoded with Marshal.
+func (GobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// JSONCodec encodes values with encoding/json. Values are decoded into the
+// generic types of encoding/json, e.g. map[string]interface{} for structs.
+type JSONCodec struct{}
+
+// Marshal encodes v.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes a value encoded with Marshal.
+func (JSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
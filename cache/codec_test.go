@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"encoding/gob"
+	"testing"
+)
+
+func TestGobCodec(t *testing.T) {
+	gob.Register(testType{})
+
+	value := testType{
+		Val1: "testValue",
+		Val2: 42,
+	}
+
+	var codec GobCodec
+
+	data, err := codec.Marshal(value)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	v, err := codec.Unmarshal(data)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	value := "testValue"
+
+	var codec JSONCodec
+
+	data, err := codec.Marshal(value)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	v, err := codec.Unmarshal(data)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// GetCtx retrieves the value stored with the given key like Fetch, but
+// returns the error of ctx once it is done, also while waiting for a load
+// started by another caller. The Loader is called with a context carrying the
+// values but not the cancellation of ctx, so the load continues in the
+// background for other callers and its value is stored for later calls.
+// Without a Loader ErrNotFound is returned on a miss.
+func (c *Cache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.latency != nil {
+		defer c.latency.get.since(time.Now())
+	}
+
+	if v, ok := c.get(key); ok {
+		return v, nil
+	}
+
+	if c.loader == nil {
+		return nil, ErrNotFound
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		v, err := c.load(context.WithoutCancel(ctx), key)
+		done <- result{v, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SetCtx stores the value with the given key like Set unless ctx is done.
+// The context is passed to the Store of the cache if it is a ContextStore,
+// errors of the Store are returned as *StoreError.
+func (c *Cache) SetCtx(ctx context.Context, key string, value interface{}) error {
+	return c.SetWithTTLCtx(ctx, key, value, c.defaultTTL)
+}
+
+// SetWithTTLCtx stores the value with the given key like SetWithTTL unless
+// ctx is done. The context is passed to the Store like by SetCtx.
+func (c *Cache) SetWithTTLCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err == nil {
+			c.invalidateOthers(key)
+		}
+	}()
+	if c.latency != nil {
+		defer c.latency.set.since(time.Now())
+	}
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if err := c.storeSetCtx(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	c.set(s, key, value, ttl)
+
+	return nil
+}
+
+// RemoveCtx deletes the value stored with the given key like Remove unless
+// ctx is done. The context is passed to the Store like by SetCtx.
+func (c *Cache) RemoveCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := c.removeCtx(ctx, key)
+
+	return err
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testContextStore fails operations with the error of their context.
+type testContextStore struct {
+	*testStore
+}
+
+func (s testContextStore) SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Set(key, value, ttl)
+}
+
+func (s testContextStore) RemoveCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Remove(key)
+}
+
+func TestGetCtx(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	release := make(chan struct{})
+	c := New(WithLoader(LoaderFunc(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		select {
+		case <-release:
+			return value, 0, nil
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// a second caller waits for the load started with the short deadline
+	loaded := make(chan error, 1)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		v, err := c.GetCtx(context.Background(), key)
+		if err == nil && v != value {
+			err = errors.New("unexpected value")
+		}
+		loaded <- err
+	}()
+
+	if _, err := c.GetCtx(ctx, key); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected", context.DeadlineExceeded, "got", err)
+		t.Fail()
+	}
+
+	close(release)
+
+	if err := <-loaded; err != nil {
+		t.Error("Expected", value, "for the second caller, got", err)
+		t.Fail()
+	}
+
+	if v, err := c.GetCtx(context.Background(), key); err != nil || v != value {
+		t.Error("Expected", value, "got", v, err)
+		t.Fail()
+	}
+
+	if _, err := c.GetCtx(ctx, key); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected", context.DeadlineExceeded, "for a done context, got", err)
+		t.Fail()
+	}
+
+	if _, err := New().GetCtx(context.Background(), key); err != ErrNotFound {
+		t.Error("Expected", ErrNotFound, "got", err)
+		t.Fail()
+	}
+}
+
+func TestSetCtx(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+
+	c := New(WithStore(testContextStore{store}))
+
+	if err := c.SetCtx(context.Background(), key, value); err != nil {
+		t.Error("Failed to set value:", err)
+		t.Fail()
+	}
+	if v, ok := store.get(key); !ok || v != value {
+		t.Error("Element should have been written to the store.")
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.SetWithTTLCtx(ctx, key, value+"New", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Error("Expected", context.Canceled, "got", err)
+		t.Fail()
+	}
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if err := c.RemoveCtx(ctx, key); !errors.Is(err, context.Canceled) {
+		t.Error("Expected", context.Canceled, "got", err)
+		t.Fail()
+	}
+	if !c.Has(key) {
+		t.Error("Element should not have been removed.")
+		t.Fail()
+	}
+
+	if err := c.RemoveCtx(context.Background(), key); err != nil {
+		t.Error("Failed to remove value:", err)
+		t.Fail()
+	}
+	if _, ok := store.get(key); ok || c.Has(key) {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestSetCtxStoreError(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+	store.err = errors.New("unavailable")
+
+	c := New(WithStore(testContextStore{store}))
+
+	var serr *StoreError
+	if err := c.SetCtx(context.Background(), key, value); !errors.As(err, &serr) || serr.Op != "set" {
+		t.Error("Expected a StoreError, got", err)
+		t.Fail()
+	}
+	if c.Has(key) {
+		t.Error("Element should not have been stored.")
+		t.Fail()
+	}
+}
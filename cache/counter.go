@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNotInteger is returned by Increment and Decrement if the value stored
+// with a key is not an int, int32 or int64.
+var ErrNotInteger = errors.New("cache: value is not an integer")
+
+// ErrOverflow is returned by Increment and Decrement if the result does not
+// fit into the type of the stored value.
+var ErrOverflow = errors.New("cache: integer overflow")
+
+// Increment adds delta to the integer stored with the given key and returns
+// the result. Missing keys are treated as zero and stored with the default
+// ttl of the cache as int64, existing entries keep their ttl and the type of
+// their value. Reading and storing happen atomically. If the result cannot be
+// written through to the Store the StoreError is returned.
+func (c *Cache) Increment(key string, delta int64) (n int64, err error) {
+	defer func() {
+		if err == nil {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		if err := c.storeSet(key, delta, c.defaultTTL); err != nil {
+			return 0, err
+		}
+		c.set(s, key, delta, c.defaultTTL)
+		return delta, nil
+	}
+
+	value, n, err := add(e.value, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.replace(s, key, e, value); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Decrement subtracts delta from the integer stored with the given key like
+// Increment.
+func (c *Cache) Decrement(key string, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return c.Increment(key, -delta)
+}
+
+// add returns the sum of the integer v and delta with the type of v and as
+// int64.
+func add(v interface{}, delta int64) (interface{}, int64, error) {
+	var n int64
+	switch v := v.(type) {
+	case int:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	default:
+		return nil, 0, ErrNotInteger
+	}
+
+	sum := n + delta
+	if (delta > 0 && sum < n) || (delta < 0 && sum > n) {
+		return nil, 0, ErrOverflow
+	}
+
+	switch v.(type) {
+	case int:
+		if int64(int(sum)) != sum {
+			return nil, 0, ErrOverflow
+		}
+		return int(sum), sum, nil
+	case int32:
+		if sum < math.MinInt32 || sum > math.MaxInt32 {
+			return nil, 0, ErrOverflow
+		}
+		return int32(sum), sum, nil
+	}
+
+	return sum, sum, nil
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrement(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	if n, err := c.Increment(key, 5); err != nil || n != 5 {
+		t.Errorf("Expected 5. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if n, err := c.Decrement(key, 7); err != nil || n != -2 {
+		t.Errorf("Expected -2. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if v, _ := c.Get(key); v != int64(-2) {
+		t.Error("Expected int64 -2 got", v)
+		t.Fail()
+	}
+}
+
+func TestIncrementKeepsType(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+	c.SetWithTTL(key, 1, time.Hour)
+
+	if n, err := c.Increment(key, 1); err != nil || n != 2 {
+		t.Errorf("Expected 2. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	item := c.Items()[key]
+	if item.Value != 2 {
+		t.Error("Expected int 2 got", item.Value)
+		t.Fail()
+	}
+
+	if item.TTL <= 59*time.Minute {
+		t.Error("Expected the ttl to be kept, got", item.TTL)
+		t.Fail()
+	}
+}
+
+func TestIncrementErrors(t *testing.T) {
+	value := "testValue"
+
+	c := New()
+	c.Set("string", value)
+	c.Set("int32", int32(math.MaxInt32))
+	c.Set("int64", int64(math.MinInt64))
+
+	if _, err := c.Increment("string", 1); !errors.Is(err, ErrNotInteger) {
+		t.Error("Expected ErrNotInteger got", err)
+		t.Fail()
+	}
+
+	if _, err := c.Increment("int32", 1); !errors.Is(err, ErrOverflow) {
+		t.Error("Expected ErrOverflow got", err)
+		t.Fail()
+	}
+
+	if _, err := c.Decrement("int64", 1); !errors.Is(err, ErrOverflow) {
+		t.Error("Expected ErrOverflow got", err)
+		t.Fail()
+	}
+
+	if v, _ := c.Get("int32"); v != int32(math.MaxInt32) {
+		t.Error("Value should not have been modified, got", v)
+		t.Fail()
+	}
+
+	store := newTestStore()
+	store.err = errors.New("unavailable")
+	c = New(WithStore(store))
+
+	var storeErr *StoreError
+	if _, err := c.Increment("missing", 1); !errors.As(err, &storeErr) {
+		t.Error("Expected a StoreError got", err)
+		t.Fail()
+	}
+}
+
+func TestIncrementParallel(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Increment(key, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, _ := c.Get(key); v != int64(1000) {
+		t.Error("Expected 1000 got", v)
+		t.Fail()
+	}
+}
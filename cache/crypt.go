@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// EncryptionKeyEnv is the environment variable the encryption key for
+// snapshots and the append-only log is read from if it is not set with
+// WithEncryptionKey. It has to hold a base64 encoded AES-128, AES-192 or
+// AES-256 key.
+const EncryptionKeyEnv = "LAYERCAKE_ENCRYPTION_KEY"
+
+// chunkSize is the amount of plaintext encrypted at once in snapshots.
+const chunkSize = 64 << 10
+
+// ErrNoEncryptionKey is returned when reading encrypted data without an
+// encryption key.
+var ErrNoEncryptionKey = errors.New("cache: data is encrypted but no encryption key is set")
+
+// ErrDecrypt is returned when encrypted data cannot be decrypted, because the
+// key is wrong or the data has been modified or truncated.
+var ErrDecrypt = errors.New("cache: cannot decrypt data")
+
+// encryption holds the cipher used for files written by the cache. If the
+// configured key is invalid err is set and nothing is written unencrypted.
+type encryption struct {
+	aead cipher.AEAD
+	err  error
+}
+
+func newEncryption(key []byte) *encryption {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return &encryption{err: err}
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return &encryption{err: err}
+	}
+
+	return &encryption{aead: aead}
+}
+
+// encryptionFromEnv returns the encryption configured by EncryptionKeyEnv or
+// nil if it is not set.
+func encryptionFromEnv() *encryption {
+	v := os.Getenv(EncryptionKeyEnv)
+	if v == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return &encryption{err: err}
+	}
+
+	return newEncryption(key)
+}
+
+// seal encrypts data with a random nonce, which is prepended to the result.
+func seal(aead cipher.AEAD, data, ad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, data, ad), nil
+}
+
+// unseal decrypts data encrypted with seal.
+func unseal(aead cipher.AEAD, data, ad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plain, nil
+}
+
+// chunkAD returns the additional data authenticating the position of a chunk
+// and whether it is the last one, so chunks cannot be reordered or dropped.
+func chunkAD(index uint64, last bool) []byte {
+	ad := make([]byte, 9)
+	binary.BigEndian.PutUint64(ad, index)
+	if last {
+		ad[8] = 1
+	}
+	return ad
+}
+
+// encryptWriter encrypts everything written to it in chunks. Close has to be
+// called to write the last chunk.
+type encryptWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+}
+
+func newEncryptWriter(w io.Writer, aead cipher.AEAD) *encryptWriter {
+	return &encryptWriter{w: w, aead: aead, buf: make([]byte, 0, chunkSize)}
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		m := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		p = p[m:]
+
+		if len(w.buf) == cap(w.buf) {
+			if err := w.writeChunk(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close writes the last chunk. It does not close the underlying writer.
+func (w *encryptWriter) Close() error {
+	return w.writeChunk(true)
+}
+
+// writeChunk writes the buffered plaintext as a chunk consisting of a flag
+// marking the last chunk, the length of the sealed data and the data itself.
+func (w *encryptWriter) writeChunk(last bool) error {
+	sealed, err := seal(w.aead, w.buf, chunkAD(w.index, last))
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 5)
+	if last {
+		header[0] = 1
+	}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(sealed)))
+
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(sealed); err != nil {
+		return err
+	}
+
+	w.index++
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
+// decryptReader decrypts data written by an encryptWriter.
+type decryptReader struct {
+	r     io.Reader
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+	done  bool
+}
+
+func newDecryptReader(r io.Reader, aead cipher.AEAD) *decryptReader {
+	return &decryptReader{r: r, aead: aead}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+
+	return n, nil
+}
+
+func (r *decryptReader) readChunk() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		// the last chunk is missing
+		return ErrDecrypt
+	}
+
+	sealed := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		return ErrDecrypt
+	}
+
+	last := header[0] == 1
+	plain, err := unseal(r.aead, sealed, chunkAD(r.index, last))
+	if err != nil {
+		return err
+	}
+
+	r.index++
+	r.buf = plain
+	r.done = last
+
+	return nil
+}
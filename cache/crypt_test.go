@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptedSnapshot(t *testing.T) {
+	key := "testKey"
+	value := strings.Repeat("secretValue", 100)
+
+	c := New(WithEncryptionKey(testKey))
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("secretValue")) {
+		t.Error("Snapshot should not contain plaintext values.")
+		t.Fail()
+	}
+
+	snapshot := buf.Bytes()
+
+	c = New(WithEncryptionKey(testKey))
+	if err := c.LoadSnapshot(bytes.NewReader(snapshot)); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	for i := 0; i < 100; i++ {
+		if v, ok := c.Get(key + strconv.Itoa(i)); !ok || v != value {
+			t.Error("Element should have been restored.")
+			t.Fail()
+		}
+	}
+
+	if err := New().LoadSnapshot(bytes.NewReader(snapshot)); err != ErrNoEncryptionKey {
+		t.Error("Expected", ErrNoEncryptionKey, "got", err)
+		t.Fail()
+	}
+
+	wrongKey := []byte("fedcba9876543210fedcba9876543210")
+	if err := New(WithEncryptionKey(wrongKey)).LoadSnapshot(bytes.NewReader(snapshot)); err != ErrDecrypt {
+		t.Error("Expected", ErrDecrypt, "got", err)
+		t.Fail()
+	}
+
+	truncated := snapshot[:len(snapshot)-100]
+	if err := New(WithEncryptionKey(testKey)).LoadSnapshot(bytes.NewReader(truncated)); err != ErrDecrypt {
+		t.Error("Expected", ErrDecrypt, "got", err)
+		t.Fail()
+	}
+}
+
+func TestInvalidEncryptionKey(t *testing.T) {
+	c := New(WithEncryptionKey([]byte("short")))
+	c.Set("testKey", "testValue")
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err == nil {
+		t.Error("Saving with an invalid key should fail.")
+		t.Fail()
+	}
+
+	if buf.Len() != 0 {
+		t.Error("Nothing should have been written.")
+		t.Fail()
+	}
+}
+
+func TestEncryptionKeyEnv(t *testing.T) {
+	t.Setenv(EncryptionKeyEnv, base64.StdEncoding.EncodeToString(testKey))
+
+	c := New()
+	c.Set("testKey", "secretValue")
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("secretValue")) {
+		t.Error("Snapshot should not contain plaintext values.")
+		t.Fail()
+	}
+
+	c = New(WithEncryptionKey(testKey))
+	if err := c.LoadSnapshot(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+}
+
+func TestEncryptedAppendOnlyLog(t *testing.T) {
+	key := "testKey"
+	value := "secretValue"
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	c := New(WithAppendOnlyLog(path, 0), WithEncryptionKey(testKey))
+	c.Set(key, value)
+	c.Close()
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte(value)) {
+		t.Error("Log should not contain plaintext values.")
+		t.Fail()
+	}
+
+	var errs []error
+	c = New(WithAppendOnlyLog(path, 0), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	if len(errs) != 1 || errs[0] != ErrNoEncryptionKey {
+		t.Error("Expected", ErrNoEncryptionKey, "got", errs)
+		t.Fail()
+	}
+
+	c = New(WithAppendOnlyLog(path, 0), WithEncryptionKey(testKey))
+	defer c.Close()
+
+	if v, ok := c.Get(key); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventType is the kind of change an Event reports.
+type EventType int
+
+const (
+	// EventSet is sent when a value is stored.
+	EventSet EventType = iota
+	// EventRemove is sent when a value is removed explicitly.
+	EventRemove
+	// EventExpire is sent when a value is removed because its ttl elapsed.
+	EventExpire
+	// EventEvict is sent when a value is evicted because the cache is full.
+	EventEvict
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventRemove:
+		return "remove"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// Event reports a change of a key.
+type Event struct {
+	Type EventType
+	Key  string
+	// Value is the stored value of EventSet, the removed value otherwise
+	Value interface{}
+	// Dropped is the number of events dropped for the subscription before
+	// this one because its buffer was full
+	Dropped int
+}
+
+// subscription delivers events of keys matching a pattern.
+type subscription struct {
+	pattern string
+	events  chan Event
+	dropped int
+}
+
+// subscriptions passes changes of the cache to subscribers.
+type subscriptions struct {
+	buffer int
+	subs   map[<-chan Event]*subscription
+	// active is the number of subscriptions, checked without locking on
+	// every change
+	active atomic.Int32
+	// dropped is the total number of dropped events
+	dropped int
+	closed  bool
+	sync.Mutex
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		buffer: 256,
+		subs:   make(map[<-chan Event]*subscription),
+	}
+}
+
+// Subscribe returns a channel receiving the changes of keys matching the
+// glob pattern, e.g. "user:*". Patterns use the syntax of Redis, * matches
+// any sequence of characters including slashes. Events are never blocked on,
+// if the buffer of the channel set with WithEventBuffer is full they are
+// dropped and counted in the Dropped field of the next event delivered. The
+// channel is closed by Unsubscribe and Close.
+func (c *Cache) Subscribe(pattern string) <-chan Event {
+	s := c.subscriptions
+	s.Lock()
+	defer s.Unlock()
+
+	sub := &subscription{
+		pattern: pattern,
+		events:  make(chan Event, s.buffer),
+	}
+	if s.closed {
+		close(sub.events)
+		return sub.events
+	}
+
+	s.subs[sub.events] = sub
+	s.active.Add(1)
+
+	return sub.events
+}
+
+// Unsubscribe stops the delivery of events to a channel returned by Subscribe
+// and closes it.
+func (c *Cache) Unsubscribe(events <-chan Event) {
+	s := c.subscriptions
+	s.Lock()
+	defer s.Unlock()
+
+	if sub, ok := s.subs[events]; ok {
+		delete(s.subs, events)
+		s.active.Add(-1)
+		close(sub.events)
+	}
+}
+
+// publish delivers an event to all matching subscriptions without blocking.
+// It is called with the shard of the key locked, so events of a key are
+// delivered in order.
+func (c *Cache) publish(typ EventType, key string, value interface{}) {
+	c.record(typ, key, value)
+	c.tags.untag(key)
+	c.keyspace.publish(typ, key)
+
+	s := c.subscriptions
+	if s.active.Load() == 0 {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for _, sub := range s.subs {
+		if !matchGlob(sub.pattern, key) {
+			continue
+		}
+
+		select {
+		case sub.events <- Event{Type: typ, Key: key, Value: value, Dropped: sub.dropped}:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+			s.dropped++
+		}
+	}
+}
+
+// close closes all subscriptions.
+func (s *subscriptions) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.closed = true
+	for events, sub := range s.subs {
+		delete(s.subs, events)
+		s.active.Add(-1)
+		close(sub.events)
+	}
+}
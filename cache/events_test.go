@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	value := "testValue"
+
+	c := New()
+
+	events := c.Subscribe("user:*")
+
+	c.Set("user:1", value)
+	c.Set("session:1", value)
+	c.SetWithSlidingTTL("user:2", value, time.Minute)
+	c.Remove("user:1")
+	c.SetWithTTL("user:3", value, 10*time.Millisecond)
+
+	expected := []Event{
+		{Type: EventSet, Key: "user:1", Value: value},
+		{Type: EventSet, Key: "user:2", Value: value},
+		{Type: EventRemove, Key: "user:1", Value: value},
+		{Type: EventSet, Key: "user:3", Value: value},
+		{Type: EventExpire, Key: "user:3", Value: value},
+	}
+
+	for _, exp := range expected {
+		select {
+		case ev := <-events:
+			if ev != exp {
+				t.Error("Expected", exp, "got", ev)
+				t.Fail()
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected", exp, "got nothing")
+			t.Fail()
+		}
+	}
+
+	c.Unsubscribe(events)
+
+	if _, ok := <-events; ok {
+		t.Error("Channel should have been closed.")
+		t.Fail()
+	}
+}
+
+func TestSubscribeEvict(t *testing.T) {
+	c := New(WithMaxEntries(DefaultShards))
+
+	events := c.Subscribe("*")
+
+	// fill a single shard beyond its capacity
+	s := c.getShard("testKey")
+	keys := []string{}
+	for i := 0; len(keys) < 2; i++ {
+		key := "testKey" + string(rune('a'+i))
+		if c.getShard(key) == s {
+			keys = append(keys, key)
+		}
+	}
+	c.Set(keys[0], "testValue")
+	c.Set(keys[1], "testValue")
+
+	<-events
+	if ev := <-events; ev.Type != EventEvict || ev.Key != keys[0] {
+		t.Error("Expected eviction of", keys[0], "got", ev)
+		t.Fail()
+	}
+}
+
+func TestSubscribeDropped(t *testing.T) {
+	c := New(WithEventBuffer(2))
+
+	events := c.Subscribe("*")
+
+	for i := 0; i < 5; i++ {
+		c.Set("testKey", i)
+	}
+
+	<-events
+	<-events
+	c.Set("testKey", 5)
+
+	ev := <-events
+	if ev.Dropped != 3 || ev.Value != 5 {
+		t.Errorf("Expected 3 dropped events. Got %d", ev.Dropped)
+		t.Fail()
+	}
+
+	c.Close()
+
+	if _, ok := <-c.Subscribe("*"); ok {
+		t.Error("Subscriptions of a closed cache should be closed.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,118 @@
+package cache
+
+import "time"
+
+// evictionSamples is the number of entries compared when looking for the
+// least recently used entry of a shard.
+const evictionSamples = 5
+
+// Reason is the cause of the removal of an entry passed to the function set
+// with WithOnEvict.
+type Reason int
+
+const (
+	// Removed entries were removed explicitly, e.g. with Remove.
+	Removed Reason = iota
+	// Expired entries were removed because their ttl elapsed.
+	Expired
+	// Evicted entries were removed because the cache was full.
+	Evicted
+)
+
+// String returns the name of the reason.
+func (r Reason) String() string {
+	switch r {
+	case Removed:
+		return "removed"
+	case Expired:
+		return "expired"
+	case Evicted:
+		return "evicted"
+	default:
+		return "unknown"
+	}
+}
+
+// evictHook is called with entries evicted from the cache and their remaining
+// ttl, which is zero for entries without expiry and negative for expired
+// entries.
+type evictHook func(key string, value interface{}, ttl time.Duration)
+
+// evict removes the least recently used of a few randomly sampled entries
+// from the shard, which has to be locked for writing.
+func (c *Cache) evict(s *shard) {
+	var oldest *entry
+	var oldestKey string
+
+	n := 0
+	for k, e := range s.Entries {
+		if oldest == nil || e.accessed.Load() < oldest.accessed.Load() {
+			oldest, oldestKey = e, k
+		}
+
+		n++
+		if n == evictionSamples {
+			break
+		}
+	}
+
+	if oldest == nil {
+		return
+	}
+
+	c.evictEntry(s, oldestKey, oldest)
+}
+
+// evictKey evicts the entry stored with the given key and reports whether
+// there was one.
+func (c *Cache) evictKey(key string) bool {
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if ok {
+		c.evictEntry(s, key, e)
+	}
+
+	return ok
+}
+
+// evictEntry removes an entry from the shard, which has to be locked for
+// writing, and passes it to the evict hooks.
+func (c *Cache) evictEntry(s *shard, key string, e *entry) {
+	var ttl time.Duration
+	if e.expires.Load() != 0 {
+		ttl = e.remaining()
+		if ttl == 0 {
+			ttl = -1
+		}
+	}
+
+	e.stop()
+	delete(s.Entries, key)
+	c.logRemove(key)
+	c.publish(EventEvict, key, e.value)
+	c.removed(key, e.value, Evicted)
+	s.stats.countEvict()
+	c.warnEviction()
+
+	for _, hook := range c.evictHooks {
+		hook(key, e.value, ttl)
+	}
+	recycle(e)
+}
+
+// onEvict registers a hook called with entries evicted because the cache is
+// full. Hooks are called with the shard of the entry locked and must not use
+// the cache.
+func (c *Cache) onEvict(hook evictHook) {
+	c.evictHooks = append(c.evictHooks, hook)
+}
+
+// removed passes an entry removed from the cache to the function set with
+// WithOnEvict. It is called with the shard of the entry locked.
+func (c *Cache) removed(key string, value interface{}, reason Reason) {
+	if c.onRemove != nil {
+		c.onRemove(key, value, reason)
+	}
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithMaxEntries(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithMaxEntries(640))
+
+	for i := 0; i < 1000; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	n := 0
+	for i := 0; i < c.len(); i++ {
+		n += len(c.shard(i).Entries)
+	}
+
+	if n > 640 {
+		t.Errorf("Expected at most 640 entries. Got %d", n)
+		t.Fail()
+	}
+
+	s := c.GetStats()
+	if s.Evicted != 1000-n {
+		t.Errorf("Expected %d evicted entries. Got %d", 1000-n, s.Evicted)
+		t.Fail()
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	c := New(WithMaxEntries(2 * DefaultShards))
+
+	s := c.shard(0)
+	keys := make([]string, 0, 3)
+	for i := 0; len(keys) < 3; i++ {
+		k := strconv.Itoa(i)
+		if c.getShard(k) == s {
+			keys = append(keys, k)
+		}
+	}
+
+	c.Set(keys[0], 0)
+	time.Sleep(time.Millisecond)
+	c.Set(keys[1], 1)
+	time.Sleep(time.Millisecond)
+	c.Get(keys[0])
+
+	var evicted []string
+	c.onEvict(func(key string, value interface{}, ttl time.Duration) {
+		evicted = append(evicted, key)
+	})
+
+	c.Set(keys[2], 2)
+
+	if len(evicted) != 1 || evicted[0] != keys[1] {
+		t.Error("Expected", keys[1], "to be evicted, got", evicted)
+		t.Fail()
+	}
+
+	if _, ok := c.Get(keys[0]); !ok {
+		t.Error("Recently used element should not have been evicted.")
+		t.Fail()
+	}
+}
+
+func TestWithOnEvict(t *testing.T) {
+	value := "testValue"
+
+	var mu sync.Mutex
+	reasons := make(map[string]Reason)
+
+	c := New(WithMaxEntries(DefaultShards), WithOnEvict(func(key string, value interface{}, reason Reason) {
+		mu.Lock()
+		reasons[key] = reason
+		mu.Unlock()
+	}))
+
+	s := c.shard(0)
+	keys := make([]string, 0, 3)
+	for i := 0; len(keys) < 3; i++ {
+		k := strconv.Itoa(i)
+		if c.getShard(k) == s {
+			keys = append(keys, k)
+		}
+	}
+
+	// the expiring key must not evict keys[2] from the same shard
+	ttlKey := "testKey"
+	for i := 0; c.getShard(ttlKey) == s; i++ {
+		ttlKey = "testKey" + strconv.Itoa(i)
+	}
+
+	c.Set(keys[0], value)
+	c.Remove(keys[0])
+	c.Set(keys[1], value)
+	c.Set(keys[2], value)
+	c.SetWithTTL(ttlKey, value, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	expected := map[string]Reason{
+		keys[0]: Removed,
+		keys[1]: Evicted,
+		ttlKey:  Expired,
+	}
+	for k, reason := range expected {
+		if reasons[k] != reason {
+			t.Errorf("Expected %s to be %s. Got %s", k, reason, reasons[k])
+			t.Fail()
+		}
+	}
+
+	if len(reasons) != len(expected) {
+		t.Errorf("Expected %d removed entries. Got %d", len(expected), len(reasons))
+		t.Fail()
+	}
+}
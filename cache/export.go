@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// jsonEntry is the form of an entry in a JSON export.
+type jsonEntry struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	// TTL is the time left until the entry expires, or the ttl it is renewed
+	// with for sliding entries, in the format of time.Duration.String
+	TTL     string `json:"ttl,omitempty"`
+	Sliding bool   `json:"sliding,omitempty"`
+}
+
+// ExportJSON writes all entries of the cache as a JSON array of objects with
+// the fields key, value, ttl and sliding to w, one entry per line. The ttl is
+// the time left until an entry expires, e.g. "4m59.5s", and is omitted for
+// entries without expiry. Values are encoded with encoding/json.
+func (c *Cache) ExportJSON(w io.Writer) error {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString("["); err != nil {
+		return err
+	}
+
+	sep := "\n"
+	for i := 0; i < c.len(); i++ {
+		for _, se := range c.snapshotShard(c.shard(i)) {
+			je := jsonEntry{
+				Key:     se.Key,
+				Value:   se.Value,
+				Sliding: se.Sliding,
+			}
+			if se.Expires != 0 {
+				ttl := se.TTL
+				if !se.Sliding {
+					ttl = time.Until(time.Unix(0, se.Expires))
+				}
+				if ttl <= 0 {
+					continue
+				}
+				je.TTL = ttl.String()
+			}
+
+			data, err := json.Marshal(&je)
+			if err != nil {
+				return err
+			}
+
+			if _, err := bw.WriteString(sep); err != nil {
+				return err
+			}
+			if _, err := bw.Write(data); err != nil {
+				return err
+			}
+			sep = ",\n"
+		}
+	}
+
+	if _, err := bw.WriteString("\n]\n"); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// ImportJSON stores the entries of a JSON array in the format written by
+// ExportJSON. Entries with a ttl expire once it elapsed from now on. Values
+// are decoded into the generic types of encoding/json, e.g.
+// map[string]interface{} for objects. Like snapshots, imported entries are
+// not written through to the Store.
+func (c *Cache) ImportJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+
+	if t, err := dec.Token(); err != nil {
+		return err
+	} else if t != json.Delim('[') {
+		return fmt.Errorf("cache: expected JSON array, got %v", t)
+	}
+
+	for dec.More() {
+		var je jsonEntry
+		if err := dec.Decode(&je); err != nil {
+			return err
+		}
+
+		se := snapshotEntry{
+			Key:     je.Key,
+			Value:   je.Value,
+			Sliding: je.Sliding,
+		}
+		ttl, err := parseTTL(je.Key, je.TTL)
+		if err != nil {
+			return err
+		}
+		if ttl < 0 {
+			continue
+		}
+		if ttl > 0 {
+			se.TTL = ttl
+			se.Expires = time.Now().Add(ttl).UnixNano()
+		}
+
+		c.restore(&se)
+	}
+
+	_, err := dec.Token()
+
+	return err
+}
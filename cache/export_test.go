@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportJSON(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+	c.SetWithTTL(key, value, time.Minute)
+	c.SetWithSlidingTTL("sliding", value, time.Hour)
+
+	var buf bytes.Buffer
+	if err := c.ExportJSON(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if n := strings.Count(buf.String(), "\n"); n != 104 {
+		t.Errorf("Expected one line per entry. Got %d lines", n)
+		t.Fail()
+	}
+
+	c = New()
+	if err := c.ImportJSON(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if n := c.GetStats().Set; n != 102 {
+		t.Errorf("Expected 102 entries to be imported. Got %d", n)
+		t.Fail()
+	}
+
+	if v, ok := c.Get(key + "42"); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	s := c.getShard(key)
+	e := s.Entries[key]
+	if e.ttl <= 59*time.Second || e.ttl > time.Minute || e.sliding {
+		t.Error("Remaining ttl should have been imported.")
+		t.Fail()
+	}
+
+	s = c.getShard("sliding")
+	e = s.Entries["sliding"]
+	if e.ttl != time.Hour || !e.sliding {
+		t.Error("Sliding ttl should have been imported.")
+		t.Fail()
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	c := New()
+
+	err := c.ImportJSON(strings.NewReader(`[
+{"key": "a", "value": {"name": "testValue"}},
+{"key": "b", "value": 42, "ttl": "1m"}
+]`))
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	v, ok := c.Get("a")
+	if m, _ := v.(map[string]interface{}); !ok || m["name"] != "testValue" {
+		t.Error("Expected value to be decoded, got", v)
+		t.Fail()
+	}
+
+	if v, ok := c.Get("b"); !ok || v != float64(42) {
+		t.Error("Expected", 42, "got", v)
+		t.Fail()
+	}
+
+	for _, data := range []string{`{}`, `[{"key": "a", "ttl": "soon"}]`, `[{"key": "a"}`} {
+		if err := c.ImportJSON(strings.NewReader(data)); err == nil {
+			t.Error("Expected error for", data)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package cache
+
+import "expvar"
+
+// PublishExpvar publishes the stats of the cache as expvar variable name, so
+// they are served as JSON on /debug/vars. The stats are read on every
+// request. Like expvar.Publish it panics if the name is already in use.
+func (c *Cache) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.GetStats()
+	}))
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// expvarRuns makes the published names unique, expvar panics when a name is
+// published twice, e.g. with -count=2
+var expvarRuns atomic.Int32
+
+func TestPublishExpvar(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	name := t.Name() + strconv.Itoa(int(expvarRuns.Add(1)))
+
+	c := New()
+	c.PublishExpvar(name)
+
+	c.Set(key, value)
+	c.Get(key)
+
+	v := expvar.Get(name)
+	if v == nil {
+		t.Error("Stats should have been published.")
+		t.FailNow()
+	}
+
+	var s Stats
+	if err := json.Unmarshal([]byte(v.String()), &s); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if s.Hits != 1 || s.Set != 1 {
+		t.Errorf("Expected 1 hit and 1 set. Got %d and %d", s.Hits, s.Set)
+		t.Fail()
+	}
+}
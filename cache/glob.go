@@ -0,0 +1,100 @@
+package cache
+
+// matchGlob reports whether key matches the glob pattern, using the syntax of
+// Redis: * matches any sequence of characters, ? any single character,
+// [abc] and [a-z] one of a set or range of characters, [^a] any character
+// but the ones given and \ escapes the next character. Unlike path.Match, *
+// also matches slashes.
+func matchGlob(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchGlob(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			n, ok := matchClass(pattern[1:], key[0])
+			if !ok {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1+n:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+
+	return len(key) == 0
+}
+
+// matchClass matches c against the character class at the start of pattern,
+// following its opening bracket. It returns the length of the class including
+// the closing bracket. An unterminated class extends to the end of pattern.
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 0
+	negate := false
+	if i < len(pattern) && pattern[i] == '^' {
+		negate = true
+		i++
+	}
+
+	match := false
+	for i < len(pattern) && pattern[i] != ']' {
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		i++
+
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi = pattern[i+1]
+			if hi == '\\' && i+2 < len(pattern) {
+				i++
+				hi = pattern[i+1]
+			}
+			i += 2
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+
+		if lo <= c && c <= hi {
+			match = true
+		}
+	}
+	if i < len(pattern) {
+		// skip the closing bracket
+		i++
+	}
+
+	return i, match != negate
+}
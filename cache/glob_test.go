@@ -0,0 +1,39 @@
+package cache
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "user/1", true},
+		{"user:*", "user:1", true},
+		{"user:*", "session:1", false},
+		{"*:1", "user:1", true},
+		{"user:*:name", "user:1:name", true},
+		{"user:*:name", "user:1:email", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"key", "key", true},
+		{"key", "keys", false},
+		{"**a", "bba", true},
+	}
+
+	for _, test := range tests {
+		if matchGlob(test.pattern, test.key) != test.match {
+			t.Errorf("Expected match of %q and %q to be %v", test.pattern, test.key, test.match)
+			t.Fail()
+		}
+	}
+}
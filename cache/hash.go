@@ -0,0 +1,120 @@
+package cache
+
+import "encoding/gob"
+
+// Hash is the value stored by the hash operations HSet and HDel, which copy
+// all fields (see modify). Like other values stored with GobCodec the
+// concrete types of field values have to be registered with gob.Register to
+// be snapshotted.
+type Hash map[string]interface{}
+
+func init() {
+	gob.Register(Hash{})
+}
+
+// HSet stores the value in a field of the hash stored with the given key and
+// reports whether the field is new. Missing keys are stored as new hashes
+// with the default ttl of the cache. If the value stored with the key is not
+// a Hash ErrWrongType is returned.
+func (c *Cache) HSet(key string, field string, value interface{}) (created bool, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		h, err := asHash(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		_, exists := h[field]
+		created = !exists
+
+		set := make(Hash, len(h)+1)
+		for f, v := range h {
+			set[f] = v
+		}
+		set[field] = value
+
+		return set, nil
+	})
+
+	return created, err
+}
+
+// HGet returns the value of a field of the hash stored with the given key.
+// If there is no such field ErrNotFound is returned.
+func (c *Cache) HGet(key string, field string) (interface{}, error) {
+	h, err := c.hash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := h[field]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return v, nil
+}
+
+// HDel deletes fields of the hash stored with the given key and returns how
+// many of them existed. The key is deleted with the last field of the hash.
+func (c *Cache) HDel(key string, fields ...string) (n int, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		h, err := asHash(v, ok)
+		if err != nil || !ok {
+			return nil, err
+		}
+
+		deleted := make(Hash, len(h))
+		for f, v := range h {
+			deleted[f] = v
+		}
+		for _, f := range fields {
+			if _, ok := deleted[f]; ok {
+				delete(deleted, f)
+				n++
+			}
+		}
+
+		if len(deleted) == 0 {
+			return nil, nil
+		}
+		return deleted, nil
+	})
+
+	return n, err
+}
+
+// HGetAll returns a copy of the fields of the hash stored with the given
+// key. A missing key is an empty hash.
+func (c *Cache) HGetAll(key string) (map[string]interface{}, error) {
+	h, err := c.hash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{}, len(h))
+	for f, v := range h {
+		fields[f] = v
+	}
+
+	return fields, nil
+}
+
+// hash returns the hash stored with the given key counting the access like
+// Get.
+func (c *Cache) hash(key string) (Hash, error) {
+	v, ok := c.get(key)
+	return asHash(v, ok)
+}
+
+func asHash(v interface{}, ok bool) (Hash, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	h, isHash := v.(Hash)
+	if !isHash {
+		return nil, ErrWrongType
+	}
+
+	return h, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if created, err := c.HSet(key, "name", value); err != nil || !created {
+		t.Error("Field should have been created.", err)
+		t.Fail()
+	}
+
+	if created, _ := c.HSet(key, "name", "otherValue"); created {
+		t.Error("Field should have been updated.")
+		t.Fail()
+	}
+	c.HSet(key, "mail", value)
+
+	if v, err := c.HGet(key, "name"); err != nil || v != "otherValue" {
+		t.Error("Expected otherValue got", v, err)
+		t.Fail()
+	}
+
+	if _, err := c.HGet(key, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound got", err)
+		t.Fail()
+	}
+
+	fields, _ := c.HGetAll(key)
+	if !reflect.DeepEqual(fields, map[string]interface{}{"name": "otherValue", "mail": value}) {
+		t.Error("Unexpected fields", fields)
+		t.Fail()
+	}
+
+	if n, _ := c.HDel(key, "name", "missing"); n != 1 {
+		t.Errorf("Expected 1 deleted field. Got %d", n)
+		t.Fail()
+	}
+
+	c.HDel(key, "mail")
+	if c.Has(key) {
+		t.Error("Empty hash should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestHashWrongType(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.RPush(key, value)
+
+	if _, err := c.HSet(key, "name", value); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+
+	if _, err := c.HGetAll(key); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
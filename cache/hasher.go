@@ -0,0 +1,46 @@
+package cache
+
+import "hash/maphash"
+
+// Hasher hashes keys to distribute them over the shards of a cache. Hash is
+// called for every operation and must be safe for concurrent use. It must not
+// retain key, which may share memory with a []byte passed to GetBytesKey.
+type Hasher interface {
+	Hash(key string) uint64
+}
+
+// MapHasher hashes keys with hash/maphash, the hash function of the Go
+// runtime, without allocating. Hashes differ between instances, so keys are
+// distributed differently by every process. It is the default Hasher.
+type MapHasher struct {
+	seed maphash.Seed
+}
+
+// NewMapHasher returns a MapHasher with a random seed.
+func NewMapHasher() *MapHasher {
+	return &MapHasher{seed: maphash.MakeSeed()}
+}
+
+// Hash returns the hash of key.
+func (h *MapHasher) Hash(key string) uint64 {
+	return maphash.String(h.seed, key)
+}
+
+// FNVHasher hashes keys with 64 bit FNV-1a without allocating. Hashes are
+// the same in every process.
+type FNVHasher struct{}
+
+// Hash returns the hash of key.
+func (FNVHasher) Hash(key string) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime
+	}
+	return h
+}
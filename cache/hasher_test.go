@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+type constantHasher struct{}
+
+func (constantHasher) Hash(string) uint64 { return 0 }
+
+func TestWithHasher(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithHasher(constantHasher{}))
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	if n := c.GetShardStats()[0].Entries; n != 100 {
+		t.Errorf("Expected all entries in the first shard. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestHasherDistribution(t *testing.T) {
+	key := "testKey"
+
+	for _, h := range []Hasher{NewMapHasher(), FNVHasher{}} {
+		counts := make([]int, DefaultShards)
+		for i := 0; i < 64000; i++ {
+			counts[h.Hash(key+strconv.Itoa(i))%uint64(DefaultShards)]++
+		}
+
+		for i, n := range counts {
+			if n < 800 || n > 1200 {
+				t.Errorf("Expected about 1000 keys in shard %d. Got %d", i, n)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestFNVHasher(t *testing.T) {
+	// test vector of FNV-1a
+	if h := (FNVHasher{}).Hash("a"); h != 0xaf63dc4c8601ec8c {
+		t.Errorf("Expected af63dc4c8601ec8c. Got %x", h)
+		t.Fail()
+	}
+}
+
+func BenchmarkHasher(b *testing.B) {
+	key := "testKey"
+
+	for _, bench := range []struct {
+		name   string
+		hasher Hasher
+	}{
+		{"maphash", NewMapHasher()},
+		{"fnv", FNVHasher{}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bench.hasher.Hash(key)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"encoding/gob"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of hash bits selecting a register of a
+// HyperLogLog, giving a standard error of about 0.81%.
+const hllPrecision = 14
+
+// HyperLogLog is the value stored by PFAdd, holding one register per
+// 2^14 hash prefixes. PFAdd copies the registers (see modify) only if it
+// increases one of them.
+type HyperLogLog []byte
+
+func init() {
+	gob.Register(HyperLogLog{})
+}
+
+// PFAdd adds the elements to the HyperLogLog stored with the given key and
+// reports whether its estimated cardinality changed. Missing keys are stored
+// as new HyperLogLogs with the default ttl of the cache, so counters can be
+// rolled over by letting them expire. If the value stored with the key is not
+// a HyperLogLog ErrWrongType is returned.
+func (c *Cache) PFAdd(key string, elements ...string) (changed bool, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		h, err := asHyperLogLog(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		// the registers are only copied once one of them increases
+		added, copied := h, !ok
+		if !ok {
+			added = make(HyperLogLog, 1<<hllPrecision)
+		}
+		for _, el := range elements {
+			i, rho := hllRegister(el)
+			if added[i] >= rho {
+				continue
+			}
+
+			if !copied {
+				added = append(HyperLogLog(nil), h...)
+				copied = true
+			}
+			added[i] = rho
+			changed = true
+		}
+
+		if !copied {
+			return nil, errUnchanged
+		}
+		return added, nil
+	})
+
+	return changed, err
+}
+
+// PFCount returns the estimated number of distinct elements added to the
+// HyperLogLog stored with the given key, zero if there is none.
+func (c *Cache) PFCount(key string) (int64, error) {
+	v, ok := c.get(key)
+	h, err := asHyperLogLog(v, ok)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.count(), nil
+}
+
+// hllRegister returns the register of an element and the value recording
+// it, registers only ever increase.
+func hllRegister(el string) (i uint64, rho byte) {
+	x := hllHash(el)
+	i = x >> (64 - hllPrecision)
+	rho = byte(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+
+	return i, rho
+}
+
+// count returns the estimated cardinality, using linear counting for small
+// cardinalities.
+func (h HyperLogLog) count() int64 {
+	if len(h) == 0 {
+		return 0
+	}
+
+	m := float64(len(h))
+	sum, zeros := 0.0, 0
+	for _, r := range h {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+
+	return int64(e + 0.5)
+}
+
+// hllHash returns the 64 bit FNV-1a hash of el with the bits mixed by the
+// finalizer of MurmurHash3, as FNV alone does not spread short keys over the
+// high bits selecting the register.
+func hllHash(el string) uint64 {
+	f := fnv.New64a()
+	f.Write([]byte(el))
+
+	x := f.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func asHyperLogLog(v interface{}, ok bool) (HyperLogLog, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	h, isHyperLogLog := v.(HyperLogLog)
+	if !isHyperLogLog {
+		return nil, ErrWrongType
+	}
+
+	return h, nil
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	if changed, err := c.PFAdd(key, "a", "b", "c"); err != nil || !changed {
+		t.Error("HyperLogLog should have changed.", err)
+		t.Fail()
+	}
+
+	_, version, _ := c.GetWithVersion(key)
+	if changed, _ := c.PFAdd(key, "a"); changed {
+		t.Error("HyperLogLog should not have changed.")
+		t.Fail()
+	}
+	if _, v, _ := c.GetWithVersion(key); v != version {
+		t.Error("HyperLogLog should not have been stored again.")
+		t.Fail()
+	}
+
+	if n, err := c.PFCount(key); err != nil || n != 3 {
+		t.Errorf("Expected 3. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	for _, n := range []int{1000, 100000} {
+		elements := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			elements = append(elements, strconv.Itoa(i))
+		}
+		c.Remove(key)
+		c.PFAdd(key, elements...)
+		c.PFAdd(key, elements[:n/2]...)
+
+		count, _ := c.PFCount(key)
+		if e := math.Abs(float64(count)-float64(n)) / float64(n); e > 0.03 {
+			t.Errorf("Expected about %d. Got %d", n, count)
+			t.Fail()
+		}
+	}
+}
+
+func TestHyperLogLogWrongType(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	if _, err := c.PFAdd(key, value); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+
+	if _, err := c.PFCount(key); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
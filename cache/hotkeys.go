@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// Dimensions of the count-min sketch of each shard.
+const (
+	sketchDepth = 4
+	sketchWidth = 1024
+)
+
+// KeyCount is a key and its estimated number of accesses.
+type KeyCount struct {
+	Key   string
+	Count int
+}
+
+// hotKeys estimates the access counts of the keys of a shard with a
+// count-min sketch and keeps the keys with the highest counts. Counts are
+// halved periodically, so keys which are no longer accessed cool down.
+type hotKeys struct {
+	sketch [sketchDepth][sketchWidth]uint32
+	// top holds up to size keys with the highest estimated counts
+	top  map[string]uint32
+	size int
+	// min is the lowest count in top once it is full
+	min uint32
+	// additions counts the accesses since the counts were last halved
+	additions int
+	sync.Mutex
+}
+
+func newHotKeys(size int) *hotKeys {
+	return &hotKeys{
+		top:  make(map[string]uint32, size),
+		size: size,
+	}
+}
+
+// add counts an access of key.
+func (h *hotKeys) add(key string) {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	sum := hash.Sum64()
+	h1, h2 := uint32(sum), uint32(sum>>32)|1
+
+	h.Lock()
+	defer h.Unlock()
+
+	count := ^uint32(0)
+	for i := range h.sketch {
+		idx := (h1 + uint32(i)*h2) % sketchWidth
+		if h.sketch[i][idx] < ^uint32(0) {
+			h.sketch[i][idx]++
+		}
+		if h.sketch[i][idx] < count {
+			count = h.sketch[i][idx]
+		}
+	}
+
+	if _, ok := h.top[key]; ok || len(h.top) < h.size {
+		h.top[key] = count
+		if len(h.top) == h.size {
+			h.updateMin()
+		}
+	} else if count > h.min {
+		for k, c := range h.top {
+			if c == h.min {
+				delete(h.top, k)
+				break
+			}
+		}
+		h.top[key] = count
+		h.updateMin()
+	}
+
+	h.additions++
+	if h.additions >= 10*sketchWidth {
+		h.age()
+	}
+}
+
+// updateMin sets min to the lowest count in top.
+func (h *hotKeys) updateMin() {
+	h.min = ^uint32(0)
+	for _, c := range h.top {
+		if c < h.min {
+			h.min = c
+		}
+	}
+}
+
+// age halves all counts.
+func (h *hotKeys) age() {
+	for i := range h.sketch {
+		for j := range h.sketch[i] {
+			h.sketch[i][j] /= 2
+		}
+	}
+	for k := range h.top {
+		h.top[k] /= 2
+	}
+	h.min /= 2
+	h.additions = 0
+}
+
+// keys returns the keys in top with their counts.
+func (h *hotKeys) keys() []KeyCount {
+	h.Lock()
+	defer h.Unlock()
+
+	keys := make([]KeyCount, 0, len(h.top))
+	for k, c := range h.top {
+		keys = append(keys, KeyCount{Key: k, Count: int(c)})
+	}
+	return keys
+}
+
+// TopKeys returns up to n of the most frequently accessed keys with their
+// estimated number of accesses by Get, highest first. Keys are only tracked
+// if enabled with WithHotKeyTracking, otherwise nil is returned. Counts are
+// estimates which may be too high and are halved periodically.
+func (c *Cache) TopKeys(n int) []KeyCount {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	var keys []KeyCount
+	for i := 0; i < c.len(); i++ {
+		if h := c.shard(i).hot; h != nil {
+			keys = append(keys, h.keys()...)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	return keys
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTopKeys(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithHotKeyTracking(4))
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	// key i is accessed 100-i times
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100-i; j++ {
+			c.Get(key + strconv.Itoa(i))
+		}
+	}
+
+	top := c.TopKeys(3)
+	if len(top) != 3 {
+		t.Errorf("Expected 3 keys. Got %d", len(top))
+		t.FailNow()
+	}
+
+	for i, kc := range top {
+		if kc.Key != key+strconv.Itoa(i) {
+			t.Errorf("Expected %s at %d. Got %s", key+strconv.Itoa(i), i, kc.Key)
+			t.Fail()
+		}
+		if kc.Count < 100-i {
+			t.Errorf("Expected a count of at least %d for %s. Got %d", 100-i, kc.Key, kc.Count)
+			t.Fail()
+		}
+	}
+
+	if New().TopKeys(3) != nil {
+		t.Error("Keys should only be tracked if enabled.")
+		t.Fail()
+	}
+}
+
+func TestHotKeysAge(t *testing.T) {
+	h := newHotKeys(2)
+
+	for i := 0; i < 10; i++ {
+		h.add("testKey")
+	}
+	h.age()
+
+	keys := h.keys()
+	if len(keys) != 1 || keys[0].Count != 5 {
+		t.Error("Expected count to be halved, got", keys)
+		t.Fail()
+	}
+}
@@ -0,0 +1,26 @@
+package cache
+
+// InvalidationBus broadcasts invalidated keys between instances of a cache
+// running in different processes, keeping their local copies coherent.
+// Implementations must not deliver keys published by an instance back to
+// itself.
+type InvalidationBus interface {
+	// Publish broadcasts a key invalidated by this instance.
+	Publish(key string) error
+	// Subscribe registers the function called with keys invalidated by
+	// other instances.
+	Subscribe(invalidate func(key string)) error
+}
+
+// invalidateOthers broadcasts a key modified with Set, SetWithTTL,
+// SetWithSlidingTTL, GetOrSet or Remove, if the cache has an invalidation
+// bus. It must not be called with the shard of the key locked.
+func (c *Cache) invalidateOthers(key string) {
+	if c.bus == nil {
+		return
+	}
+
+	if err := c.bus.Publish(key); err != nil {
+		c.handleError(err)
+	}
+}
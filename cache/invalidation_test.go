@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// testBus connects the caches subscribed to it in memory.
+type testBus struct {
+	subs []func(string)
+	sync.Mutex
+}
+
+type testBusClient struct {
+	bus *testBus
+	id  int
+	err error
+}
+
+func (b *testBus) client() *testBusClient {
+	b.Lock()
+	defer b.Unlock()
+
+	b.subs = append(b.subs, nil)
+	return &testBusClient{bus: b, id: len(b.subs) - 1}
+}
+
+func (c *testBusClient) Publish(key string) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	c.bus.Lock()
+	defer c.bus.Unlock()
+
+	for id, sub := range c.bus.subs {
+		if id != c.id && sub != nil {
+			sub(key)
+		}
+	}
+	return nil
+}
+
+func (c *testBusClient) Subscribe(invalidate func(string)) error {
+	c.bus.Lock()
+	defer c.bus.Unlock()
+
+	c.bus.subs[c.id] = invalidate
+	return nil
+}
+
+func TestInvalidationBus(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	bus := &testBus{}
+	c1 := New(WithInvalidationBus(bus.client()))
+	c2 := New(WithInvalidationBus(bus.client()))
+
+	c1.Set(key, value)
+	c2.Set(key, value)
+
+	if _, ok := c1.Get(key); ok {
+		t.Error("Set on another instance should have invalidated the key.")
+		t.Fail()
+	}
+
+	if _, ok := c2.Get(key); !ok {
+		t.Error("Key should not have been invalidated on the instance setting it.")
+		t.Fail()
+	}
+
+	c1.GetOrSet(key, value)
+	c1.Remove(key)
+
+	if _, ok := c2.Get(key); ok {
+		t.Error("Remove on another instance should have invalidated the key.")
+		t.Fail()
+	}
+}
+
+func TestInvalidationBusError(t *testing.T) {
+	busErr := errors.New("publish failed")
+
+	var errs []error
+	client := (&testBus{}).client()
+	client.err = busErr
+
+	c := New(WithInvalidationBus(client), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	c.Set("testKey", "testValue")
+
+	if len(errs) != 1 || errs[0] != busErr {
+		t.Error("Expected", busErr, "got", errs)
+		t.Fail()
+	}
+}
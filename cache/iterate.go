@@ -0,0 +1,193 @@
+package cache
+
+import "time"
+
+// Range calls f for each entry of the cache in no particular order until f
+// returns false. Like sync.Map.Range it does not correspond to a consistent
+// snapshot: each shard's live entries are copied under its read lock and f
+// is called without holding any lock, so f may use the cache. Every key is
+// visited at most once, also if the cache is resized meanwhile, entries
+// stored or removed during the iteration may or may not be visited. Range
+// does not count as an access of the entries.
+func (c *Cache) Range(f func(key string, value interface{}) bool) {
+	type item struct {
+		key   string
+		value interface{}
+	}
+
+	// visited holds the number of shards of each shard table replaced by
+	// Resize during the iteration and how many of them were visited
+	type part struct {
+		shards int
+		done   int
+	}
+	var visited []part
+
+	seen := func(key string) bool {
+		if len(visited) == 0 {
+			return false
+		}
+		h := c.hasher.Hash(key)
+		for _, p := range visited {
+			if h%uint64(p.shards) < uint64(p.done) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var items []item
+	shards := c.shards.Load()
+	for i := 0; ; i++ {
+		// resizing is only held while a shard is copied, so f may use the
+		// whole cache and even resize it
+		c.resizing.RLock()
+		if current := c.shards.Load(); current != shards {
+			visited = append(visited, part{len(*shards), i})
+			shards, i = current, 0
+		}
+		if i == len(*shards) {
+			c.resizing.RUnlock()
+			return
+		}
+		s := (*shards)[i]
+
+		items = items[:0]
+		s.RLock()
+		for k, e := range s.Entries {
+			if !e.expired() && !seen(k) {
+				items = append(items, item{k, e.value})
+			}
+		}
+		s.RUnlock()
+		c.resizing.RUnlock()
+
+		for _, it := range items {
+			if !f(it.key, it.value) {
+				return
+			}
+		}
+	}
+}
+
+// Iterator iterates a point-in-time copy of the entries of a cache created by
+// Iterator.
+type Iterator struct {
+	shards [][]snapshotEntry
+	shard  int
+	pos    int
+	cur    *snapshotEntry
+}
+
+// Iterator returns an Iterator over a copy of all live entries taken at a
+// single point in time, e.g. for backups which must not see some writes but
+// miss earlier ones. All shards are read locked while the entries are copied,
+// blocking writers briefly. Writes after that do not affect the iteration.
+func (c *Cache) Iterator() *Iterator {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	for i := 0; i < c.len(); i++ {
+		c.shard(i).RLock()
+	}
+
+	it := &Iterator{shards: make([][]snapshotEntry, c.len())}
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		it.shards[i] = s.snapshot()
+		s.RUnlock()
+	}
+
+	return it
+}
+
+// Next advances to the next entry and reports whether there is one. It has to
+// be called before the first entry is read.
+func (it *Iterator) Next() bool {
+	for it.shard < len(it.shards) {
+		if it.pos < len(it.shards[it.shard]) {
+			it.cur = &it.shards[it.shard][it.pos]
+			it.pos++
+			return true
+		}
+		it.shard++
+		it.pos = 0
+	}
+
+	it.cur = nil
+	return false
+}
+
+// Key returns the key of the current entry.
+func (it *Iterator) Key() string {
+	return it.cur.Key
+}
+
+// Value returns the value of the current entry.
+func (it *Iterator) Value() interface{} {
+	return it.cur.Value
+}
+
+// Expires returns the expiry time of the current entry, the zero time if it
+// does not expire.
+func (it *Iterator) Expires() time.Time {
+	if it.cur.Expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, it.cur.Expires)
+}
+
+// Len returns the number of entries copied.
+func (it *Iterator) Len() int {
+	n := 0
+	for _, entries := range it.shards {
+		n += len(entries)
+	}
+	return n
+}
+
+// Item is a copy of an entry returned by Items.
+type Item struct {
+	Value interface{}
+	// TTL is the time left until the entry expires, NoExpiration if it
+	// does not expire
+	TTL time.Duration
+	// Sliding entries are renewed with their ttl on every Get
+	Sliding bool
+	// Accessed is the time of the last access or of the creation of the
+	// entry
+	Accessed time.Time
+}
+
+// Items returns a copy of all live entries, e.g. for tests and debugging
+// dumps of small caches. Like Range it does not correspond to a consistent
+// snapshot and does not count as an access of the entries.
+func (c *Cache) Items() map[string]Item {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	items := make(map[string]Item)
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.RLock()
+		for k, e := range s.Entries {
+			if e.expired() {
+				continue
+			}
+
+			it := Item{
+				Value:    e.value,
+				TTL:      NoExpiration,
+				Sliding:  e.sliding,
+				Accessed: time.Unix(0, e.accessed.Load()),
+			}
+			if e.expires.Load() != 0 {
+				it.TTL = e.remaining()
+			}
+			items[k] = it
+		}
+		s.RUnlock()
+	}
+
+	return items
+}
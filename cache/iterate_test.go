@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRange(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	seen := make(map[string]bool)
+	c.Range(func(k string, v interface{}) bool {
+		if v != value {
+			t.Error("Expected", value, "got", v)
+			t.Fail()
+		}
+		seen[k] = true
+		// modifying the cache during the iteration must not deadlock
+		c.Remove(k)
+		return true
+	})
+
+	if len(seen) != 100 || c.Len() != 0 {
+		t.Errorf("Expected all 100 entries to be visited. Got %d", len(seen))
+		t.Fail()
+	}
+}
+
+func TestRangeStop(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	n := 0
+	c.Range(func(k string, v interface{}) bool {
+		n++
+		return n < 10
+	})
+
+	if n != 10 {
+		t.Errorf("Expected the iteration to stop after 10 entries. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestIterator(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+	c.SetWithTTL(key, value, time.Minute)
+
+	it := c.Iterator()
+
+	// writes after creating the iterator are not visible
+	c.Remove(key + "0")
+	c.Set("testOtherKey", value)
+
+	if it.Len() != 101 {
+		t.Errorf("Expected 101 entries. Got %d", it.Len())
+		t.Fail()
+	}
+
+	seen := make(map[string]bool)
+	for it.Next() {
+		seen[it.Key()] = true
+		if it.Value() != value {
+			t.Error("Expected", value, "got", it.Value())
+			t.Fail()
+		}
+		if it.Key() == key && it.Expires().IsZero() {
+			t.Error("Expected expiry to be set.")
+			t.Fail()
+		}
+	}
+
+	if len(seen) != 101 || !seen[key+"0"] || seen["testOtherKey"] {
+		t.Error("Iterator should have visited the entries at its creation.")
+		t.Fail()
+	}
+
+	if it.Next() {
+		t.Error("Exhausted iterator should not advance.")
+		t.Fail()
+	}
+}
+
+func TestItems(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+	c.SetWithSlidingTTL("testSlidingKey", value, time.Minute)
+	c.Get(key)
+
+	items := c.Items()
+	if len(items) != 2 {
+		t.Errorf("Expected 2 items. Got %d", len(items))
+		t.FailNow()
+	}
+
+	it := items[key]
+	if it.Value != value || it.TTL != NoExpiration || it.Sliding || it.Accessed.IsZero() {
+		t.Errorf("Unexpected item %+v", it)
+		t.Fail()
+	}
+
+	it = items["testSlidingKey"]
+	if !it.Sliding || it.TTL <= 59*time.Second || it.TTL > time.Minute {
+		t.Errorf("Unexpected sliding item %+v", it)
+		t.Fail()
+	}
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Keys returns the keys of all entries in the cache in no particular order,
+// excluding expired entries. The keys are copied shard by shard, so entries
+// stored or removed meanwhile may or may not be included.
+func (c *Cache) Keys() []string {
+	return c.keys(func(string) bool { return true })
+}
+
+// KeysWithPrefix returns the keys starting with prefix like Keys, e.g. all
+// keys of a logical group like "user:42:". Keys are matched while the shards
+// are scanned, so only matching keys are copied.
+func (c *Cache) KeysWithPrefix(prefix string) []string {
+	return c.keys(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// Match returns the keys matching the glob pattern like Keys. Patterns use
+// the syntax of Subscribe and the Redis KEYS command, e.g. "user:*:name".
+func (c *Cache) Match(pattern string) []string {
+	return c.keys(func(key string) bool { return matchGlob(pattern, key) })
+}
+
+// MatchRegexp returns the keys matching the regular expression like Keys.
+func (c *Cache) MatchRegexp(re *regexp.Regexp) []string {
+	return c.keys(re.MatchString)
+}
+
+// keys returns the keys of all live entries accepted by match.
+func (c *Cache) keys(match func(key string) bool) []string {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	var keys []string
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.RLock()
+		for k, e := range s.Entries {
+			if !e.expired() && match(k) {
+				keys = append(keys, k)
+			}
+		}
+		s.RUnlock()
+	}
+
+	return keys
+}
+
+// scanHashBits is the number of bits of a Scan cursor holding the position
+// within a shard, the bits above hold the index of the shard.
+const scanHashBits = 48
+
+// scanHash returns the position of a key in the order Scan visits the keys of
+// a shard.
+func scanHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64() & (1<<scanHashBits - 1)
+}
+
+// Scan iterates the keys of the cache incrementally like the Redis SCAN
+// command. Start with cursor 0 and call Scan with the returned cursor until
+// it returns 0 again. Each call returns about count keys, holding the lock of
+// one shard at a time. Keys present during the whole iteration are returned
+// at least once, keys stored or removed meanwhile may or may not be.
+func (c *Cache) Scan(cursor uint64, count int) (keys []string, next uint64) {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	if count <= 0 {
+		count = 10
+	}
+
+	shard := int(cursor >> scanHashBits)
+	pos := cursor & (1<<scanHashBits - 1)
+
+	for ; shard < c.len(); shard, pos = shard+1, 0 {
+		type scanKey struct {
+			key  string
+			hash uint64
+		}
+
+		var candidates []scanKey
+		s := c.shard(shard)
+		s.RLock()
+		for k, e := range s.Entries {
+			if h := scanHash(k); h >= pos && !e.expired() {
+				candidates = append(candidates, scanKey{k, h})
+			}
+		}
+		s.RUnlock()
+
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].hash < candidates[j].hash
+		})
+
+		for i, cand := range candidates {
+			// keys with the same hash are returned together, the cursor
+			// cannot point between them
+			if len(keys) >= count && cand.hash != candidates[i-1].hash {
+				return keys, uint64(shard)<<scanHashBits | cand.hash
+			}
+			keys = append(keys, cand.key)
+		}
+
+		if len(keys) >= count {
+			if shard+1 == c.len() {
+				return keys, 0
+			}
+			return keys, uint64(shard+1) << scanHashBits
+		}
+	}
+
+	return keys, 0
+}
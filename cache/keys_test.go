@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestKeys(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	var expected []string
+	for i := 0; i < 10; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+		expected = append(expected, key+strconv.Itoa(i))
+	}
+	c.SetWithTTL("expired", value, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	keys := c.Keys()
+	sort.Strings(keys)
+
+	if len(keys) != len(expected) {
+		t.Error("Expected", expected, "got", keys)
+		t.FailNow()
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Error("Expected", expected, "got", keys)
+			t.Fail()
+			break
+		}
+	}
+}
+
+func TestKeysWithPrefix(t *testing.T) {
+	value := "testValue"
+
+	c := New()
+	c.Set("user:1:name", value)
+	c.Set("user:1:mail", value)
+	c.Set("user:10:name", value)
+	c.Set("session:1", value)
+
+	keys := c.KeysWithPrefix("user:1:")
+	sort.Strings(keys)
+
+	if len(keys) != 2 || keys[0] != "user:1:mail" || keys[1] != "user:1:name" {
+		t.Error("Expected the keys of user 1, got", keys)
+		t.Fail()
+	}
+
+	if keys := c.KeysWithPrefix("missing"); len(keys) != 0 {
+		t.Error("Expected no keys, got", keys)
+		t.Fail()
+	}
+}
+
+func TestScan(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	for i := 0; i < 1000; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	seen := make(map[string]int)
+	var cursor uint64
+	calls := 0
+	for {
+		var keys []string
+		keys, cursor = c.Scan(cursor, 50)
+		for _, k := range keys {
+			seen[k]++
+		}
+
+		// modify the cache during the iteration
+		c.Set("added"+strconv.Itoa(calls), value)
+
+		calls++
+		if cursor == 0 {
+			break
+		}
+		if calls > 1000 {
+			t.Error("Scan should have ended.")
+			t.FailNow()
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		if n := seen[key+strconv.Itoa(i)]; n != 1 {
+			t.Errorf("Expected %s to be returned once. Got %d", key+strconv.Itoa(i), n)
+			t.Fail()
+		}
+	}
+
+	if calls < 20 || calls > 25 {
+		t.Errorf("Expected about 20 calls. Got %d", calls)
+		t.Fail()
+	}
+}
+
+func TestMatch(t *testing.T) {
+	value := "testValue"
+
+	c := New()
+	c.Set("user:1:name", value)
+	c.Set("user:2:name", value)
+	c.Set("user:2:mail", value)
+	c.Set("session:1", value)
+
+	keys := c.Match("user:*:name")
+	sort.Strings(keys)
+
+	if len(keys) != 2 || keys[0] != "user:1:name" || keys[1] != "user:2:name" {
+		t.Error("Expected the names of all users, got", keys)
+		t.Fail()
+	}
+
+	keys = c.MatchRegexp(regexp.MustCompile(`^user:\d+:mail$`))
+	if len(keys) != 1 || keys[0] != "user:2:mail" {
+		t.Error("Expected the mail of user 2, got", keys)
+		t.Fail()
+	}
+}
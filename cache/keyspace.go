@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Channel prefixes of keyspace events, the ones used by Redis for database 0.
+const (
+	KeyspacePrefix = "__keyspace@0__:"
+	KeyeventPrefix = "__keyevent@0__:"
+)
+
+// keyspaceClass is a set of keyspace event classes.
+type keyspaceClass int
+
+const (
+	classKeyspace keyspaceClass = 1 << iota
+	classKeyevent
+	classGeneric
+	classString
+	classExpired
+	classEvicted
+
+	classAll = classGeneric | classString | classExpired | classEvicted
+)
+
+// parseKeyspaceClasses parses event classes in the format of the Redis
+// setting notify-keyspace-events. Unknown classes are ignored.
+func parseKeyspaceClasses(classes string) keyspaceClass {
+	var c keyspaceClass
+	for _, r := range classes {
+		switch r {
+		case 'K':
+			c |= classKeyspace
+		case 'E':
+			c |= classKeyevent
+		case 'g':
+			c |= classGeneric
+		case '$':
+			c |= classString
+		case 'x':
+			c |= classExpired
+		case 'e':
+			c |= classEvicted
+		case 'A':
+			c |= classAll
+		}
+	}
+	return c
+}
+
+// keyspaceEvents maps the event types of the cache to the names and classes
+// of the Redis events.
+var keyspaceEvents = map[EventType]struct {
+	name  string
+	class keyspaceClass
+}{
+	EventSet:    {"set", classString},
+	EventRemove: {"del", classGeneric},
+	EventExpire: {"expired", classExpired},
+	EventEvict:  {"evicted", classEvicted},
+}
+
+// KeyspaceEvent is a keyspace notification in the format of Redis. Events on
+// a channel KeyspacePrefix+key carry the event name, e.g. "set", "del",
+// "expired" or "evicted", as message. Events on a channel
+// KeyeventPrefix+event carry the key as message.
+type KeyspaceEvent struct {
+	Channel string
+	Message string
+	// Dropped is the number of events dropped for the subscription before
+	// this one because its buffer was full
+	Dropped int
+}
+
+// keyspaceSubscription delivers keyspace events of channels matching a
+// pattern.
+type keyspaceSubscription struct {
+	pattern string
+	events  chan KeyspaceEvent
+	dropped int
+}
+
+// keyspace passes keyspace events to subscribers.
+type keyspace struct {
+	classes keyspaceClass
+	subs    map[<-chan KeyspaceEvent]*keyspaceSubscription
+	// active is the number of subscriptions, checked without locking on
+	// every change
+	active atomic.Int32
+	closed bool
+	sync.Mutex
+}
+
+func newKeyspace() *keyspace {
+	return &keyspace{
+		subs: make(map[<-chan KeyspaceEvent]*keyspaceSubscription),
+	}
+}
+
+// SubscribeKeyspace returns a channel receiving the keyspace events of
+// channels matching the glob pattern, e.g. KeyspacePrefix+"user:*" or
+// KeyeventPrefix+"expired". Events are only sent for the classes enabled with
+// WithKeyspaceEvents. Like Subscribe, events are dropped if the buffer of the
+// channel is full. The channel is closed by UnsubscribeKeyspace and Close.
+func (c *Cache) SubscribeKeyspace(pattern string) <-chan KeyspaceEvent {
+	k := c.keyspace
+	k.Lock()
+	defer k.Unlock()
+
+	sub := &keyspaceSubscription{
+		pattern: pattern,
+		events:  make(chan KeyspaceEvent, c.subscriptions.buffer),
+	}
+	if k.closed {
+		close(sub.events)
+		return sub.events
+	}
+
+	k.subs[sub.events] = sub
+	k.active.Add(1)
+
+	return sub.events
+}
+
+// UnsubscribeKeyspace stops the delivery of events to a channel returned by
+// SubscribeKeyspace and closes it.
+func (c *Cache) UnsubscribeKeyspace(events <-chan KeyspaceEvent) {
+	k := c.keyspace
+	k.Lock()
+	defer k.Unlock()
+
+	if sub, ok := k.subs[events]; ok {
+		delete(k.subs, events)
+		k.active.Add(-1)
+		close(sub.events)
+	}
+}
+
+// publish delivers the keyspace events of a change to all matching
+// subscriptions without blocking.
+func (k *keyspace) publish(typ EventType, key string) {
+	if k.classes == 0 || k.active.Load() == 0 {
+		return
+	}
+
+	ev, ok := keyspaceEvents[typ]
+	if !ok || k.classes&ev.class == 0 {
+		return
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	if k.classes&classKeyspace != 0 {
+		k.send(KeyspacePrefix+key, ev.name)
+	}
+	if k.classes&classKeyevent != 0 {
+		k.send(KeyeventPrefix+ev.name, key)
+	}
+}
+
+// send delivers a message to the subscriptions matching the channel. The
+// keyspace has to be locked.
+func (k *keyspace) send(channel, message string) {
+	for _, sub := range k.subs {
+		if !matchGlob(sub.pattern, channel) {
+			continue
+		}
+
+		select {
+		case sub.events <- KeyspaceEvent{Channel: channel, Message: message, Dropped: sub.dropped}:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// close closes all subscriptions.
+func (k *keyspace) close() {
+	k.Lock()
+	defer k.Unlock()
+
+	k.closed = true
+	for events, sub := range k.subs {
+		delete(k.subs, events)
+		k.active.Add(-1)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyspaceEvents(t *testing.T) {
+	value := "testValue"
+
+	c := New(WithKeyspaceEvents("K$x"))
+
+	events := c.SubscribeKeyspace(KeyspacePrefix + "user:*")
+	keyevents := c.SubscribeKeyspace(KeyeventPrefix + "*")
+
+	c.Set("user:1", value)
+	c.Set("session:1", value)
+	c.Remove("user:1")
+	c.SetWithTTL("user:2", value, 10*time.Millisecond)
+
+	expected := []KeyspaceEvent{
+		{Channel: KeyspacePrefix + "user:1", Message: "set"},
+		{Channel: KeyspacePrefix + "user:2", Message: "set"},
+		{Channel: KeyspacePrefix + "user:2", Message: "expired"},
+	}
+
+	for _, exp := range expected {
+		select {
+		case ev := <-events:
+			if ev != exp {
+				t.Error("Expected", exp, "got", ev)
+				t.Fail()
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected", exp, "got nothing")
+			t.Fail()
+		}
+	}
+
+	select {
+	case ev := <-keyevents:
+		t.Error("Keyevent channels should be disabled, got", ev)
+		t.Fail()
+	default:
+	}
+
+	c.UnsubscribeKeyspace(events)
+
+	if _, ok := <-events; ok {
+		t.Error("Channel should have been closed.")
+		t.Fail()
+	}
+}
+
+func TestKeyeventEvents(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithKeyspaceEvents("EA"))
+
+	events := c.SubscribeKeyspace(KeyeventPrefix + "*")
+
+	c.Set(key, value)
+	c.Remove(key)
+
+	expected := []KeyspaceEvent{
+		{Channel: KeyeventPrefix + "set", Message: key},
+		{Channel: KeyeventPrefix + "del", Message: key},
+	}
+
+	for _, exp := range expected {
+		if ev := <-events; ev != exp {
+			t.Error("Expected", exp, "got", ev)
+			t.Fail()
+		}
+	}
+}
+
+func TestKeyspaceEventsDisabled(t *testing.T) {
+	c := New(WithKeyspaceEvents("A"))
+
+	events := c.SubscribeKeyspace("*")
+
+	c.Set("testKey", "testValue")
+
+	select {
+	case ev := <-events:
+		t.Error("Events without K or E should be disabled, got", ev)
+		t.Fail()
+	default:
+	}
+}
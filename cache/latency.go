@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets is the number of buckets of latency histograms. The upper
+// bounds of the buckets double from one microsecond, the last bucket holds
+// all latencies above about 8 seconds.
+const latencyBuckets = 25
+
+// latencyBound returns the upper bound of bucket i.
+func latencyBound(i int) time.Duration {
+	if i == latencyBuckets-1 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Microsecond << i
+}
+
+// Bucket is a bucket of a Histogram.
+type Bucket struct {
+	// UpperBound is the longest latency counted in the bucket
+	UpperBound time.Duration `json:"upper_bound"`
+	Count      int           `json:"count"`
+}
+
+// Histogram is the distribution of the latencies of an operation.
+type Histogram struct {
+	Buckets []Bucket      `json:"buckets"`
+	Count   int           `json:"count"`
+	Sum     time.Duration `json:"sum"`
+}
+
+// Quantile returns the upper bound of the bucket containing the latency of
+// quantile q, e.g. 0.99 for the 99th percentile, or zero if nothing was
+// recorded.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	rank := int(q * float64(h.Count))
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+
+	n := 0
+	for _, b := range h.Buckets {
+		n += b.Count
+		if n > rank {
+			return b.UpperBound
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1].UpperBound
+}
+
+// Latencies holds the latency histograms of the operations of a cache.
+type Latencies struct {
+	// Get covers Get including loading missing values
+	Get Histogram `json:"get"`
+	// Set covers Set, SetWithTTL and SetWithSlidingTTL
+	Set    Histogram `json:"set"`
+	Remove Histogram `json:"remove"`
+	// Load covers the invocations of the Loader
+	Load Histogram `json:"load"`
+}
+
+// histogram records latencies atomically.
+type histogram struct {
+	counts [latencyBuckets]atomic.Int64
+	sum    atomic.Int64
+}
+
+// since records the time passed since start.
+func (h *histogram) since(start time.Time) {
+	d := time.Since(start)
+
+	i := 0
+	for i < latencyBuckets-1 && d > latencyBound(i) {
+		i++
+	}
+
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Buckets: make([]Bucket, latencyBuckets),
+		Sum:     time.Duration(h.sum.Load()),
+	}
+	for i := range s.Buckets {
+		n := int(h.counts[i].Load())
+		s.Buckets[i] = Bucket{UpperBound: latencyBound(i), Count: n}
+		s.Count += n
+	}
+	return s
+}
+
+func (h *histogram) reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.sum.Store(0)
+}
+
+// latencies records the latencies of the operations of a cache.
+type latencies struct {
+	get    histogram
+	set    histogram
+	remove histogram
+	load   histogram
+}
+
+func (l *latencies) snapshot() *Latencies {
+	return &Latencies{
+		Get:    l.get.snapshot(),
+		Set:    l.set.snapshot(),
+		Remove: l.remove.snapshot(),
+		Load:   l.load.snapshot(),
+	}
+}
+
+func (l *latencies) reset() {
+	l.get.reset()
+	l.set.reset()
+	l.remove.reset()
+	l.load.reset()
+}
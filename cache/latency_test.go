@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithLatencyTracking(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	loader := LoaderFunc(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		time.Sleep(10 * time.Millisecond)
+		return value, 0, nil
+	})
+
+	c := New(WithLatencyTracking(), WithLoader(loader))
+	c.Set(key, value)
+	c.Get(key)
+	c.Get("testOtherKey")
+	c.Remove(key)
+
+	l := c.GetStats().Latency
+	if l == nil {
+		t.Error("Latencies should have been reported.")
+		t.FailNow()
+	}
+
+	if l.Get.Count != 2 || l.Set.Count != 1 || l.Remove.Count != 1 || l.Load.Count != 1 {
+		t.Errorf("Unexpected counts %d, %d, %d, %d", l.Get.Count, l.Set.Count, l.Remove.Count, l.Load.Count)
+		t.Fail()
+	}
+
+	if q := l.Load.Quantile(0.99); q < 10*time.Millisecond || q > 20*time.Millisecond {
+		t.Errorf("Expected the loader latency bucket to be bounded by 16ms. Got %s", q)
+		t.Fail()
+	}
+
+	if l.Get.Sum < 10*time.Millisecond {
+		t.Errorf("Expected Get to include loading. Got %s", l.Get.Sum)
+		t.Fail()
+	}
+
+	if New().GetStats().Latency != nil {
+		t.Error("Latencies should only be reported if enabled.")
+		t.Fail()
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var h histogram
+	start := time.Now()
+	for i := 0; i < 99; i++ {
+		h.since(start.Add(time.Hour))
+	}
+	h.since(start.Add(-time.Second))
+
+	s := h.snapshot()
+	if q := s.Quantile(0.5); q != time.Microsecond {
+		t.Errorf("Expected a median of 1µs. Got %s", q)
+		t.Fail()
+	}
+	if q := s.Quantile(0.999); q < time.Second || q > 2*time.Second {
+		t.Errorf("Expected a 99.9th percentile between 1s and 2s. Got %s", q)
+		t.Fail()
+	}
+
+	var empty Histogram
+	if empty.Quantile(0.5) != 0 {
+		t.Error("Quantile of an empty histogram should be zero.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// WritePolicy determines how writes to a Layered cache are applied to one of
+// its tiers.
+type WritePolicy int
+
+const (
+	// WriteThrough applies Set and Remove to the tier.
+	WriteThrough WritePolicy = iota
+	// WriteInvalidate removes the key from the tier on Set and Remove. The
+	// tier is only populated by reads falling through to lower tiers.
+	WriteInvalidate
+	// ReadOnly never modifies the tier.
+	ReadOnly
+)
+
+// Layer is a cache usable as a tier of a Layered cache.
+type Layer interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	SetWithTTL(key string, value interface{}, ttl time.Duration)
+	Remove(key string)
+	GetStats() *Stats
+}
+
+var _ Layer = (*Cache)(nil)
+
+// Tier is a layer of a Layered cache.
+type Tier struct {
+	Layer  Layer
+	Policy WritePolicy
+	// TTL is used when the tier is populated from a lower tier. Zero uses
+	// the default ttl of the cache.
+	TTL time.Duration
+	// PromoteAfter is the number of hits a key needs in lower tiers before
+	// it is promoted to this tier. Zero promotes on the first hit.
+	PromoteAfter int
+	// Demote writes entries evicted from this tier because it is full down
+	// to the next tier instead of dropping them. Only a *Cache with a
+	// capacity limit evicts entries.
+	Demote bool
+}
+
+// Layered composes multiple layers, e.g. a small fast L1 in front of a larger
+// L2. Get falls through the tiers in order and populates the tiers above the
+// one holding the value.
+type Layered struct {
+	tiers []Tier
+	// hits counts hits of keys in lower tiers for promotion
+	hits  *Cache
+	stats Stats
+	sync.Mutex
+}
+
+var _ Layer = (*Layered)(nil)
+
+// promotionCandidates limits the number of keys hits are counted for.
+const promotionCandidates = 1 << 16
+
+// NewLayered returns a reference to a new Layered cache with the given tiers,
+// the first one being the topmost.
+func NewLayered(tiers ...Tier) *Layered {
+	l := &Layered{
+		tiers: tiers,
+		hits:  New(WithMaxEntries(promotionCandidates)),
+		stats: Stats{Uptime: time.Now().UTC()},
+	}
+
+	for i, t := range tiers {
+		c, ok := t.Layer.(*Cache)
+		if !t.Demote || !ok || i == len(tiers)-1 {
+			continue
+		}
+
+		lower := tiers[i+1]
+		c.onEvict(func(key string, value interface{}, ttl time.Duration) {
+			lower.demote(key, value, ttl)
+		})
+	}
+
+	return l
+}
+
+// Get retrieves a value stored with a specific key from the topmost tier
+// holding it. Tiers above that one get populated unless they are ReadOnly. If
+// no value is available nil and false will be returned.
+func (l *Layered) Get(key string) (interface{}, bool) {
+	for i, t := range l.tiers {
+		v, ok := t.Layer.Get(key)
+		if !ok {
+			continue
+		}
+
+		l.count(func(s *Stats) { s.Hits++ })
+
+		if i == 0 {
+			return v, true
+		}
+
+		n := l.countHit(key)
+		for _, upper := range l.tiers[:i] {
+			if n >= upper.PromoteAfter {
+				upper.populate(key, v)
+			}
+		}
+
+		return v, true
+	}
+
+	l.count(func(s *Stats) { s.Misses++ })
+
+	return nil, false
+}
+
+// Set stores the value with the given key in the tiers according to their
+// write policies, starting with the lowest tier.
+func (l *Layered) Set(key string, value interface{}) {
+	l.count(func(s *Stats) { s.Set++ })
+
+	for i := len(l.tiers) - 1; i >= 0; i-- {
+		t := l.tiers[i]
+		switch t.Policy {
+		case WriteThrough:
+			t.Layer.Set(key, value)
+		case WriteInvalidate:
+			t.Layer.Remove(key)
+		}
+	}
+}
+
+// SetWithTTL stores the value with the given key like Set and removes it
+// automatically after ttl.
+func (l *Layered) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	l.count(func(s *Stats) { s.Set++ })
+
+	for i := len(l.tiers) - 1; i >= 0; i-- {
+		t := l.tiers[i]
+		switch t.Policy {
+		case WriteThrough:
+			t.Layer.SetWithTTL(key, value, ttl)
+		case WriteInvalidate:
+			t.Layer.Remove(key)
+		}
+	}
+}
+
+// Remove deletes a value stored with the given key from all tiers that are
+// not ReadOnly, starting with the lowest tier.
+func (l *Layered) Remove(key string) {
+	l.count(func(s *Stats) { s.Removed++ })
+
+	for i := len(l.tiers) - 1; i >= 0; i-- {
+		t := l.tiers[i]
+		if t.Policy != ReadOnly {
+			t.Layer.Remove(key)
+		}
+	}
+}
+
+// GetStats returns Stats for this layered cache. Hits and misses count Get
+// calls served by any tier or by none, Set and Removed count the respective
+// calls and Expired and Evicted sum up the expiries and evictions of all
+// tiers. The stats of each tier, including their number of entries, are
+// included in Layers, starting with the topmost tier.
+func (l *Layered) GetStats() *Stats {
+	l.Lock()
+	s := l.stats
+	l.Unlock()
+
+	s.Layers = make([]*Stats, len(l.tiers))
+	for i, t := range l.tiers {
+		s.Layers[i] = t.Layer.GetStats()
+		s.Expired += s.Layers[i].Expired
+		s.Evicted += s.Layers[i].Evicted
+	}
+	s.SetHitRatio()
+
+	return &s
+}
+
+func (l *Layered) count(f func(*Stats)) {
+	l.Lock()
+	f(&l.stats)
+	l.Unlock()
+}
+
+// countHit counts a hit of the key in a lower tier and returns the number of
+// hits so far.
+func (l *Layered) countHit(key string) int {
+	l.Lock()
+	defer l.Unlock()
+
+	n := 1
+	if v, ok := l.hits.get(key); ok {
+		n += v.(int)
+	}
+	l.hits.Set(key, n)
+
+	return n
+}
+
+func (t Tier) populate(key string, value interface{}) {
+	if t.Policy == ReadOnly {
+		return
+	}
+
+	if t.TTL > 0 {
+		t.Layer.SetWithTTL(key, value, t.TTL)
+		return
+	}
+
+	t.Layer.Set(key, value)
+}
+
+func (t Tier) demote(key string, value interface{}, ttl time.Duration) {
+	// expired entries are dropped
+	if t.Policy == ReadOnly || ttl < 0 {
+		return
+	}
+
+	if ttl > 0 {
+		t.Layer.SetWithTTL(key, value, ttl)
+		return
+	}
+
+	t.Layer.Set(key, value)
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLayeredGet(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := New()
+	l2 := New()
+
+	l := NewLayered(
+		Tier{Layer: l1, TTL: 10 * time.Millisecond},
+		Tier{Layer: l2},
+	)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should not have been found.")
+		t.Fail()
+	}
+
+	l2.Set(key, value)
+
+	v, ok := l.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if _, ok := l1.Get(key); !ok {
+		t.Error("Upper tier should have been populated.")
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := l1.Get(key); ok {
+		t.Error("Populated element should have expired.")
+		t.Fail()
+	}
+}
+
+func TestLayeredWritePolicies(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := New()
+	l2 := New()
+	l3 := New()
+
+	l := NewLayered(
+		Tier{Layer: l1, Policy: WriteInvalidate},
+		Tier{Layer: l2},
+		Tier{Layer: l3, Policy: ReadOnly},
+	)
+
+	l1.Set(key, "oldValue")
+	l.Set(key, value)
+
+	if _, ok := l1.Get(key); ok {
+		t.Error("Element should have been invalidated.")
+		t.Fail()
+	}
+
+	if v, ok := l2.Get(key); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if _, ok := l3.Get(key); ok {
+		t.Error("Read only tier should not have been written.")
+		t.Fail()
+	}
+
+	l3.Set(key, value)
+	l.Remove(key)
+
+	if _, ok := l2.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+
+	if _, ok := l3.Get(key); !ok {
+		t.Error("Read only tier should not have been modified.")
+		t.Fail()
+	}
+}
+
+func TestLayeredPromotion(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := New()
+	l2 := New()
+
+	l := NewLayered(
+		Tier{Layer: l1, PromoteAfter: 3},
+		Tier{Layer: l2},
+	)
+
+	l2.Set(key, value)
+
+	for i := 0; i < 2; i++ {
+		l.Get(key)
+
+		if _, ok := l1.Get(key); ok {
+			t.Error("Element should not have been promoted yet.")
+			t.Fail()
+		}
+	}
+
+	l.Get(key)
+
+	if _, ok := l1.Get(key); !ok {
+		t.Error("Element should have been promoted.")
+		t.Fail()
+	}
+}
+
+func TestLayeredDemotion(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := New(WithMaxEntries(DefaultShards))
+	l2 := New()
+
+	l := NewLayered(
+		Tier{Layer: l1, Policy: WriteInvalidate, Demote: true},
+		Tier{Layer: l2, Policy: ReadOnly},
+	)
+
+	for i := 0; i < 1000; i++ {
+		l1.Set(key+strconv.Itoa(i), value)
+	}
+
+	s1 := l1.GetStats()
+	s2 := l2.GetStats()
+
+	if s1.Evicted == 0 || s2.Set != 0 {
+		t.Error("Read only tier should not receive demoted elements.")
+		t.Fail()
+	}
+
+	l1 = New(WithMaxEntries(DefaultShards))
+	l2 = New()
+
+	l = NewLayered(
+		Tier{Layer: l1, Demote: true},
+		Tier{Layer: l2},
+	)
+
+	for i := 0; i < 1000; i++ {
+		l1.Set(key+strconv.Itoa(i), value)
+	}
+
+	s1 = l1.GetStats()
+	s2 = l2.GetStats()
+
+	if s1.Evicted == 0 || s1.Evicted != s2.Set {
+		t.Errorf("Expected %d demoted elements. Got %d", s1.Evicted, s2.Set)
+		t.Fail()
+	}
+
+	for i := 0; i < 1000; i++ {
+		if _, ok := l.Get(key + strconv.Itoa(i)); !ok {
+			t.Error("Element should be available in one of the tiers.")
+			t.Fail()
+		}
+	}
+}
+
+func TestLayeredStats(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := New()
+	l2 := New()
+
+	l := NewLayered(
+		Tier{Layer: l1, Policy: WriteInvalidate},
+		Tier{Layer: l2},
+	)
+
+	l.Set(key, value)
+	l.Get(key)
+	l.Get(key)
+	l.Get(key + "1")
+
+	s := l.GetStats()
+
+	if s.Set != 1 || s.Hits != 2 || s.Misses != 1 {
+		t.Errorf("Unexpected aggregated stats %+v", s)
+		t.Fail()
+	}
+
+	if len(s.Layers) != 2 {
+		t.Errorf("Expected stats for 2 layers. Got %d", len(s.Layers))
+		t.Fail()
+	}
+
+	if s.Layers[0].Hits != 1 || s.Layers[0].Misses != 2 {
+		t.Errorf("Unexpected stats for first layer %+v", s.Layers[0])
+		t.Fail()
+	}
+
+	if s.Layers[1].Hits != 1 || s.Layers[1].Misses != 1 || s.Layers[1].Set != 1 {
+		t.Errorf("Unexpected stats for second layer %+v", s.Layers[1])
+		t.Fail()
+	}
+}
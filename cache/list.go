@@ -0,0 +1,128 @@
+package cache
+
+import "encoding/gob"
+
+// List is the value stored by the list operations LPush and RPush. Every
+// push or pop copies the list (see modify), so it costs O(n) in its length.
+type List []interface{}
+
+func init() {
+	gob.Register(List{})
+}
+
+// LPush inserts the values at the head of the list stored with the given
+// key, one after another, and returns the length of the list. Missing keys
+// are stored as new lists with the default ttl of the cache. If the value
+// stored with the key is not a List ErrWrongType is returned.
+func (c *Cache) LPush(key string, values ...interface{}) (int, error) {
+	return c.push(key, values, true)
+}
+
+// RPush appends the values to the tail of the list stored with the given key
+// like LPush.
+func (c *Cache) RPush(key string, values ...interface{}) (int, error) {
+	return c.push(key, values, false)
+}
+
+func (c *Cache) push(key string, values []interface{}, head bool) (n int, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		l, err := asList(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		pushed := make(List, 0, len(l)+len(values))
+		if head {
+			for i := len(values) - 1; i >= 0; i-- {
+				pushed = append(pushed, values[i])
+			}
+			pushed = append(pushed, l...)
+		} else {
+			pushed = append(append(pushed, l...), values...)
+		}
+
+		n = len(pushed)
+		return pushed, nil
+	})
+
+	return n, err
+}
+
+// LPop removes and returns the head of the list stored with the given key.
+// The key is deleted with the last element of the list. If there is no list
+// ErrNotFound is returned.
+func (c *Cache) LPop(key string) (value interface{}, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		l, err := asList(v, ok)
+		if err != nil {
+			return nil, err
+		}
+		if len(l) == 0 {
+			return nil, ErrNotFound
+		}
+
+		value = l[0]
+		if len(l) == 1 {
+			return nil, nil
+		}
+		return l[1:], nil
+	})
+
+	return value, err
+}
+
+// LRange returns the elements of the list stored with the given key from
+// start to stop, both included. Negative indexes count from the tail of the
+// list, -1 being the last element. Indexes out of range are limited to the
+// list, a missing key is an empty list.
+func (c *Cache) LRange(key string, start, stop int) ([]interface{}, error) {
+	l, err := c.list(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if start < 0 {
+		start += len(l)
+	}
+	if stop < 0 {
+		stop += len(l)
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= len(l) {
+		stop = len(l) - 1
+	}
+	if start > stop {
+		return []interface{}{}, nil
+	}
+
+	return append([]interface{}(nil), l[start:stop+1]...), nil
+}
+
+// LLen returns the length of the list stored with the given key, zero if
+// there is none.
+func (c *Cache) LLen(key string) (int, error) {
+	l, err := c.list(key)
+	return len(l), err
+}
+
+// list returns the list stored with the given key counting the access like
+// Get.
+func (c *Cache) list(key string) (List, error) {
+	v, ok := c.get(key)
+	return asList(v, ok)
+}
+
+func asList(v interface{}, ok bool) (List, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	l, isList := v.(List)
+	if !isList {
+		return nil, ErrWrongType
+	}
+
+	return l, nil
+}
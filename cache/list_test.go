@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	if n, err := c.RPush(key, 3, 4); err != nil || n != 2 {
+		t.Errorf("Expected length 2. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if n, err := c.LPush(key, 2, 1); err != nil || n != 4 {
+		t.Errorf("Expected length 4. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	values, err := c.LRange(key, 0, -1)
+	if err != nil || !reflect.DeepEqual(values, []interface{}{1, 2, 3, 4}) {
+		t.Error("Expected [1 2 3 4] got", values, err)
+		t.Fail()
+	}
+
+	values, _ = c.LRange(key, -2, 10)
+	if !reflect.DeepEqual(values, []interface{}{3, 4}) {
+		t.Error("Expected [3 4] got", values)
+		t.Fail()
+	}
+
+	for i := 1; i <= 4; i++ {
+		if v, err := c.LPop(key); err != nil || v != i {
+			t.Error("Expected", i, "got", v, err)
+			t.Fail()
+		}
+	}
+
+	if _, err := c.LPop(key); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound got", err)
+		t.Fail()
+	}
+
+	if c.Has(key) {
+		t.Error("Empty list should have been removed.")
+		t.Fail()
+	}
+
+	if n, err := c.LLen(key); err != nil || n != 0 {
+		t.Errorf("Expected length 0. Got %d, %v", n, err)
+		t.Fail()
+	}
+}
+
+func TestListWrongType(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	if _, err := c.LPush(key, value); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+
+	if _, err := c.LLen(key); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
+
+func TestListStoreError(t *testing.T) {
+	key := "testKey"
+	store := newTestStore()
+	storeErr := errors.New("store failed")
+
+	c := New(WithStore(store))
+	c.RPush(key, 1)
+
+	store.err = storeErr
+
+	if _, err := c.LPop(key); !errors.Is(err, storeErr) {
+		t.Error("Expected", storeErr, "got", err)
+		t.Fail()
+	}
+
+	if n, _ := c.LLen(key); n != 1 {
+		t.Errorf("Expected the element to be kept. Got %d elements", n)
+		t.Fail()
+	}
+}
+
+func TestListCopyOnWrite(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+	c.RPush(key, 1, 2)
+
+	before, _ := c.Get(key)
+	c.LPop(key)
+	c.RPush(key, 3)
+
+	if !reflect.DeepEqual(before, List{1, 2}) {
+		t.Error("Previously returned list should not change, got", before)
+		t.Fail()
+	}
+}
+
+func TestListSnapshot(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+	c.RPush(key, "a", "b")
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Error("Failed to save snapshot:", err)
+		t.FailNow()
+	}
+
+	restored := New()
+	if err := restored.LoadSnapshot(&buf); err != nil {
+		t.Error("Failed to load snapshot:", err)
+		t.FailNow()
+	}
+
+	if values, _ := restored.LRange(key, 0, -1); !reflect.DeepEqual(values, []interface{}{"a", "b"}) {
+		t.Error("Expected [a b] got", values)
+		t.Fail()
+	}
+}
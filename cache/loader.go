@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// call is an in-flight or completed loader invocation.
+type call struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// group deduplicates concurrent loader invocations for the same key.
+type group struct {
+	calls map[string]*call
+	sync.Mutex
+}
+
+// do invokes fn once for all concurrent callers with the same key and returns
+// its results to each of them.
+func (g *group) do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if cl, ok := g.calls[key]; ok {
+		g.Unlock()
+		cl.wg.Wait()
+		return cl.value, cl.err
+	}
+
+	cl := &call{}
+	cl.wg.Add(1)
+	g.calls[key] = cl
+	g.Unlock()
+
+	cl.value, cl.err = fn()
+	cl.wg.Done()
+
+	g.Lock()
+	delete(g.calls, key)
+	g.Unlock()
+
+	return cl.value, cl.err
+}
+
+// GetOrLoad retrieves the value stored with a specific key. On a miss loader
+// is invoked and its result is stored like Set before it is returned.
+// Concurrent misses for the same key share a single loader invocation. If
+// the loader fails the error is returned and nothing is stored.
+func (c *Cache) GetOrLoad(key string, loader func() (interface{}, error)) (interface{}, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	return c.loads.do(key, func() (interface{}, error) {
+		v, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Set(key, v)
+
+		return v, nil
+	})
+}
+
+// ErrNotFound is returned by Fetch if no value is stored with a key and the
+// cache has no Loader.
+var ErrNotFound = errors.New("cache: key not found")
+
+// Loader loads values missing from a read-through cache. A ttl of zero stores
+// the value with the default ttl of the cache, NoExpiration without expiry.
+type Loader interface {
+	Load(ctx context.Context, key string) (value interface{}, ttl time.Duration, err error)
+}
+
+// LoaderFunc is an adapter to use ordinary functions as Loader.
+type LoaderFunc func(ctx context.Context, key string) (interface{}, time.Duration, error)
+
+// Load calls f(ctx, key).
+func (f LoaderFunc) Load(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	return f(ctx, key)
+}
+
+// ErrorPolicy determines how a read-through cache handles Loader errors.
+type ErrorPolicy int
+
+const (
+	// PropagateErrors returns Loader errors to the caller and invokes the
+	// Loader again on the next miss.
+	PropagateErrors ErrorPolicy = iota
+	// CacheErrors remembers Loader errors for a while and returns them for
+	// the key without invoking the Loader again.
+	CacheErrors
+)
+
+// Fetch retrieves the value stored with a specific key. On a miss the value
+// is loaded with the Loader of the cache, concurrent misses for the same key
+// share a single invocation using the context of the first caller. Without a
+// Loader ErrNotFound is returned on a miss.
+func (c *Cache) Fetch(ctx context.Context, key string) (interface{}, error) {
+	if v, ok := c.get(key); ok {
+		return v, nil
+	}
+
+	if c.loader == nil {
+		return nil, ErrNotFound
+	}
+
+	return c.load(ctx, key)
+}
+
+// load invokes the Loader for the given key and stores its result.
+func (c *Cache) load(ctx context.Context, key string) (interface{}, error) {
+	if c.failures != nil {
+		if err, ok := c.failures.get(key); ok {
+			return nil, err.(error)
+		}
+	}
+
+	return c.loads.do(key, func() (interface{}, error) {
+		if c.latency != nil {
+			defer c.latency.load.since(time.Now())
+		}
+
+		v, ttl, err := c.loader.Load(ctx, key)
+		if err != nil {
+			// failures of cancelled loads are not remembered for others
+			if c.failures != nil && ctx.Err() == nil {
+				c.failures.Set(key, err)
+			}
+			return nil, err
+		}
+
+		if ttl == 0 {
+			ttl = c.defaultTTL
+		}
+
+		// loaded values are not written through to the store
+		sh := c.lockShard(key)
+		c.set(sh, key, v, ttl)
+		sh.Unlock()
+
+		return v, nil
+	})
+}
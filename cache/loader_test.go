@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	v, err := c.GetOrLoad(key, func() (interface{}, error) {
+		return value, nil
+	})
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	v, ok := c.Get(key)
+	if !ok || v != value {
+		t.Error("Loaded element should have been stored.")
+		t.Fail()
+	}
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	key := "testKey"
+	loadErr := errors.New("load failed")
+
+	c := New()
+
+	_, err := c.GetOrLoad(key, func() (interface{}, error) {
+		return nil, loadErr
+	})
+	if err != loadErr {
+		t.Error("Expected", loadErr, "got", err)
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Element should not have been stored.")
+		t.Fail()
+	}
+}
+
+func TestGetOrLoadDeduplicates(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := c.GetOrLoad(key, func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return value, nil
+			})
+			if v != value {
+				t.Error("Expected", value, "got", v)
+				t.Fail()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected loader to be called once. Got %d", calls)
+		t.Fail()
+	}
+}
+
+func TestReadThrough(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	var calls int32
+	c := New(WithLoader(LoaderFunc(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return value, 10 * time.Millisecond, nil
+	})))
+
+	v, ok := c.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	v, err := c.Fetch(context.Background(), key)
+	if err != nil || v != value {
+		t.Error("Expected", value, "got", v, err)
+		t.Fail()
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected loader to be called once. Got %d", calls)
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.Get(key)
+
+	if calls != 2 {
+		t.Errorf("Expected loader to be called again after expiry. Got %d", calls)
+		t.Fail()
+	}
+}
+
+func TestReadThroughErrors(t *testing.T) {
+	key := "testKey"
+	loadErr := errors.New("load failed")
+
+	var calls int32
+	loader := LoaderFunc(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, 0, loadErr
+	})
+
+	c := New(WithLoader(loader))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Fetch(context.Background(), key); err != loadErr {
+			t.Error("Expected", loadErr, "got", err)
+			t.Fail()
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected errors to be propagated. Got %d loader calls", calls)
+		t.Fail()
+	}
+
+	calls = 0
+	c = New(WithLoader(loader), WithLoaderErrorPolicy(CacheErrors, 10*time.Millisecond))
+
+	for i := 0; i < 2; i++ {
+		if _, ok := c.Get(key); ok {
+			t.Error("Element should not have been loaded.")
+			t.Fail()
+		}
+	}
+
+	if _, err := c.Fetch(context.Background(), key); err != loadErr {
+		t.Error("Expected", loadErr, "got", err)
+		t.Fail()
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected errors to be cached. Got %d loader calls", calls)
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.Get(key)
+
+	if calls != 2 {
+		t.Errorf("Expected loader to be called again after error ttl. Got %d", calls)
+		t.Fail()
+	}
+}
+
+func TestFetchNotFound(t *testing.T) {
+	c := New()
+
+	if _, err := c.Fetch(context.Background(), "testKey"); err != ErrNotFound {
+		t.Error("Expected", ErrNotFound, "got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+)
+
+// Lock acquires a lock on the given key by storing a token with it unless a
+// value is stored with the key already, and reports whether it succeeded.
+// The lock is released automatically once ttl elapsed unless it is released
+// before with Unlock. Tokens are taken from the entry versions of the cache,
+// so a later lock on a key always gets a greater token than an earlier one
+// and tokens can be used to fence writes of holders whose lock expired.
+func (c *Cache) Lock(key string, ttl time.Duration) (token string, ok bool) {
+	defer func() {
+		if ok {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if e, ok := s.Entries[key]; ok && !e.expired() {
+		return "", false
+	}
+
+	token = strconv.FormatUint(c.versions.Add(1), 10)
+	if !c.writeThrough(key, token, ttl) {
+		return "", false
+	}
+	c.set(s, key, token, ttl)
+
+	return token, true
+}
+
+// Unlock releases a lock acquired with Lock if it is still held with the
+// given token and reports whether it was released. Locks that expired and
+// were acquired again by another caller are not released.
+func (c *Cache) Unlock(key string, token string) (ok bool) {
+	defer func() {
+		if ok {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() || e.value != token {
+		return false
+	}
+
+	if !c.removeThrough(key) {
+		return false
+	}
+
+	e.stop()
+	delete(s.Entries, key)
+	c.logRemove(key)
+	c.publish(EventRemove, key, e.value)
+	c.removed(key, e.value, Removed)
+	s.stats.countRemove()
+	recycle(e)
+
+	return true
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	token, ok := c.Lock(key, time.Hour)
+	if !ok || token == "" {
+		t.Error("Lock should have been acquired.")
+		t.Fail()
+	}
+
+	if _, ok := c.Lock(key, time.Hour); ok {
+		t.Error("Lock should be held.")
+		t.Fail()
+	}
+
+	if c.Unlock(key, "otherToken") {
+		t.Error("Lock should not be released with another token.")
+		t.Fail()
+	}
+
+	if !c.Unlock(key, token) {
+		t.Error("Lock should have been released.")
+		t.Fail()
+	}
+
+	if c.Unlock(key, token) {
+		t.Error("Lock should not be released twice.")
+		t.Fail()
+	}
+}
+
+func TestLockExpiry(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	first, _ := c.Lock(key, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	second, ok := c.Lock(key, time.Hour)
+	if !ok {
+		t.Error("Lock should have been acquired after expiry.")
+		t.FailNow()
+	}
+
+	if c.Unlock(key, first) {
+		t.Error("Expired lock should not release the new one.")
+		t.Fail()
+	}
+
+	a, _ := strconv.ParseUint(first, 10, 64)
+	b, _ := strconv.ParseUint(second, 10, 64)
+	if b <= a {
+		t.Errorf("Expected increasing fencing tokens. Got %s and %s", first, second)
+		t.Fail()
+	}
+}
+
+func TestLockParallel(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	var wg sync.WaitGroup
+	var held, acquired int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				token, ok := c.Lock(key, time.Hour)
+				if !ok {
+					continue
+				}
+				if atomic.AddInt32(&held, 1) != 1 {
+					t.Error("Lock held concurrently.")
+				}
+				atomic.AddInt32(&acquired, 1)
+				atomic.AddInt32(&held, -1)
+				c.Unlock(key, token)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if acquired == 0 {
+		t.Error("Lock should have been acquired.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// evictionWarningInterval is the minimum time between two warnings about
+// evictions.
+const evictionWarningInterval = time.Minute
+
+// logf logs a message with the logger set with WithLogger, if there is one.
+func (c *Cache) logf(level slog.Level, msg string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+// warnEviction counts an eviction and warns about the evictions since the
+// last warning once evictionWarningInterval has passed. It is called with the
+// shard of the evicted entry locked.
+func (c *Cache) warnEviction() {
+	if c.logger == nil {
+		return
+	}
+
+	n := c.evictions.Add(1)
+
+	now := time.Now().UnixNano()
+	last := c.evictionWarned.Load()
+	if now-last < int64(evictionWarningInterval) || !c.evictionWarned.CompareAndSwap(last, now) {
+		return
+	}
+
+	c.evictions.Add(-n)
+	c.logf(slog.LevelWarn, "cache full, evicting entries", "evicted", n, "max_entries", c.maxEntries)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	buf bytes.Buffer
+	sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestWithLogger(t *testing.T) {
+	var out syncBuffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+
+	path := filepath.Join(t.TempDir(), "snapshot")
+
+	c := New(WithLogger(logger), WithMaxEntries(DefaultShards))
+	for i := 0; i < 10*DefaultShards; i++ {
+		c.Set("testKey"+strconv.Itoa(i), "testValue")
+	}
+	c.handleError(errors.New("testError"))
+
+	if err := c.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromFile(path, WithLogger(logger)); err != nil {
+		t.Fatal(err)
+	}
+
+	logged := out.String()
+	for _, msg := range []string{
+		`level=WARN msg="cache full, evicting entries" evicted=1`,
+		`level=ERROR msg="cache error" error=testError`,
+		`level=INFO msg="snapshot saved"`,
+		`level=INFO msg="snapshot loaded"`,
+	} {
+		if !strings.Contains(logged, msg) {
+			t.Errorf("Expected %s to be logged. Got %s", msg, logged)
+			t.Fail()
+		}
+	}
+
+	if n := strings.Count(logged, "evicting entries"); n != 1 {
+		t.Errorf("Expected a single eviction warning. Got %d", n)
+		t.Fail()
+	}
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Sizer returns the size of a value in bytes, e.g. len(v.([]byte)) for a
+// cache holding byte slices.
+type Sizer func(value interface{}) int64
+
+// entryOverhead estimates the memory used for an entry in addition to its key
+// and value: the entry itself, the key string header, the pointer to the
+// entry and the unused slots of the map.
+const entryOverhead = int64(unsafe.Sizeof(entry{})) + 2*int64(unsafe.Sizeof("")+unsafe.Sizeof(&entry{}))
+
+// maxSizeDepth limits how deep reflection follows pointers and containers
+// when estimating the size of a value.
+const maxSizeDepth = 8
+
+// MemoryUsage estimates the memory used by the entries of the cache from the
+// lengths of their keys, the sizes of their values and the overhead of the
+// entries and maps. Values are measured with the Sizer set with WithSizer or
+// otherwise estimated with reflection, following pointers, slices, maps and
+// structs but counting memory shared between values once per value. approx is
+// true if reflection was used. All entries are visited, so the cost grows
+// with the size of the cache.
+func (c *Cache) MemoryUsage() (bytes int64, approx bool) {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.RLock()
+		for k, e := range s.Entries {
+			bytes += entryOverhead + int64(len(k))
+			if c.sizer != nil {
+				bytes += c.sizer(e.value)
+			} else {
+				bytes += sizeOf(reflect.ValueOf(e.value), 0)
+			}
+		}
+		s.RUnlock()
+	}
+
+	return bytes, c.sizer == nil
+}
+
+// sizeOf estimates the size of v including the memory it references.
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+
+	size := int64(v.Type().Size())
+	return size + referencedSize(v, depth)
+}
+
+// referencedSize estimates the size of the memory referenced by v.
+func referencedSize(v reflect.Value, depth int) int64 {
+	if depth >= maxSizeDepth {
+		return 0
+	}
+
+	var size int64
+	switch v.Kind() {
+	case reflect.String:
+		size = int64(v.Len())
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			size = sizeOf(v.Elem(), depth+1)
+		}
+	case reflect.Slice:
+		elem := int64(v.Type().Elem().Size())
+		size = int64(v.Cap()) * elem
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			size += sizeOf(iter.Key(), depth+1) + sizeOf(iter.Value(), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), depth+1)
+		}
+	}
+	return size
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	key := "testKey"
+
+	c := New(WithSizer(func(v interface{}) int64 {
+		return int64(len(v.([]byte)))
+	}))
+
+	for i := 0; i < 10; i++ {
+		c.Set(key+strconv.Itoa(i), make([]byte, 1000))
+	}
+
+	bytes, approx := c.MemoryUsage()
+	if approx {
+		t.Error("Usage should not be approximated with a sizer.")
+		t.Fail()
+	}
+
+	expected := 10 * (1000 + int64(len(key)+1) + entryOverhead)
+	if bytes != expected {
+		t.Errorf("Expected %d bytes. Got %d", expected, bytes)
+		t.Fail()
+	}
+}
+
+func TestMemoryUsageReflection(t *testing.T) {
+	c := New()
+	c.Set("testKey", make([]byte, 1000))
+
+	bytes, approx := c.MemoryUsage()
+	if !approx {
+		t.Error("Usage should be approximated without sizer.")
+		t.Fail()
+	}
+
+	if bytes < 1000 || bytes > 1200 {
+		t.Errorf("Expected about 1000 bytes. Got %d", bytes)
+		t.Fail()
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	type testStruct struct {
+		Name  string
+		Tags  []string
+		Attrs map[string]int
+		Next  *testStruct
+	}
+
+	v := &testStruct{
+		Name:  "testValue",
+		Tags:  []string{"a", "bc"},
+		Attrs: map[string]int{"key": 1},
+		Next:  &testStruct{Name: "next"},
+	}
+
+	structSize := int64(reflect.TypeOf(testStruct{}).Size())
+	expected := 8 + // pointer
+		structSize + 9 + // Name
+		2*16 + 3 + // Tags
+		16 + 3 + 8 + // Attrs
+		structSize + 4 // Next
+
+	if size := sizeOf(reflect.ValueOf(v), 0); size != expected {
+		t.Errorf("Expected %d bytes. Got %d", expected, size)
+		t.Fail()
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import "errors"
+
+// errUnchanged is returned by the function passed to modify to leave the
+// entry as it is, without storing, logging or publishing it again.
+var errUnchanged = errors.New("cache: value unchanged")
+
+// modify atomically replaces the value stored with the given key by the
+// result of f, which is called with the shard locked and gets the current
+// value and whether there is one. If f returns nil the entry is deleted,
+// if it returns an error the entry is left unchanged. errUnchanged is not
+// returned to the caller. New entries are stored with the default ttl of the
+// cache, existing ones keep their ttl.
+//
+// f must not modify the current value in place but return a modified copy,
+// values returned by Get or being snapshotted are read without a lock. This
+// copy-on-write contract holds for all collection values built on modify,
+// so their mutations cost O(n) in the size of the value and n inserts O(n²).
+func (c *Cache) modify(key string, f func(value interface{}, ok bool) (interface{}, error)) (err error) {
+	unchanged := false
+	defer func() {
+		if err == nil && !unchanged {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	ok = ok && !e.expired()
+
+	var current interface{}
+	if ok {
+		current = e.value
+	}
+
+	value, err := f(current, ok)
+	if err == errUnchanged {
+		unchanged = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case value == nil && !ok:
+		return nil
+	case value == nil:
+		if err := c.storeRemove(key); err != nil {
+			return err
+		}
+		e.stop()
+		delete(s.Entries, key)
+		c.logRemove(key)
+		c.publish(EventRemove, key, e.value)
+		c.removed(key, e.value, Removed)
+		s.stats.countRemove()
+		recycle(e)
+		return nil
+	case !ok:
+		if err := c.storeSet(key, value, c.defaultTTL); err != nil {
+			return err
+		}
+		c.set(s, key, value, c.defaultTTL)
+		return nil
+	}
+
+	return c.replace(s, key, e, value)
+}
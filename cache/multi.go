@@ -0,0 +1,129 @@
+package cache
+
+import "time"
+
+// groupByShard returns the indexes of keys grouped by the index of their
+// shard.
+func (c *Cache) groupByShard(keys []string) map[int][]int {
+	groups := make(map[int][]int)
+	for i, key := range keys {
+		n := c.shardIndex(key)
+		groups[n] = append(groups[n], i)
+	}
+	return groups
+}
+
+// GetMulti retrieves the values stored with the given keys like Get, locking
+// each shard once for all of its keys. Missing keys are not included in the
+// result and are not loaded with the Loader of the cache.
+func (c *Cache) GetMulti(keys []string) map[string]interface{} {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	values := make(map[string]interface{}, len(keys))
+
+	for n, idx := range c.groupByShard(keys) {
+		s := c.shard(n)
+		if s.hot != nil {
+			for _, i := range idx {
+				s.hot.add(keys[i])
+			}
+		}
+
+		s.RLock()
+		for _, i := range idx {
+			key := keys[i]
+			e, ok := s.Entries[key]
+			if !ok || e.expired() {
+				s.stats.countMiss()
+				continue
+			}
+
+			c.refreshIfDue(key, e)
+			e.renew()
+			s.stats.countHit()
+			values[key] = e.value
+		}
+		s.RUnlock()
+	}
+
+	return values
+}
+
+// SetMulti stores all given values with their keys like Set, locking each
+// shard once for all of its keys.
+func (c *Cache) SetMulti(entries map[string]interface{}) {
+	c.SetMultiWithTTL(entries, c.defaultTTL)
+}
+
+// SetMultiWithTTL stores all given values with their keys like SetWithTTL,
+// locking each shard once for all of its keys.
+func (c *Cache) SetMultiWithTTL(entries map[string]interface{}, ttl time.Duration) {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	groups := make(map[int][]string)
+	for key := range entries {
+		n := c.shardIndex(key)
+		groups[n] = append(groups[n], key)
+	}
+
+	for n, keys := range groups {
+		s := c.shard(n)
+		s.Lock()
+		for _, key := range keys {
+			if c.writeThrough(key, entries[key], ttl) {
+				c.set(s, key, entries[key], ttl)
+			}
+		}
+		s.Unlock()
+
+		for _, key := range keys {
+			c.invalidateOthers(key)
+		}
+	}
+}
+
+// RemoveMulti deletes the entries stored with the given keys like Remove,
+// locking each shard once for all of its keys, and returns how many existed.
+// Expired entries kept for their stale grace period are removed but not
+// counted.
+func (c *Cache) RemoveMulti(keys []string) int {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	n := 0
+	for i, idx := range c.groupByShard(keys) {
+		s := c.shard(i)
+		s.Lock()
+		for _, j := range idx {
+			key := keys[j]
+			if !c.removeThrough(key) {
+				continue
+			}
+
+			e, ok := s.Entries[key]
+			if !ok {
+				continue
+			}
+
+			if !e.expired() {
+				n++
+			}
+			e.stop()
+			delete(s.Entries, key)
+			c.logRemove(key)
+			c.publish(EventRemove, key, e.value)
+			c.removed(key, e.value, Removed)
+			s.stats.countRemove()
+			recycle(e)
+		}
+		s.Unlock()
+
+		for _, j := range idx {
+			c.invalidateOthers(keys[j])
+		}
+	}
+
+	return n
+}
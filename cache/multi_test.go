@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetMulti(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	keys := []string{"missing"}
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value+strconv.Itoa(i))
+		keys = append(keys, key+strconv.Itoa(i))
+	}
+
+	values := c.GetMulti(keys)
+	if len(values) != 100 {
+		t.Errorf("Expected 100 values. Got %d", len(values))
+		t.Fail()
+	}
+
+	for i := 0; i < 100; i++ {
+		if v := values[key+strconv.Itoa(i)]; v != value+strconv.Itoa(i) {
+			t.Error("Expected", value+strconv.Itoa(i), "got", v)
+			t.Fail()
+		}
+	}
+
+	if s := c.GetStats(); s.Hits != 100 || s.Misses != 1 {
+		t.Errorf("Expected 100 hits and 1 miss. Got %d and %d", s.Hits, s.Misses)
+		t.Fail()
+	}
+}
+
+func TestSetMulti(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	entries := make(map[string]interface{})
+	for i := 0; i < 100; i++ {
+		entries[key+strconv.Itoa(i)] = value + strconv.Itoa(i)
+	}
+	c.SetMulti(entries)
+
+	for i := 0; i < 100; i++ {
+		if v, _ := c.Get(key + strconv.Itoa(i)); v != value+strconv.Itoa(i) {
+			t.Error("Expected", value+strconv.Itoa(i), "got", v)
+			t.Fail()
+		}
+	}
+
+	if s := c.GetStats(); s.Set != 100 {
+		t.Errorf("Expected 100 sets. Got %d", s.Set)
+		t.Fail()
+	}
+}
+
+func TestSetMultiWithTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.SetMultiWithTTL(map[string]interface{}{key: value}, 10*time.Millisecond)
+
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if c.Has(key) {
+		t.Error("Entry should have expired.")
+		t.Fail()
+	}
+}
+
+func TestRemoveMulti(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	keys := []string{"missing"}
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+		keys = append(keys, key+strconv.Itoa(i))
+	}
+	c.Set("other", value)
+
+	if n := c.RemoveMulti(keys); n != 100 {
+		t.Errorf("Expected 100 removed entries. Got %d", n)
+		t.Fail()
+	}
+
+	if keys := c.Keys(); len(keys) != 1 || keys[0] != "other" {
+		t.Error("Expected only other to be left, got", keys)
+		t.Fail()
+	}
+
+	if s := c.GetStats(); s.Removed != 100 {
+		t.Errorf("Expected 100 removals. Got %d", s.Removed)
+		t.Fail()
+	}
+}
+
+func TestRemoveMultiExpired(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithStaleGrace(time.Minute))
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if n := c.RemoveMulti([]string{key}); n != 0 {
+		t.Errorf("Expected expired entries not to be counted. Got %d", n)
+		t.Fail()
+	}
+}
+
+func BenchmarkGetMulti(b *testing.B) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	keys := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+		keys = append(keys, key+strconv.Itoa(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.GetMulti(keys)
+	}
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+// NamespaceSeparator separates the name of a Namespace from the keys of its
+// entries.
+const NamespaceSeparator = ":"
+
+// Namespace is a view of a Cache storing entries with keys prefixed by the
+// name of the namespace, so components sharing a cache cannot overwrite each
+// others entries. It counts the accesses through it separately from the
+// cache.
+type Namespace struct {
+	cache  *Cache
+	name   string
+	prefix string
+	stats  counters
+	// quota is nil if the namespace has no quota
+	quota *quota
+}
+
+var _ Layer = (*Namespace)(nil)
+
+// Namespace returns a view of the cache storing entries with keys prefixed
+// by name and NamespaceSeparator. Views of the same name share their entries
+// but not their stats.
+func (c *Cache) Namespace(name string) *Namespace {
+	return &Namespace{
+		cache:  c,
+		name:   name,
+		prefix: name + NamespaceSeparator,
+		quota:  c.quotas[name],
+	}
+}
+
+// Name returns the name of the namespace.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// Get retrieves the value stored with the given key in the namespace like
+// Cache.Get.
+func (n *Namespace) Get(key string) (interface{}, bool) {
+	v, ok := n.cache.Get(n.prefix + key)
+	if ok {
+		n.stats.countHit()
+	} else {
+		n.stats.countMiss()
+	}
+	return v, ok
+}
+
+// Has reports whether a value is stored with the given key in the namespace
+// like Cache.Has.
+func (n *Namespace) Has(key string) bool {
+	return n.cache.Has(n.prefix + key)
+}
+
+// Set stores the value with the given key in the namespace like Cache.Set.
+func (n *Namespace) Set(key string, value interface{}) {
+	n.cache.Set(n.prefix+key, value)
+	n.stats.countSet()
+	n.enforce()
+}
+
+// SetWithTTL stores the value with the given key in the namespace like
+// Cache.SetWithTTL.
+func (n *Namespace) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	n.cache.SetWithTTL(n.prefix+key, value, ttl)
+	n.stats.countSet()
+	n.enforce()
+}
+
+// enforce evicts entries exceeding the quota of the namespace.
+func (n *Namespace) enforce() {
+	if n.quota != nil {
+		n.cache.enforce(n.quota)
+	}
+}
+
+// Remove deletes the value stored with the given key in the namespace like
+// Cache.Remove.
+func (n *Namespace) Remove(key string) {
+	if n.cache.remove(n.prefix + key) {
+		n.stats.countRemove()
+	}
+}
+
+// Keys returns the keys of all entries in the namespace without the prefix
+// of the namespace like Cache.Keys.
+func (n *Namespace) Keys() []string {
+	keys := n.cache.KeysWithPrefix(n.prefix)
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, n.prefix)
+	}
+	return keys
+}
+
+// ClearNamespace deletes all entries in the namespace like
+// Cache.RemovePrefix and returns how many were deleted. Entries of other
+// namespaces and without namespace are kept.
+func (n *Namespace) ClearNamespace() int {
+	removed := n.cache.RemovePrefix(n.prefix)
+	n.stats.removed.Add(int64(removed))
+	return removed
+}
+
+// GetStats returns the accesses through the namespace view and the number of
+// entries in the namespace, which are tracked for namespaces with a quota and
+// counted otherwise. Expirations and evictions are only counted for the whole
+// cache.
+func (n *Namespace) GetStats() *Stats {
+	s := Stats{
+		Hits:    int(n.stats.hits.Load()),
+		Misses:  int(n.stats.misses.Load()),
+		Set:     int(n.stats.set.Load()),
+		Removed: int(n.stats.removed.Load()),
+		Uptime:  n.cache.created,
+	}
+	s.SetHitRatio()
+
+	if n.quota != nil {
+		s.Entries = n.quota.len()
+	} else {
+		s.Entries = len(n.cache.KeysWithPrefix(n.prefix))
+	}
+
+	return &s
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	users := c.Namespace("users")
+	sessions := c.Namespace("sessions")
+
+	users.Set(key, value)
+	sessions.Set(key, "otherValue")
+	c.Set(key, value)
+
+	if v, _ := users.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if v, _ := sessions.Get(key); v != "otherValue" {
+		t.Error("Expected otherValue got", v)
+		t.Fail()
+	}
+
+	if !c.Has("users:" + key) {
+		t.Error("Entry should be stored with the prefixed key.")
+		t.Fail()
+	}
+
+	users.Set("otherKey", value)
+	keys := users.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "otherKey" || keys[1] != key {
+		t.Error("Expected [otherKey testKey] got", keys)
+		t.Fail()
+	}
+
+	if n := users.ClearNamespace(); n != 2 {
+		t.Errorf("Expected 2 removed entries. Got %d", n)
+		t.Fail()
+	}
+
+	if users.Has(key) || !sessions.Has(key) || !c.Has(key) {
+		t.Error("Only entries of the namespace should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestNamespaceStats(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	users := c.Namespace("users")
+
+	users.Set(key, value)
+	users.Get(key)
+	users.Get("missing")
+	c.Set(key, value)
+	c.Get(key)
+	users.Remove(key)
+	users.Remove(key)
+
+	s := users.GetStats()
+	if s.Hits != 1 || s.Misses != 1 || s.Set != 1 || s.Removed != 1 || s.Entries != 0 {
+		t.Error("Unexpected namespace stats", s)
+		t.Fail()
+	}
+
+	if s := c.GetStats(); s.Hits != 2 || s.Entries != 1 {
+		t.Error("Unexpected cache stats", s)
+		t.Fail()
+	}
+}
@@ -0,0 +1,335 @@
+package cache
+
+import (
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// Option configures a Cache on creation.
+type Option func(*Cache)
+
+// WithDefaultTTL makes Set remove values automatically after ttl. Values
+// stored with SetWithTTL or SetWithSlidingTTL keep their own ttl.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithTTLJitter randomizes the ttl of every entry by up to ±fraction of its
+// length, e.g. 0.1 for ±10%, so entries stored at the same time do not all
+// expire at once. The fraction is capped at 1.
+func WithTTLJitter(fraction float64) Option {
+	return func(c *Cache) {
+		if fraction > 1 {
+			fraction = 1
+		}
+		c.ttlJitter = fraction
+	}
+}
+
+// WithStaleGrace keeps entries for the grace period after their ttl elapsed,
+// so they can still be served by GetStale while being refreshed.
+func WithStaleGrace(grace time.Duration) Option {
+	return func(c *Cache) {
+		c.staleGrace = grace
+	}
+}
+
+// WithRefresh sets the function used to refresh entries in the background.
+func WithRefresh(refresh RefreshFunc) Option {
+	return func(c *Cache) {
+		c.refresh = refresh
+	}
+}
+
+// WithRefreshAhead makes Get refresh entries in the background when they are
+// accessed within threshold, a fraction of their ttl, before they expire. With
+// a threshold of 0.2 an entry with a ttl of one minute gets refreshed when it
+// is accessed during the last 12 seconds of its lifetime. The refresh function
+// is set with WithRefresh.
+func WithRefreshAhead(threshold float64) Option {
+	return func(c *Cache) {
+		c.refreshAhead = threshold
+	}
+}
+
+// WithLoader turns the cache into a read-through cache. Misses of Get and
+// Fetch invoke the loader and store its result.
+func WithLoader(loader Loader) Option {
+	return func(c *Cache) {
+		c.loader = loader
+	}
+}
+
+// WithLoaderErrorPolicy sets how Loader errors are handled. With CacheErrors
+// errors are remembered for ttl. The default is PropagateErrors.
+func WithLoaderErrorPolicy(policy ErrorPolicy, ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.failures = nil
+		if policy == CacheErrors {
+			c.failures = New(WithDefaultTTL(ttl))
+		}
+	}
+}
+
+// WithStore makes the cache write Set and Remove operations synchronously
+// through to store before applying them.
+func WithStore(store Store) Option {
+	return func(c *Cache) {
+		c.store = store
+	}
+}
+
+// WithErrorHandler sets a function that is called with errors which cannot be
+// returned to the caller, e.g. failed Store operations.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *Cache) {
+		c.onError = handler
+	}
+}
+
+// WithLogger sets a logger for background activity: saved and loaded
+// snapshots, the replay of the append-only log and errors passed to the
+// error handler are logged at info and error level, expired entries at debug
+// level. While entries are evicted a warning is logged at most once a
+// minute. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Cache) {
+		c.logger = logger
+	}
+}
+
+// WithStatsDisabled turns off counting hits, misses and modifications for
+// maximum throughput. Stats only report the number of entries then.
+func WithStatsDisabled() Option {
+	return func(c *Cache) {
+		c.statsDisabled = true
+	}
+}
+
+// WithWindowedStats adds the hits and misses of the last minute, 5 minutes
+// and 15 minutes to Stats, sampled every 5 seconds in the background until the
+// cache is closed.
+func WithWindowedStats() Option {
+	return func(c *Cache) {
+		c.windows = newWindows()
+	}
+}
+
+// WithMapShrinking rebuilds the maps of shards every interval once they hold
+// less than a quarter of the entries they held at their peak, until the cache
+// is closed. Go maps never shrink, so otherwise the memory of removed entries
+// is only released by Clear.
+func WithMapShrinking(interval time.Duration) Option {
+	return func(c *Cache) {
+		if interval > 0 {
+			c.shrinker = newShrinker(interval)
+		}
+	}
+}
+
+// WithHotKeyTracking estimates the number of accesses of keys by Get with a
+// count-min sketch per shard and keeps the top keys of each shard, reported
+// by TopKeys. TopKeys can report up to top keys for every shard.
+func WithHotKeyTracking(top int) Option {
+	return func(c *Cache) {
+		c.hotKeys = top
+	}
+}
+
+// WithLatencyTracking records the latencies of Get, the Set methods, Remove
+// and Loader invocations in histograms reported in Stats.Latency.
+func WithLatencyTracking() Option {
+	return func(c *Cache) {
+		c.latency = &latencies{}
+	}
+}
+
+// WithSizer sets the function measuring values for MemoryUsage instead of
+// estimating their size with reflection.
+func WithSizer(sizer Sizer) Option {
+	return func(c *Cache) {
+		c.sizer = sizer
+	}
+}
+
+// WithWriteBehind makes writes to the Store set with WithStore asynchronous.
+// Operations are buffered, blocking callers while bufferSize operations are
+// pending, and written in batches of batchSize at least every interval. With
+// an interval of zero or less they are only written once a batch is full. Use
+// Flush to write pending operations immediately and Close to flush them before
+// shutting down.
+func WithWriteBehind(bufferSize int, interval time.Duration, batchSize int) Option {
+	return func(c *Cache) {
+		c.writer = newWriter(bufferSize, interval, batchSize)
+	}
+}
+
+// WithShards sets the number of shards of the cache, which limits the
+// number of operations modifying it concurrently. It is limited to MaxShards,
+// the default is DefaultShards.
+func WithShards(n int) Option {
+	return func(c *Cache) {
+		c.shardCount = min(max(n, 1), MaxShards)
+	}
+}
+
+// WithAutoShards sizes the shards of the cache by GOMAXPROCS, using four
+// shards per processor rounded up to a power of two, at least 8. Small
+// containers get less shards to scan in Keys and GetStats, large hosts more
+// to reduce lock contention.
+func WithAutoShards() Option {
+	return func(c *Cache) {
+		c.shardCount = autoShards(runtime.GOMAXPROCS(0))
+	}
+}
+
+// autoShards returns the number of shards for the given number of
+// processors.
+func autoShards(procs int) int {
+	n := 8
+	for n < 4*procs && n < MaxShards {
+		n *= 2
+	}
+	return n
+}
+
+// WithHasher sets the Hasher distributing keys over the shards. The default
+// is a MapHasher.
+func WithHasher(hasher Hasher) Option {
+	return func(c *Cache) {
+		c.hasher = hasher
+	}
+}
+
+// WithMaxEntries limits the number of entries in the cache. The limit is
+// applied per shard, each holding up to maxEntries divided by the number of
+// shards. Adding an entry to a full shard evicts one of its least recently
+// used entries.
+func WithMaxEntries(maxEntries int) Option {
+	return func(c *Cache) {
+		c.maxEntries = maxEntries
+	}
+}
+
+// WithCapacityHint allocates the maps of the shards for n entries in total,
+// e.g. for caches warmed up with millions of entries, which otherwise grow
+// their maps and rehash the entries repeatedly. It does not limit the number
+// of entries like WithMaxEntries.
+func WithCapacityHint(n int) Option {
+	return func(c *Cache) {
+		c.capacityHint = max(n, 0)
+	}
+}
+
+// WithOnEvict sets a function called with every entry leaving the cache and
+// the reason, e.g. to release resources held by the value. Values replaced by
+// Set are not passed. The function is called with the shard of the entry
+// locked and must not use the cache.
+func WithOnEvict(onEvict func(key string, value interface{}, reason Reason)) Option {
+	return func(c *Cache) {
+		c.onRemove = onEvict
+	}
+}
+
+// WithAutoSnapshot saves a snapshot of the cache to the file at path every
+// interval and once more on Close, only on Close if interval is zero or less.
+// Errors are passed to the error handler. Use NewFromFile to restore the
+// snapshot on startup.
+func WithAutoSnapshot(path string, interval time.Duration) Option {
+	return func(c *Cache) {
+		c.snapshots = newAutoSnapshot(path, interval)
+	}
+}
+
+// WithAppendOnlyLog appends every modification of the cache to the log file
+// at path and replays it when the cache is created. The file is synced to
+// disk every syncEvery, bounding the modifications lost on a crash, or after
+// every modification if syncEvery is zero. Values are encoded with
+// encoding/gob, so their concrete types have to be registered with
+// gob.Register. If the log cannot be replayed the error is passed to the error
+// handler and the cache continues without log.
+func WithAppendOnlyLog(path string, syncEvery time.Duration) Option {
+	return func(c *Cache) {
+		c.aof = newAppendLog(path, syncEvery)
+	}
+}
+
+// WithLogRewrite rewrites the append-only log in the background once it is
+// larger than size bytes and has doubled since it was last rewritten. The
+// rewritten log only contains the records needed to restore the current
+// entries.
+func WithLogRewrite(size int64) Option {
+	return func(c *Cache) {
+		c.aofRewriteSize = size
+	}
+}
+
+// WithEncryptionKey encrypts snapshots and the append-only log with AES-GCM
+// using key, which has to be 16, 24 or 32 bytes long. Without this option the
+// key is read from the environment variable named by EncryptionKeyEnv. If the
+// key is invalid, saving snapshots and opening the log fails instead of
+// writing unencrypted data.
+func WithEncryptionKey(key []byte) Option {
+	return func(c *Cache) {
+		c.encryption = newEncryption(key)
+	}
+}
+
+// WithSnapshotCompression sets the compression of snapshots. Snapshots are
+// decompressed transparently when they are loaded, regardless of this option.
+func WithSnapshotCompression(compression Compression) Option {
+	return func(c *Cache) {
+		c.compression = compression
+	}
+}
+
+// WithReplicationBuffer sets the number of modifications buffered for each
+// replica connected with ServeReplicas. Replicas falling further behind are
+// disconnected and synchronize again. The default is 4096.
+func WithReplicationBuffer(size int) Option {
+	return func(c *Cache) {
+		if size > 0 {
+			c.replicas.buffer = size
+		}
+	}
+}
+
+// WithEventBuffer sets the number of events buffered for each channel
+// returned by Subscribe. The default is 256.
+func WithEventBuffer(size int) Option {
+	return func(c *Cache) {
+		if size > 0 {
+			c.subscriptions.buffer = size
+		}
+	}
+}
+
+// WithKeyspaceEvents enables keyspace events received with SubscribeKeyspace.
+// The classes are given in the format of the Redis setting
+// notify-keyspace-events: K and E enable events on keyspace and keyevent
+// channels, g enables del, $ set, x expired and e evicted events, A is an
+// alias for g$xe. At least one of K or E is required, e.g. "KEA". Keyspace
+// events are disabled by default.
+func WithKeyspaceEvents(classes string) Option {
+	return func(c *Cache) {
+		c.keyspace.classes = parseKeyspaceClasses(classes)
+		if c.keyspace.classes&(classKeyspace|classKeyevent) == 0 {
+			c.keyspace.classes = 0
+		}
+	}
+}
+
+// WithInvalidationBus keeps the cache coherent with instances in other
+// processes sharing the bus. Keys modified with Set, SetWithTTL,
+// SetWithSlidingTTL, GetOrSet or removed with Remove are broadcast, and the
+// other instances remove their copies so they load or receive the current
+// value next. Values loaded, restored or replicated are not broadcast. Errors
+// are passed to the error handler.
+func WithInvalidationBus(bus InvalidationBus) Option {
+	return func(c *Cache) {
+		c.bus = bus
+	}
+}
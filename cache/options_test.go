@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWithDefaultTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithDefaultTTL(10 * time.Millisecond))
+	if ttl := c.DefaultTTL(); ttl != 10*time.Millisecond {
+		t.Error("Expected a default ttl of 10ms. Got", ttl)
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	c.SetWithTTL(key+"1", value, 50*time.Millisecond)
+	c.SetWithTTL(key+"2", value, NoExpiration)
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Could not find test element in cache.")
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key + "1"); !ok {
+		t.Error("Element with own ttl should not have been removed.")
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key + "2"); !ok {
+		t.Error("Element without expiry should not have been removed.")
+		t.Fail()
+	}
+}
+
+func TestWithTTLJitter(t *testing.T) {
+	ttl := 100 * time.Millisecond
+
+	c := New(WithTTLJitter(0.5))
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		d := c.jitter(ttl)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Errorf("Expected ttl between 50ms and 150ms. Got %s", d)
+			t.Fail()
+		}
+		seen[d] = true
+	}
+
+	if len(seen) < 2 {
+		t.Error("Expected ttls to be randomized.")
+		t.Fail()
+	}
+
+	c = New()
+
+	if d := c.jitter(ttl); d != ttl {
+		t.Errorf("Expected ttl of %s without jitter. Got %s", ttl, d)
+		t.Fail()
+	}
+}
+
+func TestWithShards(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithShards(4))
+	if n := len(c.GetShardStats()); n != 4 {
+		t.Errorf("Expected 4 shards. Got %d", n)
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if n := New(WithShards(0)).len(); n != 1 {
+		t.Errorf("Expected at least 1 shard. Got %d", n)
+		t.Fail()
+	}
+
+	if n := New(WithShards(1 << 20)).len(); n != MaxShards {
+		t.Errorf("Expected %d shards. Got %d", MaxShards, n)
+		t.Fail()
+	}
+}
+
+func TestWithAutoShards(t *testing.T) {
+	for procs, expected := range map[int]int{1: 8, 2: 8, 3: 16, 16: 64, 96: 512} {
+		if n := autoShards(procs); n != expected {
+			t.Errorf("Expected %d shards for %d processors. Got %d", expected, procs, n)
+			t.Fail()
+		}
+	}
+
+	if n := New(WithAutoShards()).len(); n < 8 {
+		t.Errorf("Expected at least 8 shards. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestWithCapacityHint(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithShards(4), WithCapacityHint(4000))
+	for i := 0; i < c.len(); i++ {
+		if h := c.shard(i).hint; h != 1000 {
+			t.Errorf("Expected maps allocated for 1000 entries. Got %d", h)
+			t.Fail()
+		}
+	}
+
+	fill := func(opts ...Option) func() {
+		return func() {
+			c := New(opts...)
+			for i := 0; i < 4000; i++ {
+				c.Set(key+strconv.Itoa(i), value)
+			}
+		}
+	}
+
+	hinted := testing.AllocsPerRun(1, fill(WithShards(4), WithCapacityHint(4000)))
+	if allocs := testing.AllocsPerRun(1, fill(WithShards(4))); hinted >= allocs {
+		t.Errorf("Expected less allocations with a capacity hint. Got %f and %f", hinted, allocs)
+		t.Fail()
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import "sync"
+
+// entryPool holds removed entries for reuse, so workloads storing and
+// removing many keys allocate less.
+var entryPool = sync.Pool{
+	New: func() interface{} { return new(entry) },
+}
+
+// newEntry returns an empty entry from the pool.
+func newEntry() *entry {
+	return entryPool.Get().(*entry)
+}
+
+// recycle resets a removed entry and returns it to the pool, unless it may
+// still be used outside the shard lock by a background refresh or the holder
+// of a semaphore. The entry has to be stopped and deleted from its shard,
+// which has to be locked for writing, and must not be used afterwards.
+func recycle(e *entry) {
+	if e.shared || e.refreshing.Load() {
+		return
+	}
+
+	e.value = nil
+	e.version = 0
+	e.ttl = 0
+	e.sliding = false
+	e.expires.Store(0)
+	e.exit = nil
+	e.accessed.Store(0)
+
+	entryPool.Put(e)
+}
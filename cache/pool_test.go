@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecycle(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.SetWithTTL(key, value, time.Hour)
+	c.Remove(key)
+
+	// a recycled entry must not keep the value or expiry of the removed one
+	c.Set(key, value+"New")
+	if ttl, ok := c.TTL(key); !ok || ttl != NoExpiration {
+		t.Error("Expected no expiry. Got", ttl)
+		t.Fail()
+	}
+	if v, _ := c.Get(key); v != value+"New" {
+		t.Error("Expected", value+"New", "got", v)
+		t.Fail()
+	}
+
+	e := &entry{value: value, shared: true}
+	recycle(e)
+	if e.value != value {
+		t.Error("Shared entries should not be recycled.")
+		t.Fail()
+	}
+}
+
+func TestRecycleAllocs(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c.Set(key, value)
+		c.Remove(key)
+	})
+	// the pool may drop entries, e.g. with the race detector enabled
+	if allocs >= 1 {
+		t.Errorf("Expected entries to be reused. Got %f allocations", allocs)
+		t.Fail()
+	}
+}
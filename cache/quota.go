@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Quota limits the entries of a Namespace set with WithNamespaceQuota.
+type Quota struct {
+	// MaxEntries limits the number of entries, zero does not limit them.
+	MaxEntries int
+	// MaxCost limits the summed sizes of the values, measured with the Sizer
+	// set with WithSizer or estimated with reflection like MemoryUsage. Zero
+	// does not limit the cost.
+	MaxCost int64
+}
+
+// quota tracks the entries of a namespace with a Quota.
+type quota struct {
+	Quota
+	sync.Mutex
+	// costs holds the cost of every entry of the namespace
+	costs map[string]int64
+	cost  int64
+}
+
+// quotas holds the quotas of the cache by namespace, nil if there are none.
+type quotas map[string]*quota
+
+// WithNamespaceQuota limits the entries of the namespace with the given
+// name. Entries exceeding the quota are evicted from the namespace after
+// writes through its Namespace view, least recently used first, so other
+// namespaces keep their entries. Entries are tracked from the start, also
+// those stored with prefixed keys on the cache directly.
+func WithNamespaceQuota(name string, q Quota) Option {
+	return func(c *Cache) {
+		if c.quotas == nil {
+			c.quotas = make(quotas)
+		}
+		c.quotas[name] = &quota{Quota: q, costs: make(map[string]int64)}
+	}
+}
+
+// lookup returns the quota of the namespace of key, nil if there is none.
+func (qs quotas) lookup(key string) *quota {
+	if qs == nil {
+		return nil
+	}
+
+	i := strings.Index(key, NamespaceSeparator)
+	if i < 0 {
+		return nil
+	}
+
+	return qs[key[:i]]
+}
+
+// record tracks a change of an entry in the quota of its namespace. It is
+// called with the shard of the entry locked.
+func (c *Cache) record(typ EventType, key string, value interface{}) {
+	q := c.quotas.lookup(key)
+	if q == nil {
+		return
+	}
+
+	var cost int64
+	if typ == EventSet && q.MaxCost > 0 {
+		if c.sizer != nil {
+			cost = c.sizer(value)
+		} else {
+			cost = sizeOf(reflect.ValueOf(value), 0)
+		}
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	q.cost -= q.costs[key]
+	if typ == EventSet {
+		q.costs[key] = cost
+		q.cost += cost
+	} else {
+		delete(q.costs, key)
+	}
+}
+
+// exceeded reports whether the entries of the namespace exceed the quota.
+// q has to be locked.
+func (q *quota) exceeded() bool {
+	return q.MaxEntries > 0 && len(q.costs) > q.MaxEntries ||
+		q.MaxCost > 0 && q.cost > q.MaxCost
+}
+
+// len returns the number of entries in the namespace.
+func (q *quota) len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.costs)
+}
+
+// enforce evicts the least recently used of a few sampled entries of the
+// namespace until its entries fit the quota.
+func (c *Cache) enforce(q *quota) {
+	for {
+		q.Lock()
+		if !q.exceeded() {
+			q.Unlock()
+			return
+		}
+
+		sample := make([]string, 0, evictionSamples)
+		for k := range q.costs {
+			sample = append(sample, k)
+			if len(sample) == evictionSamples {
+				break
+			}
+		}
+		q.Unlock()
+
+		var oldestKey string
+		oldest := int64(-1)
+		for _, k := range sample {
+			s := c.rlockShard(k)
+			if e, ok := s.Entries[k]; ok && (oldest < 0 || e.accessed.Load() < oldest) {
+				oldest, oldestKey = e.accessed.Load(), k
+			}
+			s.RUnlock()
+		}
+
+		if oldest >= 0 && c.evictKey(oldestKey) {
+			continue
+		}
+
+		// the sampled entries are gone already, stop tracking them
+		var gone []string
+		for _, k := range sample {
+			if !c.Has(k) {
+				gone = append(gone, k)
+			}
+		}
+
+		q.Lock()
+		for _, k := range gone {
+			q.cost -= q.costs[k]
+			delete(q.costs, k)
+		}
+		q.Unlock()
+	}
+}
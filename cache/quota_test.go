@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNamespaceQuota(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithNamespaceQuota("users", Quota{MaxEntries: 10}))
+	users := c.Namespace("users")
+	sessions := c.Namespace("sessions")
+
+	for i := 0; i < 100; i++ {
+		sessions.Set(key+strconv.Itoa(i), value)
+	}
+
+	for i := 0; i < 100; i++ {
+		users.Set(key+strconv.Itoa(i), value)
+	}
+
+	if n := len(users.Keys()); n != 10 {
+		t.Errorf("Expected 10 entries in the namespace. Got %d", n)
+		t.Fail()
+	}
+
+	if n := users.GetStats().Entries; n != 10 {
+		t.Errorf("Expected 10 tracked entries. Got %d", n)
+		t.Fail()
+	}
+
+	if n := len(sessions.Keys()); n != 100 {
+		t.Errorf("Expected other namespaces to keep their entries. Got %d", n)
+		t.Fail()
+	}
+
+	if s := c.GetStats(); s.Evicted != 90 {
+		t.Errorf("Expected 90 evictions. Got %d", s.Evicted)
+		t.Fail()
+	}
+
+	users.Remove(key + "99")
+	if n := users.GetStats().Entries; n != 9 {
+		t.Errorf("Expected 9 tracked entries. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestNamespaceQuotaCost(t *testing.T) {
+	key := "testKey"
+
+	sizer := func(v interface{}) int64 { return int64(len(v.([]byte))) }
+	c := New(WithSizer(sizer), WithNamespaceQuota("blobs", Quota{MaxCost: 1000}))
+	blobs := c.Namespace("blobs")
+
+	for i := 0; i < 10; i++ {
+		blobs.Set(key+strconv.Itoa(i), make([]byte, 300))
+	}
+
+	if n := len(blobs.Keys()); n != 3 {
+		t.Errorf("Expected 3 entries within the cost limit. Got %d", n)
+		t.Fail()
+	}
+
+	if !blobs.Has(key + "9") {
+		t.Error("Most recent entry should have been kept.")
+		t.Fail()
+	}
+}
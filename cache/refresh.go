@@ -0,0 +1,86 @@
+package cache
+
+import "time"
+
+// RefreshFunc returns a fresh value for the given key. It is called in the
+// background with the value currently stored. In case of an error the current
+// value is kept.
+type RefreshFunc func(key string, value interface{}) (interface{}, error)
+
+// GetStale retrieves a value stored with a specific key like Get, but also
+// returns values whose ttl has already elapsed as long as they are within the
+// stale grace period of the cache. For those stale is true and a refresh of
+// the entry is triggered in the background. If no value is available nil,
+// false and false will be returned.
+func (c *Cache) GetStale(key string) (value interface{}, stale bool, ok bool) {
+	s := c.rlockShard(key)
+	defer s.RUnlock()
+
+	e, ok := s.Entries[key]
+	if !ok {
+		s.stats.countMiss()
+		return nil, false, false
+	}
+
+	s.stats.countHit()
+
+	if e.expired() {
+		c.revalidate(key, e)
+		return e.value, true, true
+	}
+
+	c.refreshIfDue(key, e)
+	e.renew()
+
+	return e.value, false, true
+}
+
+// refreshIfDue triggers a background refresh of the entry if it is accessed
+// within the refresh ahead threshold before its expiry. The shard has to be
+// locked at least for reading.
+func (c *Cache) refreshIfDue(key string, e *entry) {
+	if c.refreshAhead <= 0 || e.ttl <= 0 {
+		return
+	}
+
+	if e.remaining() <= time.Duration(c.refreshAhead*float64(e.ttl)) {
+		c.revalidate(key, e)
+	}
+}
+
+// revalidate refreshes the entry in the background using the refresh function
+// of the cache. Only one refresh per entry is running at a time. The shard has
+// to be locked at least for reading.
+func (c *Cache) revalidate(key string, e *entry) {
+	if c.refresh == nil || !e.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go c.refreshEntry(key, e, e.value, e.exit)
+}
+
+// refreshEntry calls the refresh function and stores the new value with the
+// ttl of the entry, unless the entry has been modified in the meantime.
+func (c *Cache) refreshEntry(key string, e *entry, value interface{}, exit chan struct{}) {
+	value, err := c.refresh(key, value)
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e.refreshing.Store(false)
+
+	// the entry got removed or replaced while refreshing
+	if err != nil || s.Entries[key] != e || e.exit != exit {
+		return
+	}
+
+	ttl, sliding := e.ttl, e.sliding
+	e.stop()
+
+	e.value = value
+	e.version = c.versions.Add(1)
+	e.sliding = sliding
+	c.expireAfter(key, e, ttl)
+
+	s.stats.countSet()
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetStale(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	refreshed := make(chan struct{})
+
+	c := New(
+		WithStaleGrace(50*time.Millisecond),
+		WithRefresh(func(key string, v interface{}) (interface{}, error) {
+			<-refreshed
+			return v.(string) + "New", nil
+		}),
+	)
+
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	v, stale, ok := c.GetStale(key)
+	if !ok || stale {
+		t.Error("Expected fresh element in cache.")
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Get should not return stale elements.")
+		t.Fail()
+	}
+
+	v, stale, ok = c.GetStale(key)
+	if !ok || !stale {
+		t.Error("Expected stale element in cache.")
+		t.Fail()
+	}
+
+	if v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	close(refreshed)
+	time.Sleep(5 * time.Millisecond)
+
+	v, ok = c.Get(key)
+	if !ok {
+		t.Error("Element should have been refreshed.")
+		t.Fail()
+	}
+
+	if v != value+"New" {
+		t.Error("Expected", value+"New", "got", v)
+		t.Fail()
+	}
+}
+
+func TestGetStaleExpired(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(
+		WithStaleGrace(10*time.Millisecond),
+		WithRefresh(func(key string, v interface{}) (interface{}, error) {
+			return nil, errors.New("refresh failed")
+		}),
+	)
+
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	time.Sleep(15 * time.Millisecond)
+
+	if _, stale, ok := c.GetStale(key); !ok || !stale {
+		t.Error("Expected stale element in cache.")
+		t.Fail()
+	}
+
+	time.Sleep(15 * time.Millisecond)
+
+	if _, _, ok := c.GetStale(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestRefreshAhead(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(
+		WithRefreshAhead(0.5),
+		WithRefresh(func(key string, v interface{}) (interface{}, error) {
+			return v.(string) + "New", nil
+		}),
+	)
+
+	c.SetWithTTL(key, value, 40*time.Millisecond)
+
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	time.Sleep(25 * time.Millisecond)
+
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	v, ok := c.Get(key)
+	if !ok {
+		t.Error("Element should have been refreshed.")
+		t.Fail()
+	}
+
+	if v != value+"New" {
+		t.Error("Expected", value+"New", "got", v)
+		t.Fail()
+	}
+
+	time.Sleep(25 * time.Millisecond)
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Element should not have expired after refresh.")
+		t.Fail()
+	}
+}
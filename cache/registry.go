@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// registry holds the caches registered with Register.
+var registry = struct {
+	sync.RWMutex
+	layers map[string]Layer
+}{layers: make(map[string]Layer)}
+
+// Register makes a cache available by name to Lookup and Registered, e.g.
+// for admin endpoints and metrics exporters enumerating the caches of the
+// process. Registering a name twice fails.
+func Register(name string, l Layer) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.layers[name]; ok {
+		return fmt.Errorf("cache: %q is registered already", name)
+	}
+	registry.layers[name] = l
+
+	return nil
+}
+
+// Unregister removes the cache registered with the given name.
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	delete(registry.layers, name)
+}
+
+// Lookup returns the cache registered with the given name.
+func Lookup(name string) (Layer, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	l, ok := registry.layers[name]
+	return l, ok
+}
+
+// Registered returns the sorted names of all registered caches.
+func Registered() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.layers))
+	for name := range registry.layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// RegisteredStats returns the sums of the stats of all registered caches.
+// Uptime is the earliest of their uptimes, windowed stats and latencies are
+// not aggregated.
+func RegisteredStats() *Stats {
+	registry.RLock()
+	layers := make([]Layer, 0, len(registry.layers))
+	for _, l := range registry.layers {
+		layers = append(layers, l)
+	}
+	registry.RUnlock()
+
+	var s Stats
+	for _, l := range layers {
+		ls := l.GetStats()
+
+		s.Hits += ls.Hits
+		s.Misses += ls.Misses
+		s.Set += ls.Set
+		s.Removed += ls.Removed
+		s.Expired += ls.Expired
+		s.Evicted += ls.Evicted
+		s.Entries += ls.Entries
+		if s.Uptime.IsZero() || ls.Uptime.Before(s.Uptime) {
+			s.Uptime = ls.Uptime
+		}
+	}
+	s.SetHitRatio()
+
+	return &s
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	sessions := New()
+	users := New()
+	defer Unregister("sessions")
+	defer Unregister("users")
+
+	if err := Register("sessions", sessions); err != nil {
+		t.Error("Failed to register cache:", err)
+		t.Fail()
+	}
+	Register("users", users)
+
+	if err := Register("sessions", users); err == nil {
+		t.Error("Registering a name twice should fail.")
+		t.Fail()
+	}
+
+	if l, ok := Lookup("sessions"); !ok || l != sessions {
+		t.Error("Expected the sessions cache got", l)
+		t.Fail()
+	}
+
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Expected no cache.")
+		t.Fail()
+	}
+
+	if names := Registered(); !reflect.DeepEqual(names, []string{"sessions", "users"}) {
+		t.Error("Expected [sessions users] got", names)
+		t.Fail()
+	}
+
+	sessions.Set(key, value)
+	users.Set(key, value)
+	users.Get(key)
+
+	s := RegisteredStats()
+	if s.Set != 2 || s.Hits != 1 || s.Entries != 2 || s.Uptime != sessions.created {
+		t.Error("Unexpected aggregate stats", s)
+		t.Fail()
+	}
+}
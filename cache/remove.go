@@ -0,0 +1,86 @@
+package cache
+
+import "strings"
+
+// RemovePrefix deletes all entries with keys starting with prefix like
+// Remove and returns how many were deleted. Shards are processed one after
+// another, each locked while its matching entries are deleted.
+func (c *Cache) RemovePrefix(prefix string) int {
+	return c.removeWhere(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// RemoveMatch deletes all entries with keys matching the glob pattern like
+// RemovePrefix. Patterns use the syntax of Match.
+func (c *Cache) RemoveMatch(pattern string) int {
+	return c.removeWhere(func(key string) bool { return matchGlob(pattern, key) })
+}
+
+// removeWhere deletes all entries accepted by match and returns how many
+// were deleted.
+func (c *Cache) removeWhere(match func(key string) bool) int {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	n := 0
+	for i := 0; i < c.len(); i++ {
+		removed := c.removeFromShard(c.shard(i), match)
+		n += len(removed)
+
+		for _, key := range removed {
+			c.invalidateOthers(key)
+		}
+	}
+
+	return n
+}
+
+// removeFromShard deletes the entries of a shard accepted by match and
+// returns their keys.
+func (c *Cache) removeFromShard(s *shard, match func(key string) bool) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	var removed []string
+	for k, e := range s.Entries {
+		if !match(k) || !c.removeThrough(k) {
+			continue
+		}
+
+		e.stop()
+		delete(s.Entries, k)
+		c.logRemove(k)
+		c.publish(EventRemove, k, e.value)
+		c.removed(k, e.value, Removed)
+		s.stats.countRemove()
+		recycle(e)
+
+		removed = append(removed, k)
+	}
+
+	return removed
+}
+
+// Clear deletes all entries, one shard at a time with the shard locked, and
+// stops their ttl go routines. Removals are counted in the stats, logged,
+// replicated and published like those of Remove, but not written through to
+// the Store or broadcast on the invalidation bus.
+func (c *Cache) Clear() {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.Lock()
+		for k, e := range s.Entries {
+			e.stop()
+			c.logRemove(k)
+			c.publish(EventRemove, k, e.value)
+			c.removed(k, e.value, Removed)
+			s.stats.countRemove()
+			recycle(e)
+		}
+		s.Entries = make(map[string]*entry, s.hint)
+		s.peak = 0
+		s.Unlock()
+	}
+}
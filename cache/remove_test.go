@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRemovePrefix(t *testing.T) {
+	value := "testValue"
+
+	c := New()
+	for i := 0; i < 100; i++ {
+		c.Set("user:"+strconv.Itoa(i), value)
+	}
+	c.Set("session:1", value)
+
+	if n := c.RemovePrefix("user:"); n != 100 {
+		t.Errorf("Expected 100 removed entries. Got %d", n)
+		t.Fail()
+	}
+
+	if keys := c.Keys(); len(keys) != 1 || keys[0] != "session:1" {
+		t.Error("Expected only session:1 to be left, got", keys)
+		t.Fail()
+	}
+
+	if s := c.GetStats(); s.Removed != 100 {
+		t.Errorf("Expected 100 removals. Got %d", s.Removed)
+		t.Fail()
+	}
+}
+
+func TestRemoveMatch(t *testing.T) {
+	value := "testValue"
+
+	store := newTestStore()
+	c := New(WithStore(store))
+	c.Set("user:1:name", value)
+	c.Set("user:1:mail", value)
+	c.Set("user:2:name", value)
+
+	if n := c.RemoveMatch("user:*:name"); n != 2 {
+		t.Errorf("Expected 2 removed entries. Got %d", n)
+		t.Fail()
+	}
+
+	if !c.Has("user:1:mail") || c.Has("user:2:name") {
+		t.Error("Only matching entries should have been removed.")
+		t.Fail()
+	}
+
+	if _, ok := store.values["user:1:name"]; ok {
+		t.Error("Removal should have been written through to the store.")
+		t.Fail()
+	}
+}
+
+func TestClear(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	goroutines := runtime.NumGoroutine()
+
+	for i := 0; i < 100; i++ {
+		c.SetWithTTL(key+strconv.Itoa(i), value, time.Hour)
+	}
+
+	c.Clear()
+
+	if n := c.Len(); n != 0 {
+		t.Errorf("Expected no entries. Got %d", n)
+		t.Fail()
+	}
+
+	if s := c.GetStats(); s.Removed != 100 {
+		t.Errorf("Expected 100 removals. Got %d", s.Removed)
+		t.Fail()
+	}
+
+	// ttl go routines exit asynchronously
+	for i := 0; i < 100 && runtime.NumGoroutine() > goroutines; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("Expected ttl go routines to exit. Got %d go routines, %d before", n, goroutines)
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	if !c.Has(key) {
+		t.Error("Cache should be usable after Clear.")
+		t.Fail()
+	}
+}
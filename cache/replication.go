@@ -0,0 +1,293 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// replicaBackoff is the maximum time a replica waits between attempts to
+// connect to its primary.
+const replicaBackoff = 5 * time.Second
+
+// replicas passes modifications of a primary cache to connected replicas.
+type replicas struct {
+	buffer int
+	feeds  map[*feed]struct{}
+	// active is the number of feeds, checked without locking on every write
+	active atomic.Int32
+	closed bool
+	sync.Mutex
+}
+
+// feed holds the records not yet sent to a replica.
+type feed struct {
+	records chan *logRecord
+	// done is closed once the replica fell behind or the cache is closed
+	done chan struct{}
+}
+
+func newReplicas() *replicas {
+	return &replicas{
+		buffer: 4096,
+		feeds:  make(map[*feed]struct{}),
+	}
+}
+
+// add registers a new feed, nil if the cache is closed.
+func (r *replicas) add() *feed {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closed {
+		return nil
+	}
+
+	f := &feed{
+		records: make(chan *logRecord, r.buffer),
+		done:    make(chan struct{}),
+	}
+	r.feeds[f] = struct{}{}
+	r.active.Add(1)
+
+	return f
+}
+
+func (r *replicas) remove(f *feed) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.feeds[f]; ok {
+		delete(r.feeds, f)
+		r.active.Add(-1)
+		f.stop()
+	}
+}
+
+func (f *feed) stop() {
+	select {
+	case <-f.done:
+	default:
+		close(f.done)
+	}
+}
+
+// send passes a record to all feeds without blocking. Replicas falling
+// behind are disconnected and synchronize again once they reconnect.
+func (r *replicas) send(rec *logRecord) {
+	if r.active.Load() == 0 {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for f := range r.feeds {
+		select {
+		case f.records <- rec:
+		default:
+			f.stop()
+		}
+	}
+}
+
+// close disconnects all replicas.
+func (r *replicas) close() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.closed = true
+	for f := range r.feeds {
+		delete(r.feeds, f)
+		r.active.Add(-1)
+		f.stop()
+	}
+}
+
+// ServeReplicas makes the cache the primary of the replicas connecting on l.
+// Each replica first receives all entries and then every modification of the
+// cache, including removals of expired entries, asynchronously. Replicas that
+// fall behind by more than the buffer set with WithReplicationBuffer are
+// disconnected and synchronize again once they reconnect. ServeReplicas
+// returns once l is closed, replicas are disconnected by Close.
+func (c *Cache) ServeReplicas(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := c.serveReplica(conn); err != nil {
+				c.handleError(err)
+			}
+		}()
+	}
+}
+
+func (c *Cache) serveReplica(conn net.Conn) error {
+	defer conn.Close()
+
+	// register the feed first, records of modifications made while the
+	// entries are sent are applied after them
+	f := c.replicas.add()
+	if f == nil {
+		return nil
+	}
+	defer c.replicas.remove(f)
+
+	// reading fails once the replica disconnects
+	go func() {
+		conn.Read(make([]byte, 1))
+		f.stop()
+	}()
+
+	w := bufio.NewWriter(conn)
+
+	write := func(rec *logRecord) error {
+		frame, err := encodeRecord(rec, c.encryption)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(frame)
+		return err
+	}
+
+	// the entries are sent holding resizing, but not the records of the
+	// feed after them
+	c.resizing.RLock()
+	for i := 0; i < c.len(); i++ {
+		for _, se := range c.snapshotShard(c.shard(i)) {
+			err := write(&logRecord{
+				Key:     se.Key,
+				Value:   se.Value,
+				Expires: se.Expires,
+				TTL:     se.TTL,
+				Sliding: se.Sliding,
+			})
+			if err != nil {
+				c.resizing.RUnlock()
+				return err
+			}
+		}
+	}
+	c.resizing.RUnlock()
+	if err := write(&logRecord{Synced: true}); err != nil {
+		return err
+	}
+
+	for {
+		if len(f.records) == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case rec := <-f.records:
+			if err := write(rec); err != nil {
+				return err
+			}
+		case <-f.done:
+			return nil
+		}
+	}
+}
+
+// ReplicateFrom makes the cache a replica of the primary at the TCP address
+// addr until ctx is done. The entries of the cache are replaced with the ones
+// of the primary, which are then kept up to date asynchronously. If the
+// connection fails the error is passed to the error handler and the replica
+// reconnects, serving its possibly stale entries meanwhile. To fail over,
+// cancel ctx and use the replica as primary. The encryption key of the
+// replica has to match the one of the primary. Replicas should not be
+// modified directly, modified entries are overwritten by the primary.
+func (c *Cache) ReplicateFrom(ctx context.Context, addr string) error {
+	var d net.Dialer
+	backoff := 100 * time.Millisecond
+
+	for {
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			backoff = 100 * time.Millisecond
+			err = c.replicate(ctx, conn)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.handleError(err)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > replicaBackoff {
+			backoff = replicaBackoff
+		}
+	}
+}
+
+// replicate applies the records received from a primary.
+func (c *Cache) replicate(ctx context.Context, conn net.Conn) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	// keys received during the initial synchronization
+	synced := make(map[string]struct{})
+	for {
+		rec, _, err := readRecord(r, c.encryption)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case rec.Synced:
+			c.removeUnsynced(synced)
+			synced = nil
+		case rec.Remove:
+			c.removeLocal(rec.Key)
+		default:
+			if synced != nil {
+				synced[rec.Key] = struct{}{}
+			}
+			c.restore(&snapshotEntry{
+				Key:     rec.Key,
+				Value:   rec.Value,
+				Expires: rec.Expires,
+				TTL:     rec.TTL,
+				Sliding: rec.Sliding,
+			})
+		}
+	}
+}
+
+// removeUnsynced removes the entries the primary did not send.
+func (c *Cache) removeUnsynced(synced map[string]struct{}) {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.Lock()
+		for k, e := range s.Entries {
+			if _, ok := synced[k]; !ok {
+				e.stop()
+				delete(s.Entries, k)
+				c.logRemove(k)
+				c.publish(EventRemove, k, e.value)
+				c.removed(k, e.value, Removed)
+				recycle(e)
+			}
+		}
+		s.Unlock()
+	}
+}
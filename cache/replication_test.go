@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or a second passed.
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestReplication(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	primary := New()
+	defer primary.Close()
+
+	for i := 0; i < 100; i++ {
+		primary.Set(key+strconv.Itoa(i), value)
+	}
+	primary.SetWithTTL(key, value, time.Minute)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go primary.ServeReplicas(l)
+
+	replica := New(WithStaleGrace(time.Hour))
+	// stale entries are removed on synchronization
+	replica.Set("stale", value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- replica.ReplicateFrom(ctx, l.Addr().String())
+	}()
+
+	if !waitFor(func() bool { _, ok := replica.TTL(key + "99"); return ok }) {
+		t.Error("Entries should have been replicated.")
+		t.Fail()
+	}
+
+	if ttl, ok := replica.TTL(key); !ok || ttl <= 59*time.Second {
+		t.Error("Expiry should have been replicated, got", ttl)
+		t.Fail()
+	}
+
+	if !waitFor(func() bool { _, ok := replica.TTL("stale"); return !ok }) {
+		t.Error("Stale entry should have been removed.")
+		t.Fail()
+	}
+
+	primary.Set("new", value)
+	primary.Remove(key + "42")
+	primary.SetWithTTL("short", value, 10*time.Millisecond)
+
+	if !waitFor(func() bool { _, ok := replica.TTL("new"); return ok }) {
+		t.Error("Set should have been replicated.")
+		t.Fail()
+	}
+
+	if !waitFor(func() bool { _, ok := replica.TTL(key + "42"); return !ok }) {
+		t.Error("Remove should have been replicated.")
+		t.Fail()
+	}
+
+	// the replica keeps expired entries during its grace period unless the
+	// primary removes them
+	if !waitFor(func() bool {
+		s := replica.getShard("short")
+		s.RLock()
+		defer s.RUnlock()
+		_, ok := s.Entries["short"]
+		return !ok
+	}) {
+		t.Error("Expiry should have been replicated.")
+		t.Fail()
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("Expected", context.Canceled, "got", err)
+		t.Fail()
+	}
+
+	// the replica keeps its entries after failing over
+	if v, ok := replica.Get("new"); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestReplicationReconnect(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	primary := New(WithReplicationBuffer(1))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go primary.ServeReplicas(l)
+
+	errs := make(chan error, 100)
+	replica := New(WithErrorHandler(func(err error) {
+		errs <- err
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go replica.ReplicateFrom(ctx, l.Addr().String())
+
+	if !waitFor(func() bool { return primary.replicas.active.Load() == 1 }) {
+		t.Fatal("Replica should have connected.")
+	}
+
+	// overflowing the buffer disconnects the replica, which synchronizes
+	// again once it reconnected
+	for i := 0; i < 1000; i++ {
+		primary.Set(key+strconv.Itoa(i), value)
+	}
+
+	if !waitFor(func() bool { _, ok := replica.TTL(key + "999"); return ok }) {
+		t.Error("Entries should have been replicated after reconnecting.")
+		t.Fail()
+	}
+
+	primary.Close()
+
+	select {
+	case <-errs:
+	case <-time.After(time.Second):
+		t.Error("Disconnect should have been reported.")
+		t.Fail()
+	}
+}
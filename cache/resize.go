@@ -0,0 +1,54 @@
+package cache
+
+// Resize changes the number of shards of the cache to n, limited to MaxShards
+// like WithShards, e.g. to add shards when lock contention grows with the
+// load. The entries are migrated one shard at a time, each locked while its
+// entries are moved, so operations on single keys continue meanwhile and only
+// wait for the migration of the shard holding their key. Operations on the
+// whole cache like Keys, Scan and GetStats wait until all entries are
+// migrated, Range only between two shards. Scan cursors returned before a
+// Resize may skip or repeat keys. Stats are kept, hot keys tracked with
+// WithHotKeyTracking start over.
+func (c *Cache) Resize(n int) {
+	n = min(max(n, 1), MaxShards)
+
+	c.resizing.Lock()
+	defer c.resizing.Unlock()
+
+	old := *c.shards.Load()
+	if n == len(old) {
+		return
+	}
+
+	shards := c.newShards(n)
+	for i, s := range old {
+		s.Lock()
+		for k, e := range s.Entries {
+			t := shards[c.hasher.Hash(k)%uint64(n)]
+			t.Lock()
+			t.Entries[k] = e
+			t.peak = max(t.peak, len(t.Entries))
+			t.Unlock()
+		}
+		s.Entries = make(map[string]*entry)
+		s.moved = shards
+		shards[i%n].stats.add(s.stats)
+		s.Unlock()
+	}
+
+	c.shards.Store(&shards)
+}
+
+// add adds the counters of o to s.
+func (s *counters) add(o *counters) {
+	if s == nil || o == nil {
+		return
+	}
+
+	s.hits.Add(o.hits.Load())
+	s.misses.Add(o.misses.Load())
+	s.set.Add(o.set.Load())
+	s.removed.Add(o.removed.Load())
+	s.expired.Add(o.expired.Load())
+	s.evicted.Add(o.evicted.Load())
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResize(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithShards(4))
+	for i := 0; i < 1000; i++ {
+		c.Set(key+strconv.Itoa(i), value+strconv.Itoa(i))
+	}
+	c.SetWithTTL(key, value, 50*time.Millisecond)
+
+	for _, n := range []int{32, 3, 0} {
+		c.Resize(n)
+		if want := max(n, 1); c.len() != want {
+			t.Errorf("Expected %d shards. Got %d", want, c.len())
+			t.Fail()
+		}
+
+		for i := 0; i < 1000; i++ {
+			if v, _ := c.Get(key + strconv.Itoa(i)); v != value+strconv.Itoa(i) {
+				t.Error("Expected", value+strconv.Itoa(i), "got", v)
+				t.Fail()
+			}
+		}
+	}
+
+	if s := c.GetStats(); s.Set != 1001 || s.Hits != 3000 || s.Entries != 1001 {
+		t.Errorf("Expected 1001 sets, 3000 hits and 1001 entries. Got %d, %d and %d", s.Set, s.Hits, s.Entries)
+		t.Fail()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if c.Has(key) {
+		t.Error("Expected", key, "to expire after resizing")
+		t.Fail()
+	}
+}
+
+func TestResizeMaxEntries(t *testing.T) {
+	c := New(WithShards(4), WithMaxEntries(100))
+	c.Resize(10)
+
+	for i := 0; i < 1000; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	if n := c.Len(); n > 100 {
+		t.Errorf("Expected at most 100 entries. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestResizeParallel(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithShards(2))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := key + strconv.Itoa(g) + ":" + strconv.Itoa(i)
+				c.Set(k, value)
+				if v, _ := c.Get(k); v != value {
+					t.Error("Expected", value, "got", v)
+					t.Fail()
+				}
+				if i%2 == 0 {
+					c.Remove(k)
+				}
+			}
+		}(g)
+	}
+
+	for _, n := range []int{8, 64, 16, 1, 32} {
+		c.Resize(n)
+	}
+	wg.Wait()
+
+	if n := c.Len(); n != 2000 {
+		t.Errorf("Expected 2000 entries. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestResizeRange(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithShards(4))
+	for i := 0; i < 1000; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	visited := make(map[string]int)
+	c.Range(func(k string, v interface{}) bool {
+		switch len(visited) {
+		case 0:
+			c.Resize(7)
+		case 300:
+			c.Resize(64)
+		case 600:
+			c.Resize(1)
+		}
+		// f may use the whole cache
+		c.Len()
+
+		visited[k]++
+		return true
+	})
+
+	if len(visited) != 1000 {
+		t.Errorf("Expected 1000 keys to be visited. Got %d", len(visited))
+		t.Fail()
+	}
+	for k, n := range visited {
+		if n != 1 {
+			t.Errorf("Expected %s to be visited once. Got %d", k, n)
+			t.Fail()
+		}
+	}
+}
+
+func TestResizeRangeParallel(t *testing.T) {
+	c := New(WithShards(4))
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			c.Range(func(key string, value interface{}) bool {
+				c.Len()
+				return true
+			})
+		}
+	}()
+
+	for n := 1; n <= 64; n++ {
+		c.Resize(n)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Error("Range calling Len deadlocked with Resize.")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Acquire takes one of max slots of a counting semaphore stored with the
+// given key as int64 count of holders and reports whether it succeeded. Every
+// acquisition extends the ttl of the semaphore, so slots of holders that fail
+// to release them are freed once ttl elapsed without acquisitions. release
+// frees the slot, it can be called multiple times and does nothing once the
+// semaphore expired. A ttl of zero or less fails, as slots would never be
+// freed.
+func (c *Cache) Acquire(key string, max int, ttl time.Duration) (release func(), ok bool) {
+	if ttl <= 0 {
+		return nil, false
+	}
+
+	s := c.lockShard(key)
+
+	e, exists := s.Entries[key]
+	if exists && e.expired() {
+		// remove the expired semaphore right away, so its holders cannot
+		// release slots of the new one
+		e.stop()
+		delete(s.Entries, key)
+		c.logRemove(key)
+		c.publish(EventExpire, key, e.value)
+		c.removed(key, e.value, Expired)
+		s.stats.countExpire()
+		recycle(e)
+		exists = false
+	}
+
+	n := int64(0)
+	if exists {
+		held, isCount := e.value.(int64)
+		if !isCount {
+			s.Unlock()
+			return nil, false
+		}
+		n = held
+	}
+
+	if n >= int64(max) || !c.writeThrough(key, n+1, ttl) {
+		s.Unlock()
+		return nil, false
+	}
+	c.set(s, key, n+1, ttl)
+	e = s.Entries[key]
+	e.shared = true
+	s.Unlock()
+
+	c.invalidateOthers(key)
+
+	var once sync.Once
+	return func() { once.Do(func() { c.release(key, e) }) }, true
+}
+
+// release frees a slot of the semaphore stored in e with the given key unless
+// the entry got replaced, e.g. after it expired. The entry is deleted with the
+// last slot.
+func (c *Cache) release(key string, e *entry) {
+	s := c.lockShard(key)
+
+	n, isCount := e.value.(int64)
+	if s.Entries[key] != e || e.expired() || !isCount {
+		s.Unlock()
+		return
+	}
+
+	if n > 1 {
+		c.replace(s, key, e, n-1)
+		s.Unlock()
+		c.invalidateOthers(key)
+		return
+	}
+
+	if c.removeThrough(key) {
+		e.stop()
+		delete(s.Entries, key)
+		c.logRemove(key)
+		c.publish(EventRemove, key, e.value)
+		c.removed(key, e.value, Removed)
+		s.stats.countRemove()
+		recycle(e)
+	}
+	s.Unlock()
+
+	c.invalidateOthers(key)
+}
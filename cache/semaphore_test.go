@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	first, ok := c.Acquire(key, 2, time.Hour)
+	if !ok {
+		t.Error("First slot should have been acquired.")
+		t.FailNow()
+	}
+
+	second, ok := c.Acquire(key, 2, time.Hour)
+	if !ok {
+		t.Error("Second slot should have been acquired.")
+		t.FailNow()
+	}
+
+	if _, ok := c.Acquire(key, 2, time.Hour); ok {
+		t.Error("Semaphore should be full.")
+		t.Fail()
+	}
+
+	first()
+	first()
+	if v, _ := c.Get(key); v != int64(1) {
+		t.Error("Expected 1 holder got", v)
+		t.Fail()
+	}
+
+	second()
+	if c.Has(key) {
+		t.Error("Semaphore without holders should have been removed.")
+		t.Fail()
+	}
+
+	if _, ok := c.Acquire(key, 2, 0); ok {
+		t.Error("Slot without ttl should not have been acquired.")
+		t.Fail()
+	}
+}
+
+func TestAcquireExpiry(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	expired, _ := c.Acquire(key, 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	release, ok := c.Acquire(key, 1, time.Hour)
+	if !ok {
+		t.Error("Slot should have been freed by expiry.")
+		t.FailNow()
+	}
+
+	expired()
+	if _, ok := c.Acquire(key, 1, time.Hour); ok {
+		t.Error("Releasing an expired slot should not free another one.")
+		t.Fail()
+	}
+
+	release()
+}
+
+func TestAcquireParallel(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	var wg sync.WaitGroup
+	var held int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				release, ok := c.Acquire(key, 3, time.Hour)
+				if !ok {
+					continue
+				}
+				if atomic.AddInt32(&held, 1) > 3 {
+					t.Error("Too many slots held concurrently.")
+				}
+				atomic.AddInt32(&held, -1)
+				release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.Has(key) {
+		t.Error("All slots should have been released.")
+		t.Fail()
+	}
+}
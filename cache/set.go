@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"encoding/gob"
+	"sort"
+)
+
+// Set is the value stored by the set operations SAdd and SRem, which copy it
+// (see modify). Like other entries a set expires as a whole, e.g. after Touch
+// set its ttl.
+type Set map[string]bool
+
+func init() {
+	gob.Register(Set{})
+}
+
+// SAdd adds the members to the set stored with the given key and returns how
+// many of them were not in the set before. Missing keys are stored as new
+// sets with the default ttl of the cache. If the value stored with the key is
+// not a Set ErrWrongType is returned.
+func (c *Cache) SAdd(key string, members ...string) (n int, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		set, err := asSet(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		added := make(Set, len(set)+len(members))
+		for m := range set {
+			added[m] = true
+		}
+		for _, m := range members {
+			if !added[m] {
+				added[m] = true
+				n++
+			}
+		}
+
+		return added, nil
+	})
+
+	return n, err
+}
+
+// SRem removes the members from the set stored with the given key and
+// returns how many of them were in the set. The key is deleted with the last
+// member of the set.
+func (c *Cache) SRem(key string, members ...string) (n int, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		set, err := asSet(v, ok)
+		if err != nil || !ok {
+			return nil, err
+		}
+
+		removed := make(Set, len(set))
+		for m := range set {
+			removed[m] = true
+		}
+		for _, m := range members {
+			if removed[m] {
+				delete(removed, m)
+				n++
+			}
+		}
+
+		if len(removed) == 0 {
+			return nil, nil
+		}
+		return removed, nil
+	})
+
+	return n, err
+}
+
+// SMembers returns the sorted members of the set stored with the given key.
+// A missing key is an empty set.
+func (c *Cache) SMembers(key string) ([]string, error) {
+	set, err := c.members(key)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(set))
+	for m := range set {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+
+	return members, nil
+}
+
+// SIsMember reports whether member is in the set stored with the given key.
+func (c *Cache) SIsMember(key string, member string) (bool, error) {
+	set, err := c.members(key)
+	return set[member], err
+}
+
+// SCard returns the number of members of the set stored with the given key.
+func (c *Cache) SCard(key string) (int, error) {
+	set, err := c.members(key)
+	return len(set), err
+}
+
+// members returns the set stored with the given key counting the access
+// like Get.
+func (c *Cache) members(key string) (Set, error) {
+	v, ok := c.get(key)
+	return asSet(v, ok)
+}
+
+func asSet(v interface{}, ok bool) (Set, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	set, isSet := v.(Set)
+	if !isSet {
+		return nil, ErrWrongType
+	}
+
+	return set, nil
+}
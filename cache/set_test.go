@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSet(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	if n, err := c.SAdd(key, "a", "b", "a"); err != nil || n != 2 {
+		t.Errorf("Expected 2 added members. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if n, _ := c.SAdd(key, "b", "c"); n != 1 {
+		t.Errorf("Expected 1 added member. Got %d", n)
+		t.Fail()
+	}
+
+	if members, err := c.SMembers(key); err != nil || !reflect.DeepEqual(members, []string{"a", "b", "c"}) {
+		t.Error("Expected [a b c] got", members, err)
+		t.Fail()
+	}
+
+	if ok, _ := c.SIsMember(key, "b"); !ok {
+		t.Error("b should be a member.")
+		t.Fail()
+	}
+
+	if n, _ := c.SRem(key, "b", "d"); n != 1 {
+		t.Errorf("Expected 1 removed member. Got %d", n)
+		t.Fail()
+	}
+
+	if ok, _ := c.SIsMember(key, "b"); ok {
+		t.Error("b should not be a member.")
+		t.Fail()
+	}
+
+	if n, _ := c.SCard(key); n != 2 {
+		t.Errorf("Expected 2 members. Got %d", n)
+		t.Fail()
+	}
+
+	c.SRem(key, "a", "c")
+	if c.Has(key) {
+		t.Error("Empty set should have been removed.")
+		t.Fail()
+	}
+}
+
+func TestSetTTL(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+	c.SAdd(key, "a")
+	c.Touch(key, 10*time.Millisecond)
+	c.SAdd(key, "b")
+
+	time.Sleep(20 * time.Millisecond)
+
+	if n, _ := c.SCard(key); n != 0 {
+		t.Errorf("Expected the set to expire. Got %d members", n)
+		t.Fail()
+	}
+}
+
+func TestSetWrongType(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	if _, err := c.SAdd(key, value); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import "time"
+
+// shrinkRatio is how many times the peak number of entries of a shard has to
+// exceed its current number of entries for its map to be rebuilt.
+const shrinkRatio = 4
+
+// shrinkMinPeak is the peak number of entries below which maps of shards are
+// not rebuilt, as they hold little memory.
+const shrinkMinPeak = 1024
+
+// shrinker rebuilds the maps of shards periodically.
+type shrinker struct {
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newShrinker(interval time.Duration) *shrinker {
+	return &shrinker{
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (sh *shrinker) run(c *Cache) {
+	defer close(sh.done)
+
+	t := time.NewTicker(sh.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			c.shrink()
+		case <-sh.stop:
+			return
+		}
+	}
+}
+
+// close stops rebuilding maps and waits for a running rebuild to finish.
+func (sh *shrinker) close() {
+	close(sh.stop)
+	<-sh.done
+}
+
+// shrink rebuilds the maps of shards holding far less entries than they did
+// at their peak and returns how many were rebuilt. Go maps keep the memory of
+// their peak size, so without rebuilding a cache that once held millions of
+// entries keeps their memory after most were removed or expired.
+func (c *Cache) shrink() int {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	n := 0
+	for i := 0; i < c.len(); i++ {
+		s := c.shard(i)
+		s.Lock()
+		if s.peak >= shrinkMinPeak && s.peak > s.hint && len(s.Entries) <= s.peak/shrinkRatio {
+			entries := make(map[string]*entry, max(len(s.Entries), s.hint))
+			for k, e := range s.Entries {
+				entries[k] = e
+			}
+			s.Entries = entries
+			s.peak = len(entries)
+			n++
+		}
+		s.Unlock()
+	}
+
+	return n
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestShrink(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithShards(1))
+	for i := 0; i < 10*shrinkMinPeak; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	if n := c.shrink(); n != 0 {
+		t.Errorf("Expected no maps to be rebuilt. Got %d", n)
+		t.Fail()
+	}
+
+	for i := 10; i < 10*shrinkMinPeak; i++ {
+		c.Remove(key + strconv.Itoa(i))
+	}
+
+	if n := c.shrink(); n != 1 {
+		t.Errorf("Expected 1 map to be rebuilt. Got %d", n)
+		t.Fail()
+	}
+
+	for i := 0; i < 10; i++ {
+		if v, _ := c.Get(key + strconv.Itoa(i)); v != value {
+			t.Error("Expected", value, "got", v)
+			t.Fail()
+		}
+	}
+
+	if p := c.shard(0).peak; p != 10 {
+		t.Errorf("Expected a peak of 10 entries. Got %d", p)
+		t.Fail()
+	}
+}
+
+func TestWithMapShrinking(t *testing.T) {
+	c := New(WithShards(1), WithMapShrinking(10*time.Millisecond))
+	defer c.Close()
+
+	for i := 0; i < shrinkMinPeak; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	c.RemovePrefix("")
+
+	time.Sleep(50 * time.Millisecond)
+
+	s := c.shard(0)
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.peak != 0 {
+		t.Errorf("Expected the map to be rebuilt. Got a peak of %d", s.peak)
+		t.Fail()
+	}
+}
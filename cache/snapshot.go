@@ -0,0 +1,348 @@
+package cache
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotMagic identifies snapshot files, followed by a version and a flags
+// byte.
+const snapshotMagic = "LCSNAP"
+
+const snapshotVersion = 1
+
+// snapshot flags
+const (
+	flagEncrypted byte = 1 << iota
+	flagGzip
+)
+
+// Compression is a compression algorithm for snapshots.
+type Compression int
+
+const (
+	// NoCompression writes snapshots uncompressed.
+	NoCompression Compression = iota
+	// Gzip compresses snapshots with gzip.
+	Gzip
+)
+
+// ErrInvalidSnapshot is returned when reading data that is not a snapshot of
+// a supported version.
+var ErrInvalidSnapshot = errors.New("cache: invalid snapshot")
+
+// snapshotEntry is the serialized form of an entry.
+type snapshotEntry struct {
+	Key   string
+	Value interface{}
+	// Expires is the expiry time in unix nanoseconds, zero if the entry does
+	// not expire
+	Expires int64
+	TTL     time.Duration
+	Sliding bool
+}
+
+// SaveSnapshot writes all entries of the cache including their expiry to w.
+// Values are encoded with encoding/gob, so their concrete types have to be
+// registered with gob.Register. Entries are copied shard by shard, writes to
+// other shards are not blocked meanwhile. The snapshot is compressed as
+// configured with WithSnapshotCompression and encrypted with AES-GCM if the
+// cache has an encryption key.
+func (c *Cache) SaveSnapshot(w io.Writer) error {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	var flags byte
+	if c.encryption != nil {
+		if c.encryption.err != nil {
+			return c.encryption.err
+		}
+		flags |= flagEncrypted
+	}
+	if c.compression == Gzip {
+		flags |= flagGzip
+	}
+
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+	if _, err := bw.Write([]byte{snapshotVersion, flags}); err != nil {
+		return err
+	}
+
+	// values are compressed before they are encrypted
+	var body io.Writer = bw
+	var ew *encryptWriter
+	if flags&flagEncrypted != 0 {
+		ew = newEncryptWriter(bw, c.encryption.aead)
+		body = ew
+	}
+	var zw *gzip.Writer
+	if flags&flagGzip != 0 {
+		zw = gzip.NewWriter(body)
+		body = zw
+	}
+
+	enc := gob.NewEncoder(body)
+
+	for i := 0; i < c.len(); i++ {
+		for _, se := range c.snapshotShard(c.shard(i)) {
+			if err := enc.Encode(&se); err != nil {
+				return err
+			}
+		}
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	if ew != nil {
+		if err := ew.Close(); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// SaveToFile writes a snapshot of the cache to the file at path. The file is
+// replaced atomically, so it always contains a complete snapshot.
+func (c *Cache) SaveToFile(path string) error {
+	start := time.Now()
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := c.SaveSnapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	c.logf(slog.LevelInfo, "snapshot saved", "path", path, "duration", time.Since(start))
+
+	return nil
+}
+
+// snapshotShard copies the live entries of a shard.
+func (c *Cache) snapshotShard(s *shard) []snapshotEntry {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.snapshot()
+}
+
+// snapshot copies the live entries of the shard, which has to be locked for
+// reading.
+func (s *shard) snapshot() []snapshotEntry {
+	entries := make([]snapshotEntry, 0, len(s.Entries))
+	for k, e := range s.Entries {
+		if e.expired() {
+			continue
+		}
+
+		entries = append(entries, snapshotEntry{
+			Key:     k,
+			Value:   e.value,
+			Expires: e.expires.Load(),
+			TTL:     e.ttl,
+			Sliding: e.sliding,
+		})
+	}
+
+	return entries
+}
+
+// LoadSnapshot restores the entries of a snapshot written by SaveSnapshot.
+// Entries whose ttl has elapsed in the meantime are skipped, the others keep
+// their original expiry. Existing entries with the same keys are replaced.
+func (c *Cache) LoadSnapshot(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(snapshotMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return ErrInvalidSnapshot
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic || header[len(snapshotMagic)] != snapshotVersion {
+		return ErrInvalidSnapshot
+	}
+	flags := header[len(snapshotMagic)+1]
+
+	var body io.Reader = br
+	if flags&flagEncrypted != 0 {
+		if c.encryption == nil {
+			return ErrNoEncryptionKey
+		}
+		if c.encryption.err != nil {
+			return c.encryption.err
+		}
+		body = newDecryptReader(br, c.encryption.aead)
+	}
+	if flags&flagGzip != 0 {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	dec := gob.NewDecoder(body)
+
+	for {
+		var se snapshotEntry
+		if err := dec.Decode(&se); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if se.Expires != 0 && time.Now().UnixNano() >= se.Expires {
+			continue
+		}
+
+		c.restore(&se)
+	}
+}
+
+// NewFromFile returns a reference to a new Cache configured with the given
+// options and restores the snapshot at path. If there is no file at path the
+// cache is returned empty. If the snapshot cannot be read the cache is closed
+// without saving a snapshot, so the file is not replaced with the entries
+// restored until then.
+func NewFromFile(path string, opts ...Option) (*Cache, error) {
+	c := New(opts...)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		c.abandon()
+		return nil, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	if err := c.LoadSnapshot(f); err != nil {
+		c.abandon()
+		return nil, err
+	}
+
+	c.logf(slog.LevelInfo, "snapshot loaded", "path", path, "duration", time.Since(start))
+
+	return c, nil
+}
+
+// abandon closes a cache which failed to load its snapshot like Close, but
+// without saving a final snapshot over the one it failed to load.
+func (c *Cache) abandon() {
+	if c.snapshots != nil {
+		c.snapshots.halt()
+		c.snapshots = nil
+	}
+	if err := c.Close(); err != nil {
+		c.handleError(err)
+	}
+}
+
+// restore stores a snapshot entry without writing it through to the Store.
+// It is also used to replay the append-only log.
+func (c *Cache) restore(se *snapshotEntry) {
+	s := c.lockShard(se.Key)
+	defer s.Unlock()
+
+	e := c.entry(s, se.Key)
+	e.stop()
+
+	e.value = se.Value
+	e.version = c.versions.Add(1)
+	if se.Expires != 0 {
+		e.sliding = se.Sliding
+		c.expireAt(se.Key, e, se.TTL, time.Unix(0, se.Expires))
+	}
+	c.logSet(se.Key, e)
+	c.publish(EventSet, se.Key, se.Value)
+
+	s.stats.countSet()
+}
+
+// autoSnapshot periodically saves snapshots of a cache to a file.
+type autoSnapshot struct {
+	path     string
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newAutoSnapshot(path string, interval time.Duration) *autoSnapshot {
+	return &autoSnapshot{
+		path:     path,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (a *autoSnapshot) run(c *Cache) {
+	defer close(a.done)
+
+	// without interval the snapshot is only saved on Close
+	var tick <-chan time.Time
+	if a.interval > 0 {
+		t := time.NewTicker(a.interval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			if err := c.SaveToFile(a.path); err != nil {
+				c.handleError(err)
+			}
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// close stops the periodic snapshots and saves a final one.
+func (a *autoSnapshot) close(c *Cache) error {
+	a.halt()
+
+	return c.SaveToFile(a.path)
+}
+
+// halt stops the periodic snapshots without saving a final one.
+func (a *autoSnapshot) halt() {
+	close(a.stop)
+	<-a.done
+}
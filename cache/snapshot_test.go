@@ -0,0 +1,325 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSaveSnapshot(t *testing.T) {
+	gob.Register(testType{})
+
+	key := "testKey"
+	value := testType{
+		Val1: "testValue",
+		Val2: 42,
+	}
+
+	c := New()
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+	c.SetWithTTL(key, value, time.Minute)
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte(snapshotMagic)) {
+		t.Error("Snapshot should start with magic bytes.")
+		t.Fail()
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(buf.Bytes()[len(snapshotMagic)+2:]))
+
+	n := 0
+	for {
+		var se snapshotEntry
+		if err := dec.Decode(&se); err != nil {
+			break
+		}
+		n++
+
+		if se.Value != value {
+			t.Error("Expected", value, "got", se.Value)
+			t.Fail()
+		}
+
+		if se.Key == key && (se.Expires == 0 || se.TTL != time.Minute) {
+			t.Error("Expiry should have been saved.")
+			t.Fail()
+		}
+	}
+
+	if n != 101 {
+		t.Errorf("Expected 101 entries in snapshot. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestSaveToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	c := New()
+	c.Set("testKey", "testValue")
+
+	if err := c.SaveToFile(path); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	files, _ := os.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("Expected only the snapshot file. Got %d files", len(files))
+		t.Fail()
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	for i := 0; i < 100; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+	c.SetWithTTL(key, value, 20*time.Millisecond)
+	c.SetWithTTL(key+"Expired", value, 10*time.Millisecond)
+	c.SetWithSlidingTTL(key+"Sliding", value, time.Minute)
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	c = New()
+	if err := c.LoadSnapshot(&buf); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	for i := 0; i < 100; i++ {
+		v, ok := c.Get(key + strconv.Itoa(i))
+		if !ok || v != value {
+			t.Error("Expected", value, "got", v)
+			t.Fail()
+		}
+	}
+
+	if _, ok := c.Get(key + "Expired"); ok {
+		t.Error("Expired element should not have been restored.")
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Element with ttl should have been restored.")
+		t.Fail()
+	}
+
+	s := c.getShard(key + "Sliding")
+	if e := s.Entries[key+"Sliding"]; e == nil || !e.sliding || e.ttl != time.Minute {
+		t.Error("Sliding expiration should have been restored.")
+		t.Fail()
+	}
+
+	time.Sleep(15 * time.Millisecond)
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Element should have expired with its original expiry.")
+		t.Fail()
+	}
+}
+
+func TestLoadInvalidSnapshot(t *testing.T) {
+	c := New()
+
+	if err := c.LoadSnapshot(bytes.NewBufferString("invalid")); err != ErrInvalidSnapshot {
+		t.Error("Expected", ErrInvalidSnapshot, "got", err)
+		t.Fail()
+	}
+}
+
+func TestNewFromFile(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	c, err := NewFromFile(path)
+	if err != nil {
+		t.Error("Missing snapshot should not be an error", err)
+		t.Fail()
+	}
+
+	c.Set(key, value)
+
+	if err := c.SaveToFile(path); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	c, err = NewFromFile(path, WithDefaultTTL(time.Minute))
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	v, ok := c.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestNewFromInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	if err := os.WriteFile(path, []byte("invalid"), 0o600); err != nil {
+		t.Fatal("Could not write snapshot", err)
+	}
+
+	c, err := NewFromFile(path, WithAutoSnapshot(path, 10*time.Millisecond))
+	if err != ErrInvalidSnapshot || c != nil {
+		t.Error("Expected", ErrInvalidSnapshot, "got", err)
+		t.Fail()
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	// the cache must be closed without saving snapshots over the invalid one
+	if data, _ := os.ReadFile(path); string(data) != "invalid" {
+		t.Error("Snapshot should not have been replaced. Got", data)
+		t.Fail()
+	}
+}
+
+func TestWithAutoSnapshot(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	c := New(WithAutoSnapshot(path, 10*time.Millisecond))
+
+	c.Set(key, value)
+
+	time.Sleep(20 * time.Millisecond)
+
+	restored, err := NewFromFile(path)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if _, ok := restored.Get(key); !ok {
+		t.Error("Element should have been saved periodically.")
+		t.Fail()
+	}
+
+	c.Set(key+"1", value)
+
+	if err := c.Close(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	restored, err = NewFromFile(path)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if _, ok := restored.Get(key + "1"); !ok {
+		t.Error("Element should have been saved on close.")
+		t.Fail()
+	}
+}
+
+func TestWithAutoSnapshotWithoutInterval(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	c := New(WithAutoSnapshot(path, 0))
+
+	c.Set(key, value)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Snapshot should not have been saved before Close.")
+		t.Fail()
+	}
+
+	if err := c.Close(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	restored, err := NewFromFile(path)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if _, ok := restored.Get(key); !ok {
+		t.Error("Element should have been saved on close.")
+		t.Fail()
+	}
+}
+
+func TestCompressedSnapshot(t *testing.T) {
+	key := "testKey"
+	value := `{"name": "testValue", "tags": ["a", "b", "c"], "count": 42}`
+
+	c := New(WithSnapshotCompression(Gzip))
+
+	for i := 0; i < 1000; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	var compressed bytes.Buffer
+	if err := c.SaveSnapshot(&compressed); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	var plain bytes.Buffer
+	c.compression = NoCompression
+	c.SaveSnapshot(&plain)
+
+	if compressed.Len()*5 > plain.Len() {
+		t.Errorf("Expected compressed snapshot to be smaller than %d bytes. Got %d bytes", plain.Len()/5, compressed.Len())
+		t.Fail()
+	}
+
+	for _, opts := range [][]Option{nil, {WithEncryptionKey(testKey)}} {
+		c = New(append(opts, WithSnapshotCompression(Gzip))...)
+		c.Set(key, value)
+
+		var buf bytes.Buffer
+		if err := c.SaveSnapshot(&buf); err != nil {
+			t.Error("Unexpected error", err)
+			t.Fail()
+		}
+
+		c = New(opts...)
+		if err := c.LoadSnapshot(&buf); err != nil {
+			t.Error("Unexpected error", err)
+			t.Fail()
+		}
+
+		if v, ok := c.Get(key); !ok || v != value {
+			t.Error("Expected", value, "got", v)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Store is a backing store changes to the cache are written through to.
+// Values are only stored in or removed from the cache after the Store
+// succeeded, failures are passed to the error handler of the cache.
+type Store interface {
+	// Set persists the value with the given key. A ttl of zero or less
+	// means the value does not expire.
+	Set(key string, value interface{}, ttl time.Duration) error
+	// Remove deletes the value with the given key.
+	Remove(key string) error
+}
+
+// ContextStore is a Store whose operations can be cancelled. Writes through
+// SetCtx, SetWithTTLCtx and RemoveCtx pass their context to SetCtx and
+// RemoveCtx instead of calling Set and Remove if the Store implements it.
+type ContextStore interface {
+	Store
+	// SetCtx persists the value with the given key like Set.
+	SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// RemoveCtx deletes the value with the given key like Remove.
+	RemoveCtx(ctx context.Context, key string) error
+}
+
+// StoreError describes a failed Store operation.
+type StoreError struct {
+	Op  string
+	Key string
+	Err error
+}
+
+func (e *StoreError) Error() string {
+	return fmt.Sprintf("cache: store %s %q: %v", e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// writeThrough sets the value in the Store of the cache, if there is one, and
+// reports whether the value should be stored in the cache as well.
+func (c *Cache) writeThrough(key string, value interface{}, ttl time.Duration) bool {
+	return c.storeSet(key, value, ttl) == nil
+}
+
+// storeSet sets the value in the Store of the cache like writeThrough, but
+// returns the error passed to the error handler of the cache.
+func (c *Cache) storeSet(key string, value interface{}, ttl time.Duration) error {
+	return c.storeSetCtx(context.Background(), key, value, ttl)
+}
+
+// storeSetCtx sets the value in the Store of the cache like storeSet, passing
+// ctx to a ContextStore.
+func (c *Cache) storeSetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c.store == nil {
+		return nil
+	}
+
+	if c.writer != nil {
+		c.writer.enqueue(Op{Key: key, Value: value, TTL: ttl})
+		return nil
+	}
+
+	var err error
+	if cs, ok := c.store.(ContextStore); ok {
+		err = cs.SetCtx(ctx, key, value, ttl)
+	} else {
+		err = c.store.Set(key, value, ttl)
+	}
+	if err != nil {
+		err := &StoreError{Op: "set", Key: key, Err: err}
+		c.handleError(err)
+		return err
+	}
+
+	return nil
+}
+
+// removeThrough removes the value from the Store of the cache, if there is
+// one, and reports whether it should be removed from the cache as well.
+func (c *Cache) removeThrough(key string) bool {
+	return c.storeRemove(key) == nil
+}
+
+// storeRemove removes the value from the Store of the cache like
+// removeThrough, but returns the error passed to the error handler of the
+// cache.
+func (c *Cache) storeRemove(key string) error {
+	return c.storeRemoveCtx(context.Background(), key)
+}
+
+// storeRemoveCtx removes the value from the Store of the cache like
+// storeRemove, passing ctx to a ContextStore.
+func (c *Cache) storeRemoveCtx(ctx context.Context, key string) error {
+	if c.store == nil {
+		return nil
+	}
+
+	if c.writer != nil {
+		c.writer.enqueue(Op{Key: key, Remove: true})
+		return nil
+	}
+
+	var err error
+	if cs, ok := c.store.(ContextStore); ok {
+		err = cs.RemoveCtx(ctx, key)
+	} else {
+		err = c.store.Remove(key)
+	}
+	if err != nil {
+		err := &StoreError{Op: "remove", Key: key, Err: err}
+		c.handleError(err)
+		return err
+	}
+
+	return nil
+}
+
+// handleError passes err to the error handler of the cache, if there is one,
+// and logs it.
+func (c *Cache) handleError(err error) {
+	c.logf(slog.LevelError, "cache error", "error", err)
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testStore struct {
+	values map[string]interface{}
+	err    error
+	sync.Mutex
+}
+
+func newTestStore() *testStore {
+	return &testStore{values: make(map[string]interface{})}
+}
+
+func (s *testStore) Set(key string, value interface{}, ttl time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = value
+	return nil
+}
+
+func (s *testStore) Remove(key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.values, key)
+	return nil
+}
+
+func (s *testStore) get(key string) (interface{}, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func TestWriteThrough(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+
+	c := New(WithStore(store))
+
+	c.Set(key, value)
+
+	if v, ok := store.get(key); !ok || v != value {
+		t.Error("Element should have been written to the store.")
+		t.Fail()
+	}
+
+	c.Remove(key)
+
+	if _, ok := store.get(key); ok {
+		t.Error("Element should have been removed from the store.")
+		t.Fail()
+	}
+}
+
+func TestWriteThroughErrors(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+	storeErr := errors.New("store failed")
+
+	var errs []error
+	c := New(WithStore(store), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	c.Set(key, value)
+
+	store.err = storeErr
+
+	c.Set(key, "otherValue")
+	c.Remove(key)
+
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 errors. Got %d", len(errs))
+		t.Fail()
+	}
+
+	for _, err := range errs {
+		if !errors.Is(err, storeErr) {
+			t.Error("Expected", storeErr, "got", err)
+			t.Fail()
+		}
+	}
+
+	if v, ok := c.Get(key); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestGetAndDeleteStoreError(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+
+	c := New(WithStore(store))
+	c.Set(key, value)
+
+	store.err = errors.New("store failed")
+
+	if v, ok := c.GetAndDelete(key); ok {
+		t.Error("Expected no value if the store fails, got", v)
+		t.Fail()
+	}
+
+	if !c.Has(key) {
+		t.Error("Value should have been kept.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tagIndex maps tags to the keys of the entries stored with them.
+type tagIndex struct {
+	sync.Mutex
+	keys map[string]map[string]struct{}
+	tags map[string][]string
+	// tagged is the number of keys with tags, so untagging is skipped while
+	// tags are not used
+	tagged atomic.Int64
+}
+
+func newTagIndex() *tagIndex {
+	return &tagIndex{
+		keys: make(map[string]map[string]struct{}),
+		tags: make(map[string][]string),
+	}
+}
+
+// SetWithTags stores the value with the given key like Set and tags the
+// entry, so it can be removed with InvalidateTag together with all other
+// entries with one of the tags, e.g. all values derived from a record. Tags
+// are dropped when the entry is removed or a value is stored with the key
+// again without them. They are not included in snapshots and the
+// append-only log.
+func (c *Cache) SetWithTags(key string, value interface{}, tags ...string) {
+	c.SetWithTTLAndTags(key, value, c.defaultTTL, tags...)
+}
+
+// SetWithTTLAndTags stores the value with the given key like SetWithTTL and
+// tags the entry like SetWithTags.
+func (c *Cache) SetWithTTLAndTags(key string, value interface{}, ttl time.Duration, tags ...string) {
+	defer c.invalidateOthers(key)
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	if !c.writeThrough(key, value, ttl) {
+		return
+	}
+
+	c.set(s, key, value, ttl)
+	c.tags.tag(key, tags)
+}
+
+// InvalidateTag removes all entries tagged with tag like RemoveMulti and
+// returns how many were removed.
+func (c *Cache) InvalidateTag(tag string) int {
+	return c.RemoveMulti(c.tags.take(tag))
+}
+
+// Tags returns the tags of the entry stored with the given key.
+func (c *Cache) Tags(key string) []string {
+	c.tags.Lock()
+	defer c.tags.Unlock()
+
+	return append([]string(nil), c.tags.tags[key]...)
+}
+
+// tag adds the key to the keys of the tags.
+func (t *tagIndex) tag(key string, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	for _, tag := range tags {
+		keys, ok := t.keys[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			t.keys[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	t.tags[key] = append([]string(nil), tags...)
+	t.tagged.Store(int64(len(t.tags)))
+}
+
+// untag removes the key from the keys of its tags. It is called with the
+// shard of the key locked whenever the entry is modified.
+func (t *tagIndex) untag(key string) {
+	if t.tagged.Load() == 0 {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	for _, tag := range t.tags[key] {
+		delete(t.keys[tag], key)
+		if len(t.keys[tag]) == 0 {
+			delete(t.keys, tag)
+		}
+	}
+	delete(t.tags, key)
+	t.tagged.Store(int64(len(t.tags)))
+}
+
+// take returns the keys tagged with tag.
+func (t *tagIndex) take(tag string) []string {
+	t.Lock()
+	defer t.Unlock()
+
+	keys := make([]string, 0, len(t.keys[tag]))
+	for k := range t.keys[tag] {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
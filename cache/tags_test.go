@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	value := "testValue"
+
+	c := New()
+	c.SetWithTags("user:42:profile", value, "user:42")
+	c.SetWithTags("user:42:posts", value, "user:42", "posts")
+	c.SetWithTags("user:7:posts", value, "user:7", "posts")
+
+	if tags := c.Tags("user:42:posts"); !reflect.DeepEqual(tags, []string{"user:42", "posts"}) {
+		t.Error("Expected [user:42 posts] got", tags)
+		t.Fail()
+	}
+
+	if n := c.InvalidateTag("user:42"); n != 2 {
+		t.Errorf("Expected 2 removed entries. Got %d", n)
+		t.Fail()
+	}
+
+	if c.Has("user:42:profile") || c.Has("user:42:posts") || !c.Has("user:7:posts") {
+		t.Error("Only tagged entries should have been removed.")
+		t.Fail()
+	}
+
+	if n := c.InvalidateTag("posts"); n != 1 {
+		t.Errorf("Expected 1 removed entry. Got %d", n)
+		t.Fail()
+	}
+}
+
+func TestTagsDropped(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.SetWithTags(key, value, "tag")
+	c.Set(key, value)
+
+	if n := c.InvalidateTag("tag"); n != 0 {
+		t.Errorf("Expected tags to be dropped by Set. Got %d removed entries", n)
+		t.Fail()
+	}
+
+	c.SetWithTags(key, value, "tag")
+	c.Remove(key)
+	c.Set(key, value)
+
+	if n := c.InvalidateTag("tag"); n != 0 {
+		t.Errorf("Expected tags to be dropped by Remove. Got %d removed entries", n)
+		t.Fail()
+	}
+
+	if n := len(c.tags.keys); n != 0 {
+		t.Errorf("Expected an empty index. Got %d tags", n)
+		t.Fail()
+	}
+}
@@ -0,0 +1,76 @@
+package cache
+
+import "time"
+
+// GetWithVersion returns the value stored with the given key like Get along
+// with the version of the entry, which changes whenever a value is stored
+// with the key. The version can be passed to CompareAndSwap. The Loader of
+// the cache is not invoked for missing keys.
+func (c *Cache) GetWithVersion(key string) (value interface{}, version uint64, ok bool) {
+	s := c.rlockShard(key)
+	defer s.RUnlock()
+
+	if s.hot != nil {
+		s.hot.add(key)
+	}
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() {
+		s.stats.countMiss()
+		return nil, 0, false
+	}
+
+	c.refreshIfDue(key, e)
+	e.renew()
+	s.stats.countHit()
+
+	return e.value, e.version, true
+}
+
+// CompareAndSwap stores the value with the given key only if the entry
+// still has the given version, i.e. no other value was stored with the key
+// since the version was retrieved with GetWithVersion. The ttl of the entry
+// is kept. It reports whether the value was stored.
+func (c *Cache) CompareAndSwap(key string, value interface{}, version uint64) (swapped bool) {
+	defer func() {
+		if swapped {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() || e.version != version {
+		return false
+	}
+
+	return c.replace(s, key, e, value) == nil
+}
+
+// CompareAndSwapWithTTL stores the value with the given key like
+// CompareAndSwap, but replaces the ttl of the entry like SetWithTTL. Checking
+// the version and storing the value and ttl happen atomically.
+func (c *Cache) CompareAndSwapWithTTL(key string, value interface{}, version uint64, ttl time.Duration) (swapped bool) {
+	defer func() {
+		if swapped {
+			c.invalidateOthers(key)
+		}
+	}()
+
+	s := c.lockShard(key)
+	defer s.Unlock()
+
+	e, ok := s.Entries[key]
+	if !ok || e.expired() || e.version != version {
+		return false
+	}
+
+	if !c.writeThrough(key, value, ttl) {
+		return false
+	}
+	c.set(s, key, value, ttl)
+
+	return true
+}
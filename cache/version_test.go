@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	if _, _, ok := c.GetWithVersion(key); ok {
+		t.Error("Expected no value.")
+		t.Fail()
+	}
+
+	c.Set(key, value)
+	v, version, ok := c.GetWithVersion(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if !c.CompareAndSwap(key, "otherValue", version) {
+		t.Error("Value should have been swapped.")
+		t.Fail()
+	}
+
+	if c.CompareAndSwap(key, "thirdValue", version) {
+		t.Error("Value should not have been swapped with a stale version.")
+		t.Fail()
+	}
+
+	if v, _ := c.Get(key); v != "otherValue" {
+		t.Error("Expected otherValue got", v)
+		t.Fail()
+	}
+
+	_, version, _ = c.GetWithVersion(key)
+	c.Remove(key)
+	c.Set(key, value)
+	if c.CompareAndSwap(key, "otherValue", version) {
+		t.Error("Value should not have been swapped after the key was replaced.")
+		t.Fail()
+	}
+}
+
+func TestCompareAndSwapWithTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+	_, version, _ := c.GetWithVersion(key)
+
+	if !c.CompareAndSwapWithTTL(key, "otherValue", version, time.Hour) {
+		t.Error("Value should have been swapped.")
+		t.Fail()
+	}
+
+	if ttl, _ := c.TTL(key); ttl <= 59*time.Minute {
+		t.Error("Expected the new ttl. Got", ttl)
+		t.Fail()
+	}
+
+	if c.CompareAndSwapWithTTL(key, "thirdValue", version, time.Hour) {
+		t.Error("Value should not have been swapped with a stale version.")
+		t.Fail()
+	}
+}
+
+func TestCompareAndSwapParallel(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+	c.Set(key, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for {
+					v, version, _ := c.GetWithVersion(key)
+					if c.CompareAndSwap(key, v.(int)+1, version) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, _ := c.Get(key); v != 1000 {
+		t.Error("Expected 1000 got", v)
+		t.Fail()
+	}
+}
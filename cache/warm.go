@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a key/value pair to warm a cache with. A ttl of zero stores the
+// value with the default ttl of the cache, NoExpiration without expiry.
+type Record struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration
+}
+
+// Source provides the records to warm a cache with. Next returns io.EOF once
+// all records have been read.
+type Source interface {
+	Next() (Record, error)
+}
+
+// SourceFunc is an adapter to use ordinary functions as Source.
+type SourceFunc func() (Record, error)
+
+// Next calls f().
+func (f SourceFunc) Next() (Record, error) {
+	return f()
+}
+
+// WarmOption configures a call of Warm.
+type WarmOption func(*warmer)
+
+// WithConcurrency sets the number of go routines storing records during Warm,
+// which defaults to one.
+func WithConcurrency(n int) WarmOption {
+	return func(w *warmer) {
+		if n > 0 {
+			w.concurrency = n
+		}
+	}
+}
+
+// WithProgress makes Warm call fn with the number of records stored so far
+// after every interval records and once it is done.
+func WithProgress(interval int, fn func(stored int)) WarmOption {
+	return func(w *warmer) {
+		w.progressInterval = interval
+		w.progress = fn
+	}
+}
+
+type warmer struct {
+	concurrency      int
+	progressInterval int
+	progress         func(int)
+
+	stored int
+	sync.Mutex
+}
+
+// done counts a stored record and reports the progress if it is due.
+func (w *warmer) done() {
+	w.Lock()
+	defer w.Unlock()
+
+	w.stored++
+	if w.progress != nil && w.progressInterval > 0 && w.stored%w.progressInterval == 0 {
+		w.progress(w.stored)
+	}
+}
+
+// Warm bulk-loads the records of source into the cache, e.g. at startup. It
+// stops at the first error of source or once ctx is done and returns the
+// number of records stored until then. Like loaded values, warmed values are
+// not written through to the Store.
+func (c *Cache) Warm(ctx context.Context, source Source, opts ...WarmOption) (int, error) {
+	w := &warmer{concurrency: 1}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	records := make(chan Record, w.concurrency)
+
+	var wg sync.WaitGroup
+	wg.Add(w.concurrency)
+	for i := 0; i < w.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+
+			for r := range records {
+				ttl := r.TTL
+				if ttl == 0 {
+					ttl = c.defaultTTL
+				}
+
+				s := c.lockShard(r.Key)
+				c.set(s, r.Key, r.Value, ttl)
+				s.Unlock()
+
+				w.done()
+			}
+		}()
+	}
+
+	var err error
+	for err == nil {
+		var r Record
+		r, err = source.Next()
+		if err != nil {
+			break
+		}
+
+		select {
+		case records <- r:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	close(records)
+	wg.Wait()
+
+	if w.progress != nil {
+		w.progress(w.stored)
+	}
+
+	if err == io.EOF {
+		err = nil
+	}
+
+	return w.stored, err
+}
+
+// WarmFromFile warms the cache with the records of a seed file. Files with
+// the extension .csv are read with CSVSource, all others with JSONSource.
+func (c *Cache) WarmFromFile(ctx context.Context, path string, opts ...WarmOption) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var source Source
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		source = CSVSource(f)
+	} else {
+		source = JSONSource(f)
+	}
+
+	return c.Warm(ctx, source, opts...)
+}
+
+// JSONSource reads records from a JSON array in the format written by
+// ExportJSON. Values are decoded into the generic types of encoding/json.
+func JSONSource(r io.Reader) Source {
+	dec := json.NewDecoder(r)
+	started := false
+
+	return SourceFunc(func() (Record, error) {
+		if !started {
+			t, err := dec.Token()
+			if err != nil {
+				return Record{}, err
+			}
+			if t != json.Delim('[') {
+				return Record{}, fmt.Errorf("cache: expected JSON array, got %v", t)
+			}
+			started = true
+		}
+
+		if !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return Record{}, err
+			}
+			return Record{}, io.EOF
+		}
+
+		var je jsonEntry
+		if err := dec.Decode(&je); err != nil {
+			return Record{}, err
+		}
+
+		ttl, err := parseTTL(je.Key, je.TTL)
+		if err != nil {
+			return Record{}, err
+		}
+
+		return Record{Key: je.Key, Value: je.Value, TTL: ttl}, nil
+	})
+}
+
+// CSVSource reads records from CSV rows of a key, a string value and an
+// optional ttl in the format of time.ParseDuration.
+func CSVSource(r io.Reader) Source {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	return SourceFunc(func() (Record, error) {
+		row, err := cr.Read()
+		if err != nil {
+			return Record{}, err
+		}
+
+		if len(row) < 2 || len(row) > 3 {
+			line, _ := cr.FieldPos(0)
+			return Record{}, fmt.Errorf("cache: line %d: expected key, value and optional ttl", line)
+		}
+
+		rec := Record{Key: row[0], Value: row[1]}
+		if len(row) == 3 {
+			if rec.TTL, err = parseTTL(row[0], row[2]); err != nil {
+				return Record{}, err
+			}
+		}
+
+		return rec, nil
+	})
+}
+
+// parseTTL parses the ttl of a seed record, an empty ttl is zero.
+func parseTTL(key, ttl string) (time.Duration, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, fmt.Errorf("cache: invalid ttl of key %q: %v", key, err)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWarm(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+
+	i := 0
+	source := SourceFunc(func() (Record, error) {
+		if i == 1000 {
+			return Record{}, io.EOF
+		}
+		i++
+		return Record{Key: key + strconv.Itoa(i), Value: value}, nil
+	})
+
+	var progress []int
+	n, err := c.Warm(context.Background(), source, WithConcurrency(4), WithProgress(250, func(stored int) {
+		progress = append(progress, stored)
+	}))
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if n != 1000 {
+		t.Errorf("Expected 1000 records to be stored. Got %d", n)
+		t.Fail()
+	}
+
+	if len(progress) != 5 || progress[0] != 250 || progress[4] != 1000 {
+		t.Error("Unexpected progress", progress)
+		t.Fail()
+	}
+
+	if v, ok := c.Get(key + "42"); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestWarmError(t *testing.T) {
+	sourceErr := errors.New("read failed")
+
+	c := New()
+
+	n, err := c.Warm(context.Background(), SourceFunc(func() (Record, error) {
+		return Record{}, sourceErr
+	}))
+	if err != sourceErr || n != 0 {
+		t.Error("Expected", sourceErr, "got", err)
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the source never ends, only the context stops warming
+	_, err = c.Warm(ctx, SourceFunc(func() (Record, error) {
+		return Record{Key: "testKey", Value: "testValue"}, nil
+	}))
+	if err != context.Canceled {
+		t.Error("Expected", context.Canceled, "got", err)
+		t.Fail()
+	}
+}
+
+func TestWarmFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "seed.csv")
+	os.WriteFile(csvPath, []byte("a,testValue\nb,testValue,1m\n"), 0o600)
+
+	c := New()
+
+	n, err := c.WarmFromFile(context.Background(), csvPath)
+	if err != nil || n != 2 {
+		t.Errorf("Expected 2 records to be stored. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if e := c.getShard("b").Entries["b"]; e == nil || e.ttl != time.Minute {
+		t.Error("Ttl should have been set.")
+		t.Fail()
+	}
+
+	src := New()
+	src.SetWithTTL("c", "testValue", time.Minute)
+	src.Set("d", 42)
+
+	jsonPath := filepath.Join(dir, "seed.json")
+	f, _ := os.Create(jsonPath)
+	src.ExportJSON(f)
+	f.Close()
+
+	n, err = c.WarmFromFile(context.Background(), jsonPath)
+	if err != nil || n != 2 {
+		t.Errorf("Expected 2 records to be stored. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if v, ok := c.Get("d"); !ok || v != float64(42) {
+		t.Error("Expected", 42, "got", v)
+		t.Fail()
+	}
+
+	if _, err := c.Warm(context.Background(), CSVSource(strings.NewReader("a\n"))); err == nil {
+		t.Error("Expected error for row without value.")
+		t.Fail()
+	}
+}
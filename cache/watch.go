@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"strings"
+)
+
+// WatchEvent reports a change of a watched key.
+type WatchEvent struct {
+	Type EventType
+	// Value is the new value of EventSet, the removed value otherwise
+	Value interface{}
+	// Dropped is the number of events dropped before this one because the
+	// watcher fell behind
+	Dropped int
+}
+
+// Deleted reports whether the key was removed, expired or evicted.
+func (e WatchEvent) Deleted() bool {
+	return e.Type != EventSet
+}
+
+// Watch returns a channel receiving the updates and deletions of a single key
+// until the context is cancelled or the cache is closed, when the channel is
+// closed. Events are buffered like those of Subscribe.
+func (c *Cache) Watch(ctx context.Context, key string) <-chan WatchEvent {
+	events := c.Subscribe(escapeGlob(key))
+	watch := make(chan WatchEvent)
+
+	go func() {
+		defer close(watch)
+		defer c.Unsubscribe(events)
+
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case watch <- WatchEvent{Type: ev.Type, Value: ev.Value, Dropped: ev.Dropped}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return watch
+}
+
+// escapeGlob returns a pattern matching only the given key.
+func escapeGlob(key string) string {
+	if !strings.ContainsAny(key, `*?[]\`) {
+		return key
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
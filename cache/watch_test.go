@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	key := "test*Key"
+	value := "testValue"
+
+	c := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := c.Watch(ctx, key)
+
+	c.Set("testOtherKey", value)
+	c.Set(key, value)
+	c.Remove(key)
+
+	expected := []WatchEvent{
+		{Type: EventSet, Value: value},
+		{Type: EventRemove, Value: value},
+	}
+
+	for _, exp := range expected {
+		select {
+		case ev := <-watch:
+			if ev != exp {
+				t.Error("Expected", exp, "got", ev)
+				t.Fail()
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected", exp, "got nothing")
+			t.Fail()
+		}
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-watch:
+		if ok {
+			t.Error("Channel should have been closed.")
+			t.Fail()
+		}
+	case <-time.After(time.Second):
+		t.Error("Channel should have been closed after cancelling.")
+		t.Fail()
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	for _, key := range []string{"testKey", "a*b", `[x]?\`} {
+		if !matchGlob(escapeGlob(key), key) {
+			t.Errorf("Escaped %q should match itself", key)
+			t.Fail()
+		}
+	}
+
+	if matchGlob(escapeGlob("a*"), "ab") {
+		t.Error("Escaped pattern should only match the key.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// statsWindows are the windows reported in Stats.Windows.
+var statsWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// windowSampleInterval is the interval hits and misses are sampled at for
+// windowed stats.
+const windowSampleInterval = 5 * time.Second
+
+// WindowStats are the hits and misses counted during a window of time.
+type WindowStats struct {
+	Window   time.Duration `json:"window"`
+	Hits     int           `json:"hits"`
+	Misses   int           `json:"misses"`
+	HitRatio float64       `json:"hit_ratio"`
+}
+
+// sample is the number of hits and misses counted until a point in time.
+type sample struct {
+	at     time.Time
+	hits   int
+	misses int
+}
+
+// windows samples the hits and misses of a cache periodically to compute
+// windowed stats.
+type windows struct {
+	// samples is a ring buffer holding the samples of the longest window
+	samples []sample
+	next    int
+	n       int
+	stop    chan struct{}
+	done    chan struct{}
+	sync.Mutex
+}
+
+func newWindows() *windows {
+	longest := statsWindows[len(statsWindows)-1]
+	return &windows{
+		samples: make([]sample, int(longest/windowSampleInterval)+1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// start records the first sample and starts sampling in the background.
+func (w *windows) start(c *Cache) {
+	hits, misses := c.hitsAndMisses()
+	w.record(time.Now(), hits, misses)
+
+	go w.run(c)
+}
+
+func (w *windows) run(c *Cache) {
+	defer close(w.done)
+
+	t := time.NewTicker(windowSampleInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case now := <-t.C:
+			hits, misses := c.hitsAndMisses()
+			w.record(now, hits, misses)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// record adds a sample, replacing the oldest one if the buffer is full.
+func (w *windows) record(at time.Time, hits, misses int) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.samples[w.next] = sample{at: at, hits: hits, misses: misses}
+	w.next = (w.next + 1) % len(w.samples)
+	if w.n < len(w.samples) {
+		w.n++
+	}
+}
+
+// reset drops all samples and records a sample without hits and misses.
+func (w *windows) reset(at time.Time) {
+	w.Lock()
+	w.next = 0
+	w.n = 0
+	w.Unlock()
+
+	w.record(at, 0, 0)
+}
+
+// stats returns the hits and misses of each window given the current
+// counts. Windows longer than the time sampled so far cover the time since
+// the first sample.
+func (w *windows) stats(now time.Time, hits, misses int) []WindowStats {
+	w.Lock()
+	defer w.Unlock()
+
+	stats := make([]WindowStats, len(statsWindows))
+	for i, window := range statsWindows {
+		stats[i].Window = window
+		if w.n == 0 {
+			continue
+		}
+
+		// find the oldest sample within the window, allowing for the
+		// sampling to be late by half an interval
+		start := w.samples[(w.next-1+len(w.samples))%len(w.samples)]
+		for j := 2; j <= w.n; j++ {
+			s := w.samples[(w.next-j+len(w.samples))%len(w.samples)]
+			if now.Sub(s.at) > window+windowSampleInterval/2 {
+				break
+			}
+			start = s
+		}
+
+		stats[i].Hits = hits - start.hits
+		stats[i].Misses = misses - start.misses
+		if n := stats[i].Hits + stats[i].Misses; n > 0 {
+			stats[i].HitRatio = float64(stats[i].Hits) / float64(n)
+		}
+	}
+
+	return stats
+}
+
+// close stops the sampling.
+func (w *windows) close() {
+	close(w.stop)
+	<-w.done
+}
+
+// hitsAndMisses returns the hits and misses of all shards.
+func (c *Cache) hitsAndMisses() (hits, misses int) {
+	c.resizing.RLock()
+	defer c.resizing.RUnlock()
+
+	for i := 0; i < c.len(); i++ {
+		if s := c.shard(i).stats; s != nil {
+			hits += int(s.hits.Load())
+			misses += int(s.misses.Load())
+		}
+	}
+	return hits, misses
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindows(t *testing.T) {
+	w := newWindows()
+	start := time.Now()
+
+	// 20 hits and 0 misses per sample interval for 10 minutes, then 10
+	// hits and 10 misses per interval for 5 minutes
+	hits, misses := 0, 0
+	for at := start; at.Before(start.Add(15 * time.Minute)); at = at.Add(windowSampleInterval) {
+		w.record(at, hits, misses)
+		if at.Sub(start) < 10*time.Minute {
+			hits += 20
+		} else {
+			hits += 10
+			misses += 10
+		}
+	}
+
+	stats := w.stats(start.Add(15*time.Minute), hits, misses)
+	if len(stats) != 3 {
+		t.Errorf("Expected 3 windows. Got %d", len(stats))
+		t.FailNow()
+	}
+
+	for i, ratio := range []float64{0.5, 0.5, 5.0 / 6} {
+		if stats[i].HitRatio != ratio {
+			t.Errorf("Expected a hit ratio of %f for %s. Got %f", ratio, stats[i].Window, stats[i].HitRatio)
+			t.Fail()
+		}
+	}
+
+	if stats[0].Hits != 120 || stats[0].Misses != 120 {
+		t.Errorf("Expected 120 hits and misses in the last minute. Got %d and %d", stats[0].Hits, stats[0].Misses)
+		t.Fail()
+	}
+}
+
+func TestWithWindowedStats(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New(WithWindowedStats())
+	defer c.Close()
+
+	c.Set(key, value)
+	c.Get(key)
+	c.Get("testOtherKey")
+
+	s := c.GetStats()
+	if len(s.Windows) != 3 {
+		t.Errorf("Expected 3 windows. Got %d", len(s.Windows))
+		t.FailNow()
+	}
+
+	if s.Windows[0].Window != time.Minute || s.Windows[0].HitRatio != 0.5 {
+		t.Errorf("Unexpected stats of the last minute %+v", s.Windows[0])
+		t.Fail()
+	}
+
+	if New().GetStats().Windows != nil {
+		t.Error("Windows should only be reported if enabled.")
+		t.Fail()
+	}
+}
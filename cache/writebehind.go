@@ -0,0 +1,161 @@
+package cache
+
+import "time"
+
+// Op is a pending write to a Store.
+type Op struct {
+	Key    string
+	Value  interface{}
+	TTL    time.Duration
+	Remove bool
+}
+
+// BatchStore is a Store that can apply several operations at once. The
+// write-behind buffer uses Apply instead of single Set and Remove calls if the
+// Store implements it.
+type BatchStore interface {
+	Store
+	// Apply performs the operations in order.
+	Apply(ops []Op) error
+}
+
+// writer buffers writes to a Store and flushes them in batches.
+type writer struct {
+	store     Store
+	onError   func(error)
+	interval  time.Duration
+	batchSize int
+
+	ops   chan Op
+	flush chan chan struct{}
+	done  chan struct{}
+}
+
+func newWriter(bufferSize int, interval time.Duration, batchSize int) *writer {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	return &writer{
+		interval:  interval,
+		batchSize: batchSize,
+		ops:       make(chan Op, bufferSize),
+		flush:     make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (w *writer) start(store Store, onError func(error)) {
+	w.store = store
+	w.onError = onError
+
+	go w.run()
+}
+
+// enqueue adds op to the buffer. It blocks while the buffer is full.
+func (w *writer) enqueue(op Op) {
+	w.ops <- op
+}
+
+func (w *writer) run() {
+	// without interval batches are only written once full or flushed
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		t := time.NewTicker(w.interval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	batch := make([]Op, 0, w.batchSize)
+
+	for {
+		select {
+		case op, ok := <-w.ops:
+			if !ok {
+				w.write(batch)
+				close(w.done)
+				return
+			}
+
+			batch = append(batch, op)
+			if len(batch) >= w.batchSize {
+				w.write(batch)
+				batch = batch[:0]
+			}
+		case <-tick:
+			w.write(batch)
+			batch = batch[:0]
+		case ack := <-w.flush:
+			batch = w.drain(batch)
+			w.write(batch)
+			batch = batch[:0]
+			close(ack)
+		}
+	}
+}
+
+// drain appends all buffered operations to batch, writing full batches on
+// the way.
+func (w *writer) drain(batch []Op) []Op {
+	for {
+		select {
+		case op, ok := <-w.ops:
+			if !ok {
+				return batch
+			}
+
+			batch = append(batch, op)
+			if len(batch) >= w.batchSize {
+				w.write(batch)
+				batch = batch[:0]
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+func (w *writer) write(batch []Op) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if bs, ok := w.store.(BatchStore); ok {
+		if err := bs.Apply(batch); err != nil {
+			w.onError(&StoreError{Op: "apply", Err: err})
+		}
+		return
+	}
+
+	for _, op := range batch {
+		if op.Remove {
+			if err := w.store.Remove(op.Key); err != nil {
+				w.onError(&StoreError{Op: "remove", Key: op.Key, Err: err})
+			}
+			continue
+		}
+
+		if err := w.store.Set(op.Key, op.Value, op.TTL); err != nil {
+			w.onError(&StoreError{Op: "set", Key: op.Key, Err: err})
+		}
+	}
+}
+
+// close flushes all buffered operations and stops the writer.
+func (w *writer) close() {
+	close(w.ops)
+	<-w.done
+}
+
+// Flush writes all operations buffered by the write-behind mode to the Store
+// and returns once they have been applied. Without write-behind mode Flush
+// does nothing.
+func (c *Cache) Flush() {
+	if c.writer == nil {
+		return
+	}
+
+	ack := make(chan struct{})
+	c.writer.flush <- ack
+	<-ack
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testBatchStore struct {
+	*testStore
+	batches [][]Op
+	mu      sync.Mutex
+}
+
+func (s *testBatchStore) Apply(ops []Op) error {
+	s.mu.Lock()
+	s.batches = append(s.batches, append([]Op(nil), ops...))
+	s.mu.Unlock()
+
+	for _, op := range ops {
+		if op.Remove {
+			s.Remove(op.Key)
+			continue
+		}
+		s.Set(op.Key, op.Value, op.TTL)
+	}
+	return nil
+}
+
+func TestWriteBehind(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+
+	c := New(WithStore(store), WithWriteBehind(100, time.Hour, 100))
+
+	c.Set(key, value)
+
+	if _, ok := store.get(key); ok {
+		t.Error("Element should not have been written yet.")
+		t.Fail()
+	}
+
+	if v, ok := c.Get(key); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	c.Flush()
+
+	if v, ok := store.get(key); !ok || v != value {
+		t.Error("Element should have been written to the store.")
+		t.Fail()
+	}
+
+	c.Remove(key)
+	c.Close()
+
+	if _, ok := store.get(key); ok {
+		t.Error("Element should have been removed from the store on close.")
+		t.Fail()
+	}
+}
+
+func TestWriteBehindInterval(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+
+	c := New(WithStore(store), WithWriteBehind(100, 10*time.Millisecond, 100))
+	defer c.Close()
+
+	c.Set(key, value)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := store.get(key); !ok {
+		t.Error("Element should have been written to the store.")
+		t.Fail()
+	}
+}
+
+func TestWriteBehindBatches(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := &testBatchStore{testStore: newTestStore()}
+
+	c := New(WithStore(store), WithWriteBehind(100, time.Hour, 10))
+
+	for i := 0; i < 25; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	c.Close()
+
+	if len(store.batches) != 3 {
+		t.Errorf("Expected 3 batches. Got %d", len(store.batches))
+		t.Fail()
+	}
+
+	for i := 0; i < 25; i++ {
+		if _, ok := store.get(key + strconv.Itoa(i)); !ok {
+			t.Error("Element should have been written to the store.")
+			t.Fail()
+		}
+	}
+}
+
+func TestWriteBehindWithoutInterval(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	store := newTestStore()
+
+	c := New(WithStore(store), WithWriteBehind(100, 0, 10))
+
+	c.Set(key, value)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := store.get(key); ok {
+		t.Error("Element should not have been written before the batch is full.")
+		t.Fail()
+	}
+
+	c.Close()
+
+	if _, ok := store.get(key); !ok {
+		t.Error("Element should have been written to the store on close.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"encoding/gob"
+	"sort"
+)
+
+// ZMember is a member of a SortedSet with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// SortedSet is the value stored by the sorted set operations ZAdd and
+// ZIncrBy. Its members are ordered by score, members with the same score by
+// name. ZAdd and ZIncrBy copy the members (see modify), so they cost O(n)
+// in the size of the set.
+type SortedSet []ZMember
+
+func init() {
+	gob.Register(SortedSet{})
+}
+
+// less reports whether a is ordered before b.
+func (a ZMember) less(b ZMember) bool {
+	return a.Score < b.Score || a.Score == b.Score && a.Member < b.Member
+}
+
+// ZAdd adds the members to the sorted set stored with the given key, or
+// updates their scores if they are in the set already, and returns how many
+// of them were not in the set before. Missing keys are stored as new sorted
+// sets with the default ttl of the cache. If the value stored with the key is
+// not a SortedSet ErrWrongType is returned.
+func (c *Cache) ZAdd(key string, members ...ZMember) (n int, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		z, err := asSortedSet(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range members {
+			var existed bool
+			z, existed = z.with(m)
+			if !existed {
+				n++
+			}
+		}
+
+		return z, nil
+	})
+
+	return n, err
+}
+
+// ZIncrBy adds delta to the score of a member of the sorted set stored with
+// the given key and returns the new score. Missing members are added with a
+// score of delta.
+func (c *Cache) ZIncrBy(key string, member string, delta float64) (score float64, err error) {
+	err = c.modify(key, func(v interface{}, ok bool) (interface{}, error) {
+		z, err := asSortedSet(v, ok)
+		if err != nil {
+			return nil, err
+		}
+
+		score = delta
+		if i := z.index(member); i >= 0 {
+			score += z[i].Score
+		}
+
+		z, _ = z.with(ZMember{Member: member, Score: score})
+		return z, nil
+	})
+
+	return score, err
+}
+
+// ZRangeByScore returns the members of the sorted set stored with the given
+// key with scores between min and max, both included, in order.
+func (c *Cache) ZRangeByScore(key string, min, max float64) ([]ZMember, error) {
+	z, err := c.sortedSet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	from := sort.Search(len(z), func(i int) bool { return z[i].Score >= min })
+	to := sort.Search(len(z), func(i int) bool { return z[i].Score > max })
+	if from >= to {
+		return []ZMember{}, nil
+	}
+
+	return append([]ZMember(nil), z[from:to]...), nil
+}
+
+// ZRank returns the zero based rank of a member of the sorted set stored
+// with the given key, the member with the lowest score having rank zero. If
+// the member is not in the set ErrNotFound is returned.
+func (c *Cache) ZRank(key string, member string) (int, error) {
+	z, err := c.sortedSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	i := z.index(member)
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+
+	return i, nil
+}
+
+// index returns the position of a member in the sorted set, -1 if it is not
+// in the set.
+func (z SortedSet) index(member string) int {
+	for i, m := range z {
+		if m.Member == member {
+			return i
+		}
+	}
+	return -1
+}
+
+// with returns a copy of the sorted set with the member added at its
+// position and reports whether the member was in the set already.
+func (z SortedSet) with(m ZMember) (SortedSet, bool) {
+	i := z.index(m.Member)
+
+	added := make(SortedSet, 0, len(z)+1)
+	for j, o := range z {
+		if j != i {
+			added = append(added, o)
+		}
+	}
+
+	pos := sort.Search(len(added), func(j int) bool { return m.less(added[j]) })
+	added = append(added, ZMember{})
+	copy(added[pos+1:], added[pos:])
+	added[pos] = m
+
+	return added, i >= 0
+}
+
+// sortedSet returns the sorted set stored with the given key counting the
+// access like Get.
+func (c *Cache) sortedSet(key string) (SortedSet, error) {
+	v, ok := c.get(key)
+	return asSortedSet(v, ok)
+}
+
+func asSortedSet(v interface{}, ok bool) (SortedSet, error) {
+	if !ok {
+		return nil, nil
+	}
+
+	z, isSortedSet := v.(SortedSet)
+	if !isSortedSet {
+		return nil, ErrWrongType
+	}
+
+	return z, nil
+}
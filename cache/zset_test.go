@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSortedSet(t *testing.T) {
+	key := "testKey"
+
+	c := New()
+
+	n, err := c.ZAdd(key, ZMember{"c", 3}, ZMember{"a", 1}, ZMember{"b", 2})
+	if err != nil || n != 3 {
+		t.Errorf("Expected 3 added members. Got %d, %v", n, err)
+		t.Fail()
+	}
+
+	if n, _ := c.ZAdd(key, ZMember{"a", 4}, ZMember{"d", 2}); n != 1 {
+		t.Errorf("Expected 1 added member. Got %d", n)
+		t.Fail()
+	}
+
+	members, err := c.ZRangeByScore(key, 2, 3)
+	if err != nil || !reflect.DeepEqual(members, []ZMember{{"b", 2}, {"d", 2}, {"c", 3}}) {
+		t.Error("Unexpected members", members, err)
+		t.Fail()
+	}
+
+	if rank, err := c.ZRank(key, "a"); err != nil || rank != 3 {
+		t.Errorf("Expected rank 3. Got %d, %v", rank, err)
+		t.Fail()
+	}
+
+	if score, err := c.ZIncrBy(key, "b", 10); err != nil || score != 12 {
+		t.Errorf("Expected score 12. Got %f, %v", score, err)
+		t.Fail()
+	}
+
+	if rank, _ := c.ZRank(key, "b"); rank != 3 {
+		t.Errorf("Expected rank 3. Got %d", rank)
+		t.Fail()
+	}
+
+	if score, _ := c.ZIncrBy(key, "e", 1); score != 1 {
+		t.Errorf("Expected score 1. Got %f", score)
+		t.Fail()
+	}
+
+	if _, err := c.ZRank(key, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound got", err)
+		t.Fail()
+	}
+
+	if members, _ := c.ZRangeByScore(key, 100, 200); len(members) != 0 {
+		t.Error("Expected no members got", members)
+		t.Fail()
+	}
+}
+
+func TestSortedSetWrongType(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := New()
+	c.Set(key, value)
+
+	if _, err := c.ZAdd(key, ZMember{value, 1}); !errors.Is(err, ErrWrongType) {
+		t.Error("Expected ErrWrongType got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,212 @@
+// Package client accesses a layercake server over HTTP, gRPC or RESP. A
+// Client has the same Get, Set, SetWithTTL, Remove and GetStats methods as a
+// local cache.Cache and implements cache.Layer, so code can switch between an
+// embedded and a remote cache, or use the remote one as a lower tier.
+//
+// Values are serialized with a cache.Codec, cache.GobCodec by default.
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// errNotFound is returned by transports if no value is stored with a key.
+var errNotFound = errors.New("client: key not found")
+
+// transport performs requests to a server.
+type transport interface {
+	get(ctx context.Context, key string) ([]byte, error)
+	set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	remove(ctx context.Context, key string) error
+	stats(ctx context.Context) (*cache.Stats, error)
+	close() error
+}
+
+// Client is a cache.Layer backed by a remote layercake server.
+type Client struct {
+	transport transport
+	codec     cache.Codec
+	timeout   time.Duration
+	retries   int
+	backoff   time.Duration
+	poolSize  int
+	onError   func(error)
+}
+
+var _ cache.Layer = (*Client)(nil)
+
+// Option configures a Client on creation.
+type Option func(*Client)
+
+// WithCodec sets the codec used to serialize values. The default is
+// cache.GobCodec.
+func WithCodec(codec cache.Codec) Option {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithTimeout sets the timeout of a single request including its retries. The
+// default is one second.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries retries failed requests up to n times, waiting backoff before
+// the first retry and doubling it for each further one. Requests are not
+// retried by default.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = n
+		c.backoff = backoff
+	}
+}
+
+// WithPoolSize sets the number of idle connections kept open to the server.
+// The default is 8. It does not apply to gRPC, which multiplexes requests
+// over a single connection.
+func WithPoolSize(size int) Option {
+	return func(c *Client) {
+		c.poolSize = size
+	}
+}
+
+// WithErrorHandler sets a function that is called with request and codec
+// errors. Failed reads are treated as misses.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *Client) {
+		c.onError = handler
+	}
+}
+
+func newClient(opts []Option) *Client {
+	c := &Client{
+		codec:    cache.GobCodec{},
+		timeout:  time.Second,
+		poolSize: 8,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get retrieves a value stored with a specific key. If no value is available
+// or the request fails nil and false will be returned.
+func (c *Client) Get(key string) (interface{}, bool) {
+	var data []byte
+	err := c.do(func(ctx context.Context) error {
+		var err error
+		data, err = c.transport.get(ctx, key)
+		return err
+	})
+	if err != nil {
+		if err != errNotFound {
+			c.handleError(err)
+		}
+		return nil, false
+	}
+
+	v, err := c.codec.Unmarshal(data)
+	if err != nil {
+		c.handleError(err)
+		return nil, false
+	}
+
+	return v, true
+}
+
+// Set stores the value with the given key using the default ttl of the
+// server.
+func (c *Client) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores the value with the given key and lets the server remove
+// it after ttl. A ttl of zero or less uses the default ttl of the server.
+func (c *Client) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		c.handleError(err)
+		return
+	}
+
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	err = c.do(func(ctx context.Context) error {
+		return c.transport.set(ctx, key, data, ttl)
+	})
+	if err != nil {
+		c.handleError(err)
+	}
+}
+
+// Remove deletes a value stored with the given key.
+func (c *Client) Remove(key string) {
+	err := c.do(func(ctx context.Context) error {
+		return c.transport.remove(ctx, key)
+	})
+	if err != nil {
+		c.handleError(err)
+	}
+}
+
+// GetStats returns the Stats of the server. If the request fails empty Stats
+// are returned.
+func (c *Client) GetStats() *cache.Stats {
+	var stats *cache.Stats
+	err := c.do(func(ctx context.Context) error {
+		var err error
+		stats, err = c.transport.stats(ctx)
+		return err
+	})
+	if err != nil {
+		c.handleError(err)
+		return &cache.Stats{}
+	}
+
+	return stats
+}
+
+// Close closes the connections to the server.
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+// do runs a request with the timeout of the client and retries it on errors
+// other than errNotFound.
+func (c *Client) do(req func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	backoff := c.backoff
+	for i := 0; ; i++ {
+		err := req(ctx)
+		if err == nil || err == errNotFound || i >= c.retries {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) handleError(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// testClient exercises a client connected to a server serving c.
+func testClient(t *testing.T, client *Client, c *cache.Cache) {
+	key := "testKey"
+	value := "testValue"
+
+	client.Set(key, value)
+
+	v, ok := client.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if _, ok := client.Get("missing"); ok {
+		t.Error("Missing key should not have been found.")
+		t.Fail()
+	}
+
+	client.SetWithTTL("ttl", value, time.Minute)
+	if ttl, ok := c.TTL("ttl"); !ok || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Error("Expected ttl to be set, got", ttl)
+		t.Fail()
+	}
+
+	client.Remove(key)
+	if _, ok := c.Get(key); ok {
+		t.Error("Key should have been removed.")
+		t.Fail()
+	}
+
+	stats := client.GetStats()
+	if stats.Hits != 1 || stats.Set != 2 || stats.Entries != 1 || stats.Uptime.IsZero() {
+		t.Error("Unexpected stats", stats)
+		t.Fail()
+	}
+}
+
+type failingTransport struct {
+	failures int
+	calls    int
+}
+
+func (t *failingTransport) get(ctx context.Context, key string) ([]byte, error) {
+	t.calls++
+	if t.calls <= t.failures {
+		return nil, errors.New("unavailable")
+	}
+	return cache.GobCodec{}.Marshal("testValue")
+}
+
+func (t *failingTransport) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (t *failingTransport) remove(ctx context.Context, key string) error {
+	return errNotFound
+}
+
+func (t *failingTransport) stats(ctx context.Context) (*cache.Stats, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (t *failingTransport) close() error {
+	return nil
+}
+
+func TestRetries(t *testing.T) {
+	var errs []error
+
+	tr := &failingTransport{failures: 2}
+	c := newClient([]Option{WithRetries(2, time.Millisecond), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	})})
+	c.transport = tr
+
+	if v, ok := c.Get("testKey"); !ok || v != "testValue" {
+		t.Error("Expected", "testValue", "got", v)
+		t.Fail()
+	}
+
+	if tr.calls != 3 {
+		t.Errorf("Expected 3 calls. Got %d", tr.calls)
+		t.Fail()
+	}
+
+	tr.calls = 0
+	tr.failures = 3
+	if _, ok := c.Get("testKey"); ok {
+		t.Error("Get should fail once all retries failed.")
+		t.Fail()
+	}
+
+	if c.GetStats() == nil || len(errs) != 2 {
+		t.Error("Errors should have been reported, got", errs)
+		t.Fail()
+	}
+}
+
+func TestRetriesTimeout(t *testing.T) {
+	tr := &failingTransport{failures: 100}
+	c := newClient([]Option{WithRetries(100, 10*time.Millisecond), WithTimeout(50 * time.Millisecond)})
+	c.transport = tr
+
+	start := time.Now()
+	c.Get("testKey")
+
+	if d := time.Since(start); d > 200*time.Millisecond {
+		t.Errorf("Retries should stop at the timeout. Took %v", d)
+		t.Fail()
+	}
+}
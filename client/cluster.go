@@ -0,0 +1,252 @@
+package client
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// Cluster shards keys across multiple nodes using a consistent hash ring.
+// Adding or removing a node only moves the keys of the ring segments it
+// takes over or gives up, about 1/n of all keys for n nodes.
+type Cluster struct {
+	replicas int
+
+	nodes map[string]cache.Layer
+	// ring holds the sorted hashes of all virtual nodes
+	ring   []uint64
+	owners map[uint64]string
+	sync.RWMutex
+}
+
+var _ cache.Layer = (*Cluster)(nil)
+
+// ClusterOption configures a Cluster on creation.
+type ClusterOption func(*Cluster)
+
+// WithVirtualNodes sets the number of points each node is placed at on the
+// hash ring. More virtual nodes distribute keys more evenly. The default is
+// 160.
+func WithVirtualNodes(n int) ClusterOption {
+	return func(c *Cluster) {
+		if n > 0 {
+			c.replicas = n
+		}
+	}
+}
+
+// NewCluster returns a reference to a new Cluster without nodes.
+func NewCluster(opts ...ClusterOption) *Cluster {
+	c := &Cluster{
+		replicas: 160,
+		nodes:    make(map[string]cache.Layer),
+		owners:   make(map[uint64]string),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// AddNode adds a node with a unique name, e.g. its address, to the cluster.
+// The name determines the position of the node on the ring, so it has to be
+// the same on all clients. A node added with an existing name replaces it.
+func (c *Cluster) AddNode(name string, node cache.Layer) {
+	c.Lock()
+	defer c.Unlock()
+
+	if _, ok := c.nodes[name]; ok {
+		c.nodes[name] = node
+		return
+	}
+	c.nodes[name] = node
+
+	for i := 0; i < c.replicas; i++ {
+		h := hash(name + "#" + strconv.Itoa(i))
+		// on the unlikely collision the smaller name wins on all clients
+		if owner, ok := c.owners[h]; ok && owner < name {
+			continue
+		}
+		c.owners[h] = name
+	}
+	c.rebuild()
+}
+
+// RemoveNode removes the node with the given name from the cluster and
+// returns it, so it can be closed. If there is no such node nil is returned.
+func (c *Cluster) RemoveNode(name string) cache.Layer {
+	c.Lock()
+	defer c.Unlock()
+
+	node, ok := c.nodes[name]
+	if !ok {
+		return nil
+	}
+	delete(c.nodes, name)
+
+	for h, owner := range c.owners {
+		if owner == name {
+			delete(c.owners, h)
+		}
+	}
+	// points lost on collisions are taken over by the remaining nodes again
+	for other := range c.nodes {
+		for i := 0; i < c.replicas; i++ {
+			h := hash(other + "#" + strconv.Itoa(i))
+			if owner, ok := c.owners[h]; !ok || other < owner {
+				c.owners[h] = other
+			}
+		}
+	}
+	c.rebuild()
+
+	return node
+}
+
+// rebuild sorts the ring. The cluster has to be locked for writing.
+func (c *Cluster) rebuild() {
+	c.ring = c.ring[:0]
+	for h := range c.owners {
+		c.ring = append(c.ring, h)
+	}
+	sort.Slice(c.ring, func(i, j int) bool { return c.ring[i] < c.ring[j] })
+}
+
+// Nodes returns the names of all nodes in the cluster.
+func (c *Cluster) Nodes() []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	names := make([]string, 0, len(c.nodes))
+	for name := range c.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NodeFor returns the name of the node owning key, false if the cluster has
+// no nodes.
+func (c *Cluster) NodeFor(key string) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	if len(c.ring) == 0 {
+		return "", false
+	}
+
+	return c.owners[c.point(key)], true
+}
+
+// point returns the ring point owning key. The cluster has to be locked and
+// must have nodes.
+func (c *Cluster) point(key string) uint64 {
+	h := hash(key)
+	i := sort.Search(len(c.ring), func(i int) bool { return c.ring[i] >= h })
+	if i == len(c.ring) {
+		i = 0
+	}
+	return c.ring[i]
+}
+
+// node returns the node owning key, nil if the cluster has no nodes.
+func (c *Cluster) node(key string) cache.Layer {
+	c.RLock()
+	defer c.RUnlock()
+
+	if len(c.ring) == 0 {
+		return nil
+	}
+
+	return c.nodes[c.owners[c.point(key)]]
+}
+
+// hash returns the position of s on the ring. FNV-1a alone clusters similar
+// strings, so its result is mixed with the finalizer of splitmix64.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// Get retrieves a value stored with a specific key from the node owning it.
+// If no value is available or the cluster has no nodes nil and false will be
+// returned.
+func (c *Cluster) Get(key string) (interface{}, bool) {
+	node := c.node(key)
+	if node == nil {
+		return nil, false
+	}
+	return node.Get(key)
+}
+
+// Set stores the value with the given key on the node owning it.
+func (c *Cluster) Set(key string, value interface{}) {
+	if node := c.node(key); node != nil {
+		node.Set(key, value)
+	}
+}
+
+// SetWithTTL stores the value with the given key and ttl on the node owning
+// it.
+func (c *Cluster) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	if node := c.node(key); node != nil {
+		node.SetWithTTL(key, value, ttl)
+	}
+}
+
+// Remove deletes a value stored with the given key from the node owning it.
+func (c *Cluster) Remove(key string) {
+	if node := c.node(key); node != nil {
+		node.Remove(key)
+	}
+}
+
+// GetStats returns the summed Stats of all nodes, with the Stats of each node
+// in the order of Nodes in Layers. Uptime is the earliest one of all nodes.
+func (c *Cluster) GetStats() *cache.Stats {
+	c.RLock()
+	names := make([]string, 0, len(c.nodes))
+	for name := range c.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nodes := make([]cache.Layer, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, c.nodes[name])
+	}
+	c.RUnlock()
+
+	stats := &cache.Stats{}
+	for _, node := range nodes {
+		s := node.GetStats()
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Set += s.Set
+		stats.Removed += s.Removed
+		stats.Expired += s.Expired
+		stats.Evicted += s.Evicted
+		stats.Entries += s.Entries
+		if stats.Uptime.IsZero() || (!s.Uptime.IsZero() && s.Uptime.Before(stats.Uptime)) {
+			stats.Uptime = s.Uptime
+		}
+		stats.Layers = append(stats.Layers, s)
+	}
+	stats.SetHitRatio()
+
+	return stats
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+func TestCluster(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := NewCluster()
+
+	if _, ok := c.Get(key); ok {
+		t.Error("Empty cluster should not return values.")
+		t.Fail()
+	}
+	c.Set(key, value)
+
+	nodes := map[string]*cache.Cache{}
+	for i := 0; i < 4; i++ {
+		name := "node" + strconv.Itoa(i)
+		nodes[name] = cache.New()
+		c.AddNode(name, nodes[name])
+	}
+
+	for i := 0; i < 10000; i++ {
+		c.Set(key+strconv.Itoa(i), value)
+	}
+
+	for name, node := range nodes {
+		n := node.GetStats().Set
+		if n < 2000 || n > 3000 {
+			t.Errorf("Expected keys to be spread evenly. Got %d keys on %s", n, name)
+			t.Fail()
+		}
+	}
+
+	if v, ok := c.Get(key + "42"); !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	name, _ := c.NodeFor(key + "42")
+	if _, ok := nodes[name].Get(key + "42"); !ok {
+		t.Error("Key should have been stored on", name)
+		t.Fail()
+	}
+
+	stats := c.GetStats()
+	if stats.Set != 10000 || len(stats.Layers) != 4 {
+		t.Error("Unexpected stats", stats)
+		t.Fail()
+	}
+}
+
+func TestClusterRebalance(t *testing.T) {
+	key := "testKey"
+
+	c := NewCluster()
+	for i := 0; i < 4; i++ {
+		c.AddNode("node"+strconv.Itoa(i), cache.New())
+	}
+
+	owners := map[string]string{}
+	for i := 0; i < 10000; i++ {
+		owners[key+strconv.Itoa(i)], _ = c.NodeFor(key + strconv.Itoa(i))
+	}
+
+	c.AddNode("node4", cache.New())
+
+	moved := 0
+	for k, owner := range owners {
+		n, _ := c.NodeFor(k)
+		if n != owner {
+			if n != "node4" {
+				t.Error("Keys should only move to the new node, not", n)
+				t.Fail()
+			}
+			moved++
+		}
+	}
+
+	if moved < 1500 || moved > 2500 {
+		t.Errorf("Expected about a fifth of the keys to move. Got %d", moved)
+		t.Fail()
+	}
+
+	if c.RemoveNode("node4") == nil || c.RemoveNode("node4") != nil {
+		t.Error("Node should have been removed once.")
+		t.Fail()
+	}
+
+	for k, owner := range owners {
+		if n, _ := c.NodeFor(k); n != owner {
+			t.Error("Keys should move back after removing the node.")
+			t.Fail()
+			break
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/server/grpc/cachepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// grpcTransport talks to a server/grpc server.
+type grpcTransport struct {
+	client cachepb.CacheClient
+	conn   *grpc.ClientConn
+}
+
+// NewGRPC returns a reference to a new Client using a connection to a
+// server/grpc server. The connection is not closed by Close.
+func NewGRPC(conn grpc.ClientConnInterface, opts ...Option) *Client {
+	c := newClient(opts)
+	c.transport = &grpcTransport{client: cachepb.NewCacheClient(conn)}
+	return c
+}
+
+// DialGRPC returns a reference to a new Client connected to the server/grpc
+// server at target with the given dial options, e.g. transport credentials.
+func DialGRPC(target string, dialOpts []grpc.DialOption, opts ...Option) (*Client, error) {
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newClient(opts)
+	c.transport = &grpcTransport{
+		client: cachepb.NewCacheClient(conn),
+		conn:   conn,
+	}
+	return c, nil
+}
+
+func (t *grpcTransport) get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := t.client.Get(ctx, &cachepb.GetRequest{Key: key})
+	if status.Code(err) == codes.NotFound {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetValue(), nil
+}
+
+func (t *grpcTransport) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	req := &cachepb.SetRequest{Key: key, Value: value}
+	if ttl > 0 {
+		req.Ttl = durationpb.New(ttl)
+	}
+
+	_, err := t.client.Set(ctx, req)
+
+	return err
+}
+
+func (t *grpcTransport) remove(ctx context.Context, key string) error {
+	_, err := t.client.Remove(ctx, &cachepb.RemoveRequest{Key: key})
+	return err
+}
+
+func (t *grpcTransport) stats(ctx context.Context) (*cache.Stats, error) {
+	resp, err := t.client.Stats(ctx, &cachepb.StatsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &cache.Stats{
+		Hits:    int(resp.GetHits()),
+		Misses:  int(resp.GetMisses()),
+		Set:     int(resp.GetSet()),
+		Removed: int(resp.GetRemoved()),
+		Expired: int(resp.GetExpired()),
+		Evicted: int(resp.GetEvicted()),
+		Entries: int(resp.GetEntries()),
+		Uptime:  resp.GetUptime().AsTime(),
+	}
+	stats.SetHitRatio()
+
+	return stats, nil
+}
+
+func (t *grpcTransport) close() error {
+	if t.conn != nil {
+		return t.conn.Close()
+	}
+	return nil
+}
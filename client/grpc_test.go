@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/mkrull/layercake/cache"
+	grpcserver "github.com/mkrull/layercake/server/grpc"
+	"github.com/mkrull/layercake/server/grpc/cachepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPC(t *testing.T) {
+	c := cache.New()
+
+	l := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	cachepb.RegisterCacheServer(srv, grpcserver.New(c))
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	client, err := DialGRPC("passthrough:///bufconn", []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	testClient(t, client, c)
+}
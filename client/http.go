@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// httpTransport talks to a server/http server.
+type httpTransport struct {
+	base   string
+	client *http.Client
+}
+
+// NewHTTP returns a reference to a new Client for the server/http server at
+// baseURL, e.g. "http://localhost:8080".
+func NewHTTP(baseURL string, opts ...Option) *Client {
+	c := newClient(opts)
+	c.transport = &httpTransport{
+		base: strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConns:        c.poolSize,
+				MaxIdleConnsPerHost: c.poolSize,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+	return c
+}
+
+func (t *httpTransport) url(key string) string {
+	return t.base + "/keys/" + url.PathEscape(key)
+}
+
+func (t *httpTransport) do(ctx context.Context, method, url string, body []byte, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && method == http.MethodGet:
+		return nil, errNotFound
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("client: %s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(data))
+	}
+
+	return data, nil
+}
+
+func (t *httpTransport) get(ctx context.Context, key string) ([]byte, error) {
+	return t.do(ctx, http.MethodGet, t.url(key), nil, nil)
+}
+
+func (t *httpTransport) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var header http.Header
+	if ttl > 0 {
+		header = http.Header{}
+		header.Set("X-TTL", ttl.String())
+	}
+
+	_, err := t.do(ctx, http.MethodPut, t.url(key), value, header)
+
+	return err
+}
+
+func (t *httpTransport) remove(ctx context.Context, key string) error {
+	_, err := t.do(ctx, http.MethodDelete, t.url(key), nil, nil)
+	return err
+}
+
+func (t *httpTransport) stats(ctx context.Context) (*cache.Stats, error) {
+	data, err := t.do(ctx, http.MethodGet, t.base+"/stats", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats cache.Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
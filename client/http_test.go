@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mkrull/layercake/cache"
+	httpserver "github.com/mkrull/layercake/server/http"
+)
+
+func TestHTTP(t *testing.T) {
+	c := cache.New()
+	srv := httptest.NewServer(httpserver.New(c))
+	defer srv.Close()
+
+	client := NewHTTP(srv.URL + "/")
+	defer client.Close()
+
+	testClient(t, client, c)
+}
+
+func TestHTTPError(t *testing.T) {
+	srv := httptest.NewServer(httpserver.New(cache.New(), httpserver.WithMaxValueSize(1)))
+	defer srv.Close()
+
+	var errs []error
+	client := NewHTTP(srv.URL, WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer client.Close()
+
+	client.Set("testKey", "testValue")
+
+	if len(errs) != 1 {
+		t.Error("Expected an error for a value that is too large, got", errs)
+		t.Fail()
+	}
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// respTransport talks to a server/resp server, or any other server speaking
+// RESP, over a pool of connections.
+type respTransport struct {
+	addr   string
+	dialer net.Dialer
+	idle   chan *respConn
+	closed bool
+	sync.Mutex
+}
+
+type respConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// respError is an error reply of the server.
+type respError string
+
+func (e respError) Error() string {
+	return "client: " + string(e)
+}
+
+// NewRESP returns a reference to a new Client for the server/resp server at
+// the TCP address addr.
+func NewRESP(addr string, opts ...Option) *Client {
+	c := newClient(opts)
+	c.transport = &respTransport{
+		addr: addr,
+		idle: make(chan *respConn, c.poolSize),
+	}
+	return c
+}
+
+// conn returns an idle connection or dials a new one.
+func (t *respTransport) conn(ctx context.Context) (*respConn, error) {
+	select {
+	case conn := <-t.idle:
+		return conn, nil
+	default:
+	}
+
+	conn, err := t.dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respConn{
+		Conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}, nil
+}
+
+// release returns a connection to the pool, or closes it if the pool is full
+// or the transport closed.
+func (t *respTransport) release(conn *respConn) {
+	t.Lock()
+	defer t.Unlock()
+
+	if !t.closed {
+		select {
+		case t.idle <- conn:
+			return
+		default:
+		}
+	}
+	conn.Close()
+}
+
+// do sends a command and returns its reply. Connections are only reused after
+// a complete reply has been read.
+func (t *respTransport) do(ctx context.Context, args ...[]byte) (interface{}, error) {
+	conn, err := t.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Time{})
+	}
+
+	conn.w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		conn.w.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n")
+		conn.w.Write(arg)
+		conn.w.WriteString("\r\n")
+	}
+	if err := conn.w.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := readReply(conn.r)
+	if err != nil {
+		if _, ok := err.(respError); !ok {
+			conn.Close()
+			return nil, err
+		}
+	}
+	t.release(conn)
+
+	return reply, err
+}
+
+// readReply reads a reply. Bulk strings are returned as []byte, null replies
+// as nil, integers as int64 and simple strings as string. Arrays are not
+// supported as none of the used commands replies with one.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("client: malformed reply")
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return nil, respError(line[1:])
+	case ':':
+		return strconv.ParseInt(string(line[1:]), 10, 64)
+	case '$':
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	default:
+		return nil, fmt.Errorf("client: unsupported reply type %q", line[0])
+	}
+}
+
+func (t *respTransport) get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := t.do(ctx, []byte("GET"), []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, errNotFound
+	}
+
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("client: unexpected reply %v", reply)
+	}
+
+	return data, nil
+}
+
+func (t *respTransport) set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := [][]byte{[]byte("SET"), []byte(key), value}
+	if ttl > 0 {
+		// round up to keep short ttls from expiring immediately
+		ms := (ttl + time.Millisecond - 1) / time.Millisecond
+		args = append(args, []byte("PX"), []byte(strconv.FormatInt(int64(ms), 10)))
+	}
+
+	_, err := t.do(ctx, args...)
+
+	return err
+}
+
+func (t *respTransport) remove(ctx context.Context, key string) error {
+	_, err := t.do(ctx, []byte("DEL"), []byte(key))
+	return err
+}
+
+// stats parses the INFO reply of the server.
+func (t *respTransport) stats(ctx context.Context) (*cache.Stats, error) {
+	reply, err := t.do(ctx, []byte("INFO"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("client: unexpected reply %v", reply)
+	}
+
+	stats := &cache.Stats{}
+	for _, line := range bytes.Split(data, []byte("\r\n")) {
+		name, value, ok := strings.Cut(string(line), ":")
+		if !ok {
+			continue
+		}
+		if name == "db0" {
+			// db0:keys=n,...
+			value, _, _ = strings.Cut(strings.TrimPrefix(value, "keys="), ",")
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+
+		switch name {
+		case "uptime_in_seconds":
+			stats.Uptime = time.Now().Add(-time.Duration(n) * time.Second).UTC()
+		case "keyspace_hits":
+			stats.Hits = n
+		case "keyspace_misses":
+			stats.Misses = n
+		case "set_commands":
+			stats.Set = n
+		case "removed_keys":
+			stats.Removed = n
+		case "expired_keys":
+			stats.Expired = n
+		case "evicted_keys":
+			stats.Evicted = n
+		case "db0":
+			stats.Entries = n
+		}
+	}
+	stats.SetHitRatio()
+
+	return stats, nil
+}
+
+func (t *respTransport) close() error {
+	t.Lock()
+	defer t.Unlock()
+
+	t.closed = true
+	for {
+		select {
+		case conn := <-t.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
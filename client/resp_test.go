@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/server/resp"
+)
+
+func TestRESP(t *testing.T) {
+	c := cache.New()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := resp.New(c)
+	go srv.Serve(l)
+	defer srv.Close()
+
+	client := NewRESP(l.Addr().String(), WithPoolSize(2))
+	defer client.Close()
+
+	testClient(t, client, c)
+
+	rt := client.transport.(*respTransport)
+	if len(rt.idle) != 1 {
+		t.Errorf("Expected the connection to be reused. Got %d idle connections", len(rt.idle))
+		t.Fail()
+	}
+}
+
+func TestRESPError(t *testing.T) {
+	c := cache.New()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := resp.New(c)
+	go srv.Serve(l)
+	defer srv.Close()
+
+	client := NewRESP(l.Addr().String())
+	defer client.Close()
+
+	rt := client.transport.(*respTransport)
+	_, err = rt.do(context.Background(), []byte("FLUSHALL"))
+	if _, ok := err.(respError); !ok {
+		t.Error("Expected error reply, got", err)
+		t.Fail()
+	}
+
+	// the connection is still usable after an error reply
+	client.Set("testKey", "testValue")
+	if _, ok := client.Get("testKey"); !ok || len(rt.idle) != 1 {
+		t.Error("Connection should have been reused.")
+		t.Fail()
+	}
+}
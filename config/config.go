@@ -0,0 +1,353 @@
+// Package config builds caches and RESP servers from configuration files in
+// YAML or JSON and environment variables, so deployments can tune them
+// without code changes.
+//
+//	cfg, err := config.Load("layercake.yaml")
+//	if err != nil {
+//		// handle error
+//	}
+//	c, err := cfg.NewCache()
+//
+// A YAML file could look like this:
+//
+//	default_ttl: 5m
+//	max_entries: 100000
+//	snapshot:
+//	  path: /var/lib/layercake/cache.snap
+//	  interval: 1m
+//	server:
+//	  addr: :6379
+//
+// Environment variables override the file, named by EnvPrefix and the path
+// of the setting in upper case, e.g. LAYERCAKE_DEFAULT_TTL=10m or
+// LAYERCAKE_SNAPSHOT_PATH=/tmp/cache.snap.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/server/resp"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables read by Load.
+const EnvPrefix = "LAYERCAKE_"
+
+// Eviction policies.
+const (
+	// EvictLRU evicts the least recently used of a few sampled entries.
+	EvictLRU = "lru"
+)
+
+// Duration is a time.Duration read from strings like "1m30s".
+type Duration time.Duration
+
+// UnmarshalText parses a duration in the format of time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration like time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config holds the settings of a cache and the RESP server serving it.
+// Zero values keep the defaults of the cache.
+type Config struct {
+	// Shards is the number of shards, AutoShards sizes them by GOMAXPROCS
+	Shards     int      `json:"shards" yaml:"shards" env:"SHARDS"`
+	AutoShards bool     `json:"auto_shards" yaml:"auto_shards" env:"AUTO_SHARDS"`
+	DefaultTTL Duration `json:"default_ttl" yaml:"default_ttl" env:"DEFAULT_TTL"`
+	TTLJitter  float64  `json:"ttl_jitter" yaml:"ttl_jitter" env:"TTL_JITTER"`
+	StaleGrace Duration `json:"stale_grace" yaml:"stale_grace" env:"STALE_GRACE"`
+	MaxEntries int      `json:"max_entries" yaml:"max_entries" env:"MAX_ENTRIES"`
+	// CapacityHint is the number of entries the cache is allocated for
+	CapacityHint int `json:"capacity_hint" yaml:"capacity_hint" env:"CAPACITY_HINT"`
+	// ShrinkInterval is the interval the maps of shards are rebuilt at once
+	// most of their entries were removed, zero disables rebuilding
+	ShrinkInterval Duration `json:"shrink_interval" yaml:"shrink_interval" env:"SHRINK_INTERVAL"`
+	// Eviction is the policy evicting entries once MaxEntries is reached,
+	// only EvictLRU is supported
+	Eviction       string `json:"eviction" yaml:"eviction" env:"EVICTION"`
+	KeyspaceEvents string `json:"keyspace_events" yaml:"keyspace_events" env:"KEYSPACE_EVENTS"`
+
+	Stats         Stats            `json:"stats" yaml:"stats" env:"STATS"`
+	Snapshot      Snapshot         `json:"snapshot" yaml:"snapshot" env:"SNAPSHOT"`
+	AppendOnlyLog AppendOnlyLog    `json:"append_only_log" yaml:"append_only_log" env:"AOF"`
+	Quotas        map[string]Quota `json:"quotas" yaml:"quotas"`
+	Server        Server           `json:"server" yaml:"server" env:"SERVER"`
+}
+
+// Stats holds the settings of the statistics of the cache.
+type Stats struct {
+	Disabled bool `json:"disabled" yaml:"disabled" env:"DISABLED"`
+	Windowed bool `json:"windowed" yaml:"windowed" env:"WINDOWED"`
+	Latency  bool `json:"latency" yaml:"latency" env:"LATENCY"`
+	// HotKeys is the number of hot keys tracked per shard, zero disables
+	// tracking
+	HotKeys int `json:"hot_keys" yaml:"hot_keys" env:"HOT_KEYS"`
+}
+
+// Snapshot holds the settings of automatic snapshots. NewCache restores the
+// snapshot at Path if there is one.
+type Snapshot struct {
+	Path     string   `json:"path" yaml:"path" env:"PATH"`
+	Interval Duration `json:"interval" yaml:"interval" env:"INTERVAL"`
+	Gzip     bool     `json:"gzip" yaml:"gzip" env:"GZIP"`
+}
+
+// AppendOnlyLog holds the settings of the append-only log.
+type AppendOnlyLog struct {
+	Path        string   `json:"path" yaml:"path" env:"PATH"`
+	SyncEvery   Duration `json:"sync_every" yaml:"sync_every" env:"SYNC_EVERY"`
+	RewriteSize int64    `json:"rewrite_size" yaml:"rewrite_size" env:"REWRITE_SIZE"`
+}
+
+// Quota limits the entries of a namespace like cache.Quota.
+type Quota struct {
+	MaxEntries int   `json:"max_entries" yaml:"max_entries"`
+	MaxCost    int64 `json:"max_cost" yaml:"max_cost"`
+}
+
+// Server holds the settings of the RESP server.
+type Server struct {
+	Addr         string `json:"addr" yaml:"addr" env:"ADDR"`
+	MaxValueSize int    `json:"max_value_size" yaml:"max_value_size" env:"MAX_VALUE_SIZE"`
+}
+
+// Load reads the configuration file at path, YAML or JSON depending on the
+// extension .yaml, .yml or .json, and applies the environment variables
+// starting with EnvPrefix.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *Config
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		cfg, err = ParseYAML(data)
+	case ".json":
+		cfg, err = ParseJSON(data)
+	default:
+		return nil, fmt.Errorf("config: unknown file type %q", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ApplyEnv(EnvPrefix); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ParseYAML parses a configuration in YAML. Unknown settings are rejected.
+func ParseYAML(data []byte) (*Config, error) {
+	cfg := &Config{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// ParseJSON parses a configuration in JSON. Unknown settings are rejected.
+func ParseJSON(data []byte) (*Config, error) {
+	cfg := &Config{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// ApplyEnv overrides settings with the environment variables named by prefix
+// and the env tags of the settings, joined with underscores for nested
+// settings, e.g. LAYERCAKE_SNAPSHOT_PATH. Quotas cannot be set from the
+// environment.
+func (cfg *Config) ApplyEnv(prefix string) error {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), prefix)
+}
+
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+
+		name := prefix + tag
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, s); err != nil {
+			return fmt.Errorf("config: %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setField sets a setting to the value parsed from s.
+func setField(field reflect.Value, s string) error {
+	if u, ok := field.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// Options returns the cache options of the configuration.
+func (cfg *Config) Options() ([]cache.Option, error) {
+	if e := strings.ToLower(cfg.Eviction); e != "" && e != EvictLRU {
+		return nil, fmt.Errorf("config: unknown eviction policy %q", cfg.Eviction)
+	}
+
+	var opts []cache.Option
+	if cfg.AutoShards {
+		opts = append(opts, cache.WithAutoShards())
+	} else if cfg.Shards > 0 {
+		opts = append(opts, cache.WithShards(cfg.Shards))
+	}
+	if cfg.DefaultTTL != 0 {
+		opts = append(opts, cache.WithDefaultTTL(time.Duration(cfg.DefaultTTL)))
+	}
+	if cfg.TTLJitter != 0 {
+		opts = append(opts, cache.WithTTLJitter(cfg.TTLJitter))
+	}
+	if cfg.StaleGrace != 0 {
+		opts = append(opts, cache.WithStaleGrace(time.Duration(cfg.StaleGrace)))
+	}
+	if cfg.MaxEntries != 0 {
+		opts = append(opts, cache.WithMaxEntries(cfg.MaxEntries))
+	}
+	if cfg.CapacityHint > 0 {
+		opts = append(opts, cache.WithCapacityHint(cfg.CapacityHint))
+	}
+	if cfg.ShrinkInterval > 0 {
+		opts = append(opts, cache.WithMapShrinking(time.Duration(cfg.ShrinkInterval)))
+	}
+	if cfg.KeyspaceEvents != "" {
+		opts = append(opts, cache.WithKeyspaceEvents(cfg.KeyspaceEvents))
+	}
+
+	if cfg.Stats.Disabled {
+		opts = append(opts, cache.WithStatsDisabled())
+	}
+	if cfg.Stats.Windowed {
+		opts = append(opts, cache.WithWindowedStats())
+	}
+	if cfg.Stats.Latency {
+		opts = append(opts, cache.WithLatencyTracking())
+	}
+	if cfg.Stats.HotKeys > 0 {
+		opts = append(opts, cache.WithHotKeyTracking(cfg.Stats.HotKeys))
+	}
+
+	if cfg.Snapshot.Path != "" && cfg.Snapshot.Interval > 0 {
+		opts = append(opts, cache.WithAutoSnapshot(cfg.Snapshot.Path, time.Duration(cfg.Snapshot.Interval)))
+	}
+	if cfg.Snapshot.Gzip {
+		opts = append(opts, cache.WithSnapshotCompression(cache.Gzip))
+	}
+
+	if cfg.AppendOnlyLog.Path != "" {
+		opts = append(opts, cache.WithAppendOnlyLog(cfg.AppendOnlyLog.Path, time.Duration(cfg.AppendOnlyLog.SyncEvery)))
+	}
+	if cfg.AppendOnlyLog.RewriteSize > 0 {
+		opts = append(opts, cache.WithLogRewrite(cfg.AppendOnlyLog.RewriteSize))
+	}
+
+	for name, q := range cfg.Quotas {
+		opts = append(opts, cache.WithNamespaceQuota(name, cache.Quota{MaxEntries: q.MaxEntries, MaxCost: q.MaxCost}))
+	}
+
+	return opts, nil
+}
+
+// NewCache returns a reference to a new cache configured with the options of
+// the configuration followed by opts, e.g. a Loader. The snapshot at the
+// snapshot path is restored if there is one.
+func (cfg *Config) NewCache(opts ...cache.Option) (*cache.Cache, error) {
+	cfgOpts, err := cfg.Options()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(cfgOpts, opts...)
+
+	if cfg.Snapshot.Path != "" {
+		return cache.NewFromFile(cfg.Snapshot.Path, opts...)
+	}
+
+	return cache.New(opts...), nil
+}
+
+// NewServer returns a reference to a new RESP server serving c with the
+// settings of the configuration. Start it with ListenAndServe and
+// cfg.Server.Addr.
+func (cfg *Config) NewServer(c *cache.Cache, opts ...resp.Option) *resp.Server {
+	if cfg.Server.MaxValueSize > 0 {
+		opts = append([]resp.Option{resp.WithMaxValueSize(cfg.Server.MaxValueSize)}, opts...)
+	}
+
+	return resp.New(c, opts...)
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+func TestParseYAML(t *testing.T) {
+	cfg, err := ParseYAML([]byte(`
+default_ttl: 5m
+max_entries: 1000
+eviction: lru
+stats:
+  hot_keys: 10
+snapshot:
+  path: /tmp/cache.snap
+  interval: 1m
+quotas:
+  users:
+    max_entries: 10
+server:
+  addr: :6379
+`))
+	if err != nil {
+		t.Error("Failed to parse config:", err)
+		t.FailNow()
+	}
+
+	if time.Duration(cfg.DefaultTTL) != 5*time.Minute {
+		t.Error("Expected 5m got", time.Duration(cfg.DefaultTTL))
+		t.Fail()
+	}
+
+	if cfg.MaxEntries != 1000 || cfg.Stats.HotKeys != 10 || cfg.Server.Addr != ":6379" {
+		t.Error("Unexpected config", cfg)
+		t.Fail()
+	}
+
+	if time.Duration(cfg.Snapshot.Interval) != time.Minute || cfg.Quotas["users"].MaxEntries != 10 {
+		t.Error("Unexpected config", cfg)
+		t.Fail()
+	}
+
+	if _, err := ParseYAML([]byte("unknown: 1")); err == nil {
+		t.Error("Unknown settings should be rejected.")
+		t.Fail()
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	cfg, err := ParseJSON([]byte(`{"default_ttl": "1h", "append_only_log": {"path": "cache.aof", "sync_every": "1s"}}`))
+	if err != nil {
+		t.Error("Failed to parse config:", err)
+		t.FailNow()
+	}
+
+	if time.Duration(cfg.DefaultTTL) != time.Hour || time.Duration(cfg.AppendOnlyLog.SyncEvery) != time.Second {
+		t.Error("Unexpected config", cfg)
+		t.Fail()
+	}
+
+	if _, err := ParseJSON([]byte(`{"default_ttl": "soon"}`)); err == nil {
+		t.Error("Invalid durations should be rejected.")
+		t.Fail()
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "layercake.json")
+	os.WriteFile(path, []byte(`{"default_ttl": "1h", "max_entries": 10}`), 0o600)
+
+	t.Setenv("LAYERCAKE_DEFAULT_TTL", "10m")
+	t.Setenv("LAYERCAKE_STATS_WINDOWED", "true")
+	t.Setenv("LAYERCAKE_SERVER_MAX_VALUE_SIZE", "1024")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Error("Failed to load config:", err)
+		t.FailNow()
+	}
+
+	if time.Duration(cfg.DefaultTTL) != 10*time.Minute {
+		t.Error("Expected 10m got", time.Duration(cfg.DefaultTTL))
+		t.Fail()
+	}
+
+	if cfg.MaxEntries != 10 || !cfg.Stats.Windowed || cfg.Server.MaxValueSize != 1024 {
+		t.Error("Unexpected config", cfg)
+		t.Fail()
+	}
+
+	t.Setenv("LAYERCAKE_MAX_ENTRIES", "many")
+	if _, err := Load(path); err == nil {
+		t.Error("Invalid environment variables should be rejected.")
+		t.Fail()
+	}
+}
+
+func TestNewCache(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	c := cache.New()
+	c.Set(key, value)
+	c.SaveToFile(path)
+
+	cfg := &Config{
+		Shards:     8,
+		DefaultTTL: Duration(time.Hour),
+		Snapshot:   Snapshot{Path: path},
+		Quotas:     map[string]Quota{"users": {MaxEntries: 1}},
+	}
+
+	c, err := cfg.NewCache()
+	if err != nil {
+		t.Error("Failed to create cache:", err)
+		t.FailNow()
+	}
+
+	if v, _ := c.Get(key); v != value {
+		t.Error("Expected the snapshot to be restored, got", v)
+		t.Fail()
+	}
+
+	c.Set("otherKey", value)
+	if ttl, _ := c.TTL("otherKey"); ttl <= 59*time.Minute {
+		t.Error("Expected the default ttl, got", ttl)
+		t.Fail()
+	}
+
+	if n := len(c.GetShardStats()); n != 8 {
+		t.Errorf("Expected 8 shards. Got %d", n)
+		t.Fail()
+	}
+
+	users := c.Namespace("users")
+	users.Set("a", value)
+	users.Set("b", value)
+	if n := len(users.Keys()); n != 1 {
+		t.Errorf("Expected the quota to be applied. Got %d entries", n)
+		t.Fail()
+	}
+
+	cfg.Eviction = "random"
+	if _, err := cfg.NewCache(); err == nil {
+		t.Error("Unknown eviction policies should be rejected.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,31 @@
+package gossip
+
+import (
+	"io"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/client"
+)
+
+// ClusterHandler returns an event handler keeping the nodes of cluster in
+// sync with the alive members. Joining members are added with the layer
+// returned by node, e.g. a client.Client for the address in their metadata.
+// Nodes of failed and leaving members are removed and closed if they
+// implement io.Closer. The local member is never reported, add it to the
+// cluster separately if it should own keys.
+func ClusterHandler(cluster *client.Cluster, node func(Member) cache.Layer) func(Event) {
+	return func(ev Event) {
+		switch ev.Type {
+		case Join:
+			if l := node(ev.Member); l != nil {
+				cluster.AddNode(ev.Member.Name, l)
+			}
+		case Fail, Leave:
+			if l := cluster.RemoveNode(ev.Member.Name); l != nil {
+				if c, ok := l.(io.Closer); ok {
+					c.Close()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/client"
+)
+
+func TestClusterHandler(t *testing.T) {
+	cluster := client.NewCluster()
+	layers := map[string]*cache.Cache{}
+
+	handler := ClusterHandler(cluster, func(m Member) cache.Layer {
+		layers[m.Meta] = cache.New()
+		return layers[m.Meta]
+	})
+
+	m0, err := New("node0", "127.0.0.1:0", WithInterval(10*time.Millisecond), WithFailureTimeout(200*time.Millisecond), WithEventHandler(handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m0.Close()
+
+	m1, err := New("node1", "127.0.0.1:0", WithInterval(10*time.Millisecond), WithMeta("127.0.0.1:6379"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1.Join(m0.Addr())
+
+	if !waitFor(func() bool { return len(cluster.Nodes()) == 1 }) {
+		t.Fatal("Joined member should have been added to the cluster.")
+	}
+
+	cluster.Set("testKey", "testValue")
+	if _, ok := layers["127.0.0.1:6379"].Get("testKey"); !ok {
+		t.Error("Value should have been stored on the joined node.")
+		t.Fail()
+	}
+
+	m1.Leave()
+
+	if !waitFor(func() bool { return len(cluster.Nodes()) == 0 }) {
+		t.Error("Leaving member should have been removed from the cluster.")
+		t.Fail()
+	}
+}
@@ -0,0 +1,478 @@
+// Package gossip provides cluster membership for layercake nodes. Nodes
+// discover each other by gossiping their member lists over UDP and detect
+// failed nodes once their heartbeats stop spreading, so the hash ring of a
+// client.Cluster can follow the cluster without a static node list.
+package gossip
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxPacketSize is the maximum size of a gossip message.
+const maxPacketSize = 60 * 1024
+
+// ErrClosed is returned when using a closed Membership.
+var ErrClosed = errors.New("gossip: membership closed")
+
+// State is the state of a member.
+type State int
+
+const (
+	// Alive members are spreading their heartbeat.
+	Alive State = iota
+	// Failed members stopped spreading their heartbeat.
+	Failed
+	// Left members left the cluster on purpose.
+	Left
+)
+
+// Member is a node of the cluster.
+type Member struct {
+	Name string
+	// Addr is the gossip address of the member
+	Addr string
+	// Meta is set with WithMeta, e.g. to the address a node serves the
+	// cache on
+	Meta  string
+	State State
+}
+
+// EventType is the kind of a membership change.
+type EventType int
+
+const (
+	// Join is emitted when a member is discovered or comes back after it
+	// failed.
+	Join EventType = iota
+	// Fail is emitted when a member stopped spreading its heartbeat.
+	Fail
+	// Leave is emitted when a member left the cluster.
+	Leave
+)
+
+// Event is a change of the membership of the cluster.
+type Event struct {
+	Type   EventType
+	Member Member
+}
+
+// member is the local view of a member.
+type member struct {
+	Member
+	heartbeat uint64
+	// seen is the time the heartbeat last increased, or the member failed
+	// or left
+	seen time.Time
+}
+
+// wireMember is the gossiped form of a member.
+type wireMember struct {
+	Name      string `json:"name"`
+	Addr      string `json:"addr"`
+	Meta      string `json:"meta,omitempty"`
+	Heartbeat uint64 `json:"heartbeat"`
+	Left      bool   `json:"left,omitempty"`
+}
+
+// Membership tracks the members of a cluster by gossiping with them.
+type Membership struct {
+	self     string
+	meta     string
+	interval time.Duration
+	fanout   int
+	timeout  time.Duration
+	onEvent  func(Event)
+
+	conn    net.PacketConn
+	members map[string]*member
+	seeds   []string
+	closed  bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	// emitting keeps events in order, it is acquired before the membership
+	// is unlocked
+	emitting sync.Mutex
+	sync.Mutex
+}
+
+// Option configures a Membership on creation.
+type Option func(*Membership)
+
+// WithMeta sets the metadata gossiped with the local member, e.g. the
+// address it serves the cache on.
+func WithMeta(meta string) Option {
+	return func(m *Membership) {
+		m.meta = meta
+	}
+}
+
+// WithInterval sets how often members are gossiped. The default is 200ms,
+// which is kept for intervals of zero or less.
+func WithInterval(interval time.Duration) Option {
+	return func(m *Membership) {
+		if interval > 0 {
+			m.interval = interval
+		}
+	}
+}
+
+// WithFanout sets the number of random members gossiped with per interval.
+// The default is 3.
+func WithFanout(n int) Option {
+	return func(m *Membership) {
+		m.fanout = n
+	}
+}
+
+// WithFailureTimeout sets the time after which a member whose heartbeat did
+// not increase is considered failed. The default is 5s.
+func WithFailureTimeout(timeout time.Duration) Option {
+	return func(m *Membership) {
+		m.timeout = timeout
+	}
+}
+
+// WithEventHandler sets a function that is called with membership changes in
+// the order they are detected. It is called from the gossip go routines and
+// should return quickly.
+func WithEventHandler(handler func(Event)) Option {
+	return func(m *Membership) {
+		m.onEvent = handler
+	}
+}
+
+// New returns a reference to a new Membership for the local member with a
+// unique name, gossiping on the UDP address bindAddr. Use Join to contact
+// existing members.
+func New(name, bindAddr string, opts ...Option) (*Membership, error) {
+	m := &Membership{
+		self:     name,
+		interval: 200 * time.Millisecond,
+		fanout:   3,
+		timeout:  5 * time.Second,
+		members:  make(map[string]*member),
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	conn, err := net.ListenPacket("udp", bindAddr)
+	if err != nil {
+		return nil, err
+	}
+	m.conn = conn
+
+	// the heartbeat starts at the current time, so a restarted member is
+	// not mistaken for its former incarnation
+	m.members[name] = &member{
+		Member: Member{
+			Name: name,
+			Addr: conn.LocalAddr().String(),
+			Meta: m.meta,
+		},
+		heartbeat: uint64(time.Now().UnixNano()),
+		seen:      time.Now(),
+	}
+
+	m.wg.Add(2)
+	go m.receive()
+	go m.run()
+
+	return m, nil
+}
+
+// Addr returns the gossip address of the local member.
+func (m *Membership) Addr() string {
+	return m.conn.LocalAddr().String()
+}
+
+// Join contacts the members at the given gossip addresses. The seeds are
+// contacted again every interval as long as no other member is known.
+func (m *Membership) Join(seeds ...string) error {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return ErrClosed
+	}
+	m.seeds = append(m.seeds, seeds...)
+	msg := m.message()
+	m.Unlock()
+
+	var err error
+	for _, addr := range seeds {
+		if e := m.send(addr, msg); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// Members returns the alive members including the local one.
+func (m *Membership) Members() []Member {
+	m.Lock()
+	defer m.Unlock()
+
+	members := make([]Member, 0, len(m.members))
+	for _, mem := range m.members {
+		if mem.State == Alive {
+			members = append(members, mem.Member)
+		}
+	}
+
+	return members
+}
+
+// Leave tells the other members that the local member leaves and closes the
+// membership.
+func (m *Membership) Leave() error {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return ErrClosed
+	}
+	self := m.members[m.self]
+	self.heartbeat++
+	self.State = Left
+	msg := m.message()
+	var addrs []string
+	for _, mem := range m.alive() {
+		addrs = append(addrs, mem.Addr)
+	}
+	if len(addrs) == 0 {
+		addrs = m.seeds
+	}
+	m.Unlock()
+
+	for _, addr := range addrs {
+		m.send(addr, msg)
+	}
+
+	return m.Close()
+}
+
+// Close stops gossiping without telling the other members, which will
+// consider the local member failed.
+func (m *Membership) Close() error {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return ErrClosed
+	}
+	m.closed = true
+	m.Unlock()
+
+	close(m.stop)
+	err := m.conn.Close()
+	m.wg.Wait()
+
+	return err
+}
+
+// run gossips every interval and detects failed members.
+func (m *Membership) run() {
+	defer m.wg.Done()
+
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			m.tick()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *Membership) tick() {
+	now := time.Now()
+	var events []Event
+
+	m.Lock()
+	m.members[m.self].heartbeat++
+
+	for name, mem := range m.members {
+		switch {
+		case name == m.self:
+		case mem.State == Alive && now.Sub(mem.seen) > m.timeout:
+			mem.State = Failed
+			mem.seen = now
+			events = append(events, Event{Type: Fail, Member: mem.Member})
+		case mem.State != Alive && now.Sub(mem.seen) > 10*m.timeout:
+			// members are remembered for a while, so outdated gossip
+			// does not bring them back
+			delete(m.members, name)
+		}
+	}
+
+	targets := m.alive()
+	rand.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+	if len(targets) > m.fanout {
+		targets = targets[:m.fanout]
+	}
+
+	var addrs []string
+	for _, mem := range targets {
+		addrs = append(addrs, mem.Addr)
+	}
+	if len(addrs) == 0 {
+		addrs = m.seeds
+	}
+
+	msg := m.message()
+	m.emitting.Lock()
+	m.Unlock()
+
+	m.emit(events)
+
+	for _, addr := range addrs {
+		m.send(addr, msg)
+	}
+}
+
+// alive returns the other alive members. The membership has to be locked.
+func (m *Membership) alive() []Member {
+	var members []Member
+	for name, mem := range m.members {
+		if name != m.self && mem.State == Alive {
+			members = append(members, mem.Member)
+		}
+	}
+	return members
+}
+
+// message encodes the alive and left members, dropping random ones if they
+// do not fit into a packet. The membership has to be locked.
+func (m *Membership) message() []byte {
+	members := make([]wireMember, 0, len(m.members))
+	for _, mem := range m.members {
+		if mem.State == Failed {
+			continue
+		}
+		members = append(members, wireMember{
+			Name:      mem.Name,
+			Addr:      mem.Addr,
+			Meta:      mem.Meta,
+			Heartbeat: mem.heartbeat,
+			Left:      mem.State == Left,
+		})
+	}
+
+	for {
+		data, err := json.Marshal(members)
+		if err == nil && (len(data) <= maxPacketSize || len(members) <= 1) {
+			return data
+		}
+
+		// keep the local member, which is spread by nobody else
+		rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		for i, mem := range members {
+			if mem.Name == m.self {
+				members[0], members[i] = members[i], members[0]
+				break
+			}
+		}
+		members = members[:len(members)/2]
+	}
+}
+
+func (m *Membership) send(addr string, msg []byte) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.conn.WriteTo(msg, udpAddr)
+
+	return err
+}
+
+// receive merges the member lists gossiped by other members.
+func (m *Membership) receive() {
+	defer m.wg.Done()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := m.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-m.stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		var members []wireMember
+		if err := json.Unmarshal(buf[:n], &members); err != nil {
+			continue
+		}
+
+		m.merge(members)
+	}
+}
+
+// merge updates the local view with newer heartbeats of members.
+func (m *Membership) merge(members []wireMember) {
+	now := time.Now()
+	var events []Event
+
+	m.Lock()
+	for _, wm := range members {
+		if wm.Name == m.self {
+			continue
+		}
+
+		mem, ok := m.members[wm.Name]
+		if ok && wm.Heartbeat <= mem.heartbeat {
+			continue
+		}
+		if !ok {
+			if wm.Left {
+				continue
+			}
+			mem = &member{}
+			m.members[wm.Name] = mem
+		}
+
+		prev := mem.State
+		mem.Member = Member{Name: wm.Name, Addr: wm.Addr, Meta: wm.Meta}
+		mem.heartbeat = wm.Heartbeat
+		mem.seen = now
+
+		switch {
+		case wm.Left:
+			mem.State = Left
+			if ok && prev == Alive {
+				events = append(events, Event{Type: Leave, Member: mem.Member})
+			}
+		case !ok || prev != Alive:
+			events = append(events, Event{Type: Join, Member: mem.Member})
+		}
+	}
+	m.emitting.Lock()
+	m.Unlock()
+
+	m.emit(events)
+}
+
+// emit passes events to the event handler and releases emitting.
+func (m *Membership) emit(events []Event) {
+	defer m.emitting.Unlock()
+
+	if m.onEvent == nil {
+		return
+	}
+	for _, ev := range events {
+		m.onEvent(ev)
+	}
+}
@@ -0,0 +1,152 @@
+package gossip
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func names(members []Member) []string {
+	var n []string
+	for _, m := range members {
+		n = append(n, m.Name)
+	}
+	sort.Strings(n)
+	return n
+}
+
+type recorder struct {
+	events []Event
+	sync.Mutex
+}
+
+func (r *recorder) handle(ev Event) {
+	r.Lock()
+	r.events = append(r.events, ev)
+	r.Unlock()
+}
+
+func (r *recorder) has(typ EventType, name string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, ev := range r.events {
+		if ev.Type == typ && ev.Member.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestMembers(t *testing.T, n int, rec *recorder) []*Membership {
+	var members []*Membership
+	for i := 0; i < n; i++ {
+		opts := []Option{
+			WithInterval(10 * time.Millisecond),
+			WithFailureTimeout(200 * time.Millisecond),
+			WithMeta("meta" + strconv.Itoa(i)),
+		}
+		if i == 0 && rec != nil {
+			opts = append(opts, WithEventHandler(rec.handle))
+		}
+
+		m, err := New("node"+strconv.Itoa(i), "127.0.0.1:0", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { m.Close() })
+
+		if i > 0 {
+			// every node only knows the first one
+			m.Join(members[0].Addr())
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+func TestMembership(t *testing.T) {
+	rec := &recorder{}
+	members := newTestMembers(t, 4, rec)
+
+	for _, m := range members {
+		if !waitFor(func() bool { return len(m.Members()) == 4 }) {
+			t.Error("Members should have discovered each other, got", names(m.Members()))
+			t.Fail()
+		}
+	}
+
+	for _, m := range members[1].Members() {
+		if m.Meta != "meta"+m.Name[len("node"):] {
+			t.Error("Unexpected meta", m.Meta, "of", m.Name)
+			t.Fail()
+		}
+	}
+
+	if !rec.has(Join, "node3") {
+		t.Error("Join should have been reported.")
+		t.Fail()
+	}
+}
+
+func TestMembershipFailure(t *testing.T) {
+	rec := &recorder{}
+	members := newTestMembers(t, 3, rec)
+
+	for _, m := range members {
+		if !waitFor(func() bool { return len(m.Members()) == 3 }) {
+			t.Fatal("Members should have discovered each other.")
+		}
+	}
+
+	members[1].Leave()
+	members[2].Close()
+
+	if !waitFor(func() bool { return rec.has(Leave, "node1") && rec.has(Fail, "node2") }) {
+		t.Error("Leave and failure should have been detected.")
+		t.Fail()
+	}
+
+	if got := names(members[0].Members()); len(got) != 1 || got[0] != "node0" {
+		t.Error("Expected only the local member, got", got)
+		t.Fail()
+	}
+
+	// a failed member coming back rejoins
+	m, err := New("node2", "127.0.0.1:0", WithInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	m.Join(members[0].Addr())
+
+	if !waitFor(func() bool { return len(members[0].Members()) == 2 }) {
+		t.Error("Restarted member should have rejoined.")
+		t.Fail()
+	}
+}
+
+func TestWithInterval(t *testing.T) {
+	m, err := New("node0", "127.0.0.1:0", WithInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.interval != 200*time.Millisecond {
+		t.Error("Expected the default interval. Got", m.interval)
+		t.Fail()
+	}
+}
@@ -0,0 +1,298 @@
+// Package bolt provides a persistent cache.Layer backed by a bbolt database,
+// e.g. to let large cold datasets overflow from memory to disk.
+package bolt
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"go.etcd.io/bbolt"
+)
+
+// Layer is a cache.Layer storing values in a bbolt database. Expired values
+// are removed lazily when they are read or by Purge.
+type Layer struct {
+	db      *bbolt.DB
+	bucket  []byte
+	codec   cache.Codec
+	onError func(error)
+
+	stats cache.Stats
+	sync.Mutex
+}
+
+var _ cache.Layer = (*Layer)(nil)
+
+// Option configures a Layer on creation.
+type Option func(*Layer)
+
+// WithCodec sets the codec used to serialize values. The default is
+// cache.GobCodec.
+func WithCodec(codec cache.Codec) Option {
+	return func(l *Layer) {
+		l.codec = codec
+	}
+}
+
+// WithBucket sets the name of the bucket values are stored in. The default is
+// "layercake".
+func WithBucket(name string) Option {
+	return func(l *Layer) {
+		l.bucket = []byte(name)
+	}
+}
+
+// WithErrorHandler sets a function that is called with database and codec
+// errors. Failed reads are treated as misses.
+func WithErrorHandler(handler func(error)) Option {
+	return func(l *Layer) {
+		l.onError = handler
+	}
+}
+
+// Open opens or creates the database at path and returns a Layer using it.
+func Open(path string, opts ...Option) (*Layer, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := New(db, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// New returns a reference to a new Layer using an already opened database.
+func New(db *bbolt.DB, opts ...Option) (*Layer, error) {
+	l := &Layer{
+		db:     db,
+		bucket: []byte("layercake"),
+		codec:  cache.GobCodec{},
+		stats:  cache.Stats{Uptime: time.Now().UTC()},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(l.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Close closes the underlying database.
+func (l *Layer) Close() error {
+	return l.db.Close()
+}
+
+// Get retrieves a value stored with a specific key. If no value is available
+// nil and false will be returned.
+func (l *Layer) Get(key string) (interface{}, bool) {
+	var data []byte
+	var expired bool
+
+	err := l.db.View(func(tx *bbolt.Tx) error {
+		record := tx.Bucket(l.bucket).Get([]byte(key))
+		if record == nil {
+			return nil
+		}
+
+		if isExpired(record, time.Now()) {
+			expired = true
+			return nil
+		}
+
+		// the record is only valid during the transaction
+		data = append([]byte(nil), record[8:]...)
+		return nil
+	})
+	if err != nil {
+		l.handleError(err)
+	}
+
+	if expired {
+		l.removeExpired(key)
+	}
+
+	if data == nil {
+		l.count(func(s *cache.Stats) { s.Misses++ })
+		return nil, false
+	}
+
+	v, err := l.codec.Unmarshal(data)
+	if err != nil {
+		l.handleError(err)
+		l.count(func(s *cache.Stats) { s.Misses++ })
+		return nil, false
+	}
+
+	l.count(func(s *cache.Stats) { s.Hits++ })
+
+	return v, true
+}
+
+// Set stores the value with the given key.
+func (l *Layer) Set(key string, value interface{}) {
+	l.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores the value with the given key and removes it after ttl. A
+// ttl of zero or less stores the value without expiry.
+func (l *Layer) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	data, err := l.codec.Marshal(value)
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+
+	record := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(record, uint64(expires))
+	copy(record[8:], data)
+
+	err = l.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(l.bucket).Put([]byte(key), record)
+	})
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	l.count(func(s *cache.Stats) { s.Set++ })
+}
+
+// Remove deletes a value stored with the given key.
+func (l *Layer) Remove(key string) {
+	var removed bool
+
+	err := l.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(l.bucket)
+		if b.Get([]byte(key)) == nil {
+			return nil
+		}
+
+		removed = true
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	if removed {
+		l.count(func(s *cache.Stats) { s.Removed++ })
+	}
+}
+
+// Purge deletes all expired values from the database and returns how many
+// were deleted.
+func (l *Layer) Purge() int {
+	var n int
+	now := time.Now()
+
+	err := l.db.Update(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(l.bucket).Cursor()
+		for k, record := c.First(); k != nil; {
+			if !isExpired(record, now) {
+				k, record = c.Next()
+				continue
+			}
+
+			deleted := append([]byte(nil), k...)
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			n++
+			// continue after the deleted key, Next would skip an element
+			k, record = c.Seek(deleted)
+		}
+		return nil
+	})
+	if err != nil {
+		l.handleError(err)
+	}
+
+	l.count(func(s *cache.Stats) { s.Expired += n })
+
+	return n
+}
+
+// GetStats returns Stats for the operations performed by this layer and the
+// number of records in the database, including expired ones not purged yet.
+func (l *Layer) GetStats() *cache.Stats {
+	var entries int
+	err := l.db.View(func(tx *bbolt.Tx) error {
+		entries = tx.Bucket(l.bucket).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		l.handleError(err)
+	}
+
+	l.Lock()
+	s := l.stats
+	l.Unlock()
+
+	s.Entries = entries
+	s.SetHitRatio()
+
+	return &s
+}
+
+// removeExpired deletes the record with the given key if it is still expired.
+func (l *Layer) removeExpired(key string) {
+	var removed bool
+
+	err := l.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(l.bucket)
+		record := b.Get([]byte(key))
+		if record == nil || !isExpired(record, time.Now()) {
+			return nil
+		}
+
+		removed = true
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	if removed {
+		l.count(func(s *cache.Stats) { s.Expired++ })
+	}
+}
+
+// isExpired reports whether the expiry stored in the first eight bytes of the
+// record has passed.
+func isExpired(record []byte, now time.Time) bool {
+	expires := int64(binary.BigEndian.Uint64(record))
+	return expires != 0 && now.UnixNano() >= expires
+}
+
+func (l *Layer) count(f func(*cache.Stats)) {
+	l.Lock()
+	f(&l.stats)
+	l.Unlock()
+}
+
+func (l *Layer) handleError(err error) {
+	if l.onError != nil {
+		l.onError(err)
+	}
+}
@@ -0,0 +1,130 @@
+package bolt
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+func newTestLayer(t *testing.T) *Layer {
+	l, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal("Could not open database", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	return l
+}
+
+func TestSetGet(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l := newTestLayer(t)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should not have been found.")
+		t.Fail()
+	}
+
+	l.Set(key, value)
+
+	v, ok := l.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	l.Remove(key)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+
+	s := l.GetStats()
+	if s.Hits != 1 || s.Misses != 2 || s.Set != 1 || s.Removed != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+		t.Fail()
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal("Could not open database", err)
+	}
+
+	l.Set(key, value)
+	l.Close()
+
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal("Could not open database", err)
+	}
+	defer l.Close()
+
+	v, ok := l.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
+
+func TestSetWithTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l := newTestLayer(t)
+
+	for i := 0; i < 10; i++ {
+		l.SetWithTTL(key+strconv.Itoa(i), value, 10*time.Millisecond)
+	}
+	l.Set(key, value)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := l.Get(key + "0"); ok {
+		t.Error("Element should have expired.")
+		t.Fail()
+	}
+
+	if n := l.Purge(); n != 9 {
+		t.Errorf("Expected 9 expired elements to be purged. Got %d", n)
+		t.Fail()
+	}
+
+	if _, ok := l.Get(key); !ok {
+		t.Error("Element without ttl should not have been purged.")
+		t.Fail()
+	}
+}
+
+func TestLayered(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := cache.New()
+	l2 := newTestLayer(t)
+
+	l := cache.NewLayered(
+		cache.Tier{Layer: l1},
+		cache.Tier{Layer: l2},
+	)
+
+	l.Set(key, value)
+	l1.Remove(key)
+
+	v, ok := l.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+}
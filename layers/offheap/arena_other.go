@@ -0,0 +1,14 @@
+//go:build !unix
+
+package offheap
+
+// allocate falls back to a single large heap allocation on platforms without
+// mmap. It contains no pointers, so it does not add to GC scan times.
+func allocate(size int) ([]byte, error) {
+	return make([]byte, size), nil
+}
+
+// release is a no-op for heap allocated regions.
+func release(buf []byte) error {
+	return nil
+}
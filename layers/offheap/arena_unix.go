@@ -0,0 +1,16 @@
+//go:build unix
+
+package offheap
+
+import "syscall"
+
+// allocate maps an anonymous memory region of the given size outside of the
+// Go heap.
+func allocate(size int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+// release unmaps a region returned by allocate.
+func release(buf []byte) error {
+	return syscall.Munmap(buf)
+}
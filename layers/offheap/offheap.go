@@ -0,0 +1,371 @@
+// Package offheap provides a cache.Layer keeping serialized values in large
+// memory mapped arenas outside of the Go heap. Each shard appends entries to
+// a ring buffer and indexes them by a 64 bit hash of their key, so neither the
+// values nor the index contain pointers the garbage collector has to scan.
+// Once a shard is full the oldest entries are overwritten.
+package offheap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// header layout: expires (8 bytes), hash (8 bytes), key length (2 bytes),
+// value length (4 bytes)
+const headerSize = 22
+
+// ErrTooLarge is reported if an entry does not fit into a shard.
+var ErrTooLarge = errors.New("offheap: entry too large")
+
+// Layer is a cache.Layer storing serialized values off-heap.
+type Layer struct {
+	shards  []*shard
+	codec   cache.Codec
+	onError func(error)
+
+	uptime time.Time
+}
+
+var _ cache.Layer = (*Layer)(nil)
+
+type shard struct {
+	index map[uint64]uint32
+	buf   []byte
+
+	// head is the offset of the oldest entry, tail the offset the next entry
+	// is written to. If the buffer wrapped, entries are stored in
+	// [head, end) followed by [0, tail), otherwise in [head, tail).
+	head    int
+	tail    int
+	end     int
+	wrapped bool
+	entries int
+
+	stats cache.Stats
+	sync.Mutex
+}
+
+// Option configures a Layer on creation.
+type Option func(*config)
+
+type config struct {
+	shards    int
+	shardSize int
+	codec     cache.Codec
+	onError   func(error)
+}
+
+// WithShards sets the number of shards. The default is 64.
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+// WithShardSize sets the size of the arena of each shard in bytes. The
+// default is 4 MiB, the maximum 4 GiB.
+func WithShardSize(size int) Option {
+	return func(c *config) {
+		c.shardSize = size
+	}
+}
+
+// WithCodec sets the codec used to serialize values. The default is
+// cache.GobCodec.
+func WithCodec(codec cache.Codec) Option {
+	return func(c *config) {
+		c.codec = codec
+	}
+}
+
+// WithErrorHandler sets a function that is called with codec errors and
+// entries too large to be stored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// New allocates the arenas and returns a reference to a new Layer. The arenas
+// are released by Close.
+func New(opts ...Option) (*Layer, error) {
+	cfg := config{
+		shards:    64,
+		shardSize: 4 << 20,
+		codec:     cache.GobCodec{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.shards < 1 || cfg.shardSize < headerSize || int64(cfg.shardSize) > 1<<32 {
+		return nil, errors.New("offheap: invalid shard configuration")
+	}
+
+	l := &Layer{
+		shards:  make([]*shard, cfg.shards),
+		codec:   cfg.codec,
+		onError: cfg.onError,
+		uptime:  time.Now().UTC(),
+	}
+
+	for i := range l.shards {
+		buf, err := allocate(cfg.shardSize)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.shards[i] = &shard{
+			index: make(map[uint64]uint32),
+			buf:   buf,
+		}
+	}
+
+	return l, nil
+}
+
+// Close releases the arenas. The layer must not be used afterwards.
+func (l *Layer) Close() error {
+	var err error
+	for _, s := range l.shards {
+		if s == nil {
+			continue
+		}
+		s.Lock()
+		if e := release(s.buf); e != nil {
+			err = e
+		}
+		s.buf = nil
+		s.index = nil
+		s.Unlock()
+	}
+	return err
+}
+
+// Get retrieves a value stored with a specific key. If no value is available
+// nil and false will be returned.
+func (l *Layer) Get(key string) (interface{}, bool) {
+	h := hash(key)
+	s := l.getShard(h)
+	s.Lock()
+
+	off, ok := s.lookup(key, h)
+	if !ok {
+		s.stats.Misses++
+		s.Unlock()
+		return nil, false
+	}
+
+	if expires := s.expires(off); expires != 0 && time.Now().UnixNano() >= expires {
+		delete(s.index, h)
+		s.stats.Expired++
+		s.stats.Misses++
+		s.Unlock()
+		return nil, false
+	}
+
+	// copy the value so it can be decoded without holding the lock
+	data := append([]byte(nil), s.value(off)...)
+	s.stats.Hits++
+	s.Unlock()
+
+	v, err := l.codec.Unmarshal(data)
+	if err != nil {
+		l.handleError(err)
+		return nil, false
+	}
+
+	return v, true
+}
+
+// Set stores the value with the given key.
+func (l *Layer) Set(key string, value interface{}) {
+	l.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores the value with the given key and removes it after ttl. A
+// ttl of zero or less stores the value without expiry.
+func (l *Layer) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	data, err := l.codec.Marshal(value)
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+
+	h := hash(key)
+	s := l.getShard(h)
+	s.Lock()
+	defer s.Unlock()
+
+	size := headerSize + len(key) + len(data)
+	if len(key) > 1<<16-1 || size > len(s.buf) {
+		l.handleError(ErrTooLarge)
+		return
+	}
+
+	off := s.push(size)
+	binary.BigEndian.PutUint64(s.buf[off:], uint64(expires))
+	binary.BigEndian.PutUint64(s.buf[off+8:], h)
+	binary.BigEndian.PutUint16(s.buf[off+16:], uint16(len(key)))
+	binary.BigEndian.PutUint32(s.buf[off+18:], uint32(len(data)))
+	copy(s.buf[off+headerSize:], key)
+	copy(s.buf[off+headerSize+len(key):], data)
+
+	s.index[h] = uint32(off)
+	s.stats.Set++
+}
+
+// Remove deletes a value stored with the given key. Its memory is reclaimed
+// once the ring buffer of the shard wraps around.
+func (l *Layer) Remove(key string) {
+	h := hash(key)
+	s := l.getShard(h)
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.lookup(key, h); ok {
+		delete(s.index, h)
+		s.stats.Removed++
+	}
+}
+
+// GetStats returns Stats for this layer.
+func (l *Layer) GetStats() *cache.Stats {
+	st := cache.Stats{Uptime: l.uptime}
+
+	for _, s := range l.shards {
+		s.Lock()
+		st.Hits += s.stats.Hits
+		st.Misses += s.stats.Misses
+		st.Set += s.stats.Set
+		st.Removed += s.stats.Removed
+		st.Expired += s.stats.Expired
+		st.Evicted += s.stats.Evicted
+		st.Entries += len(s.index)
+		s.Unlock()
+	}
+	st.SetHitRatio()
+
+	return &st
+}
+
+func (l *Layer) getShard(h uint64) *shard {
+	return l.shards[h%uint64(len(l.shards))]
+}
+
+func (l *Layer) handleError(err error) {
+	if l.onError != nil {
+		l.onError(err)
+	}
+}
+
+// lookup returns the offset of the entry with the given key.
+func (s *shard) lookup(key string, h uint64) (int, bool) {
+	o, ok := s.index[h]
+	if !ok {
+		return 0, false
+	}
+
+	off := int(o)
+	// guard against hash collisions
+	if s.key(off) != key {
+		return 0, false
+	}
+
+	return off, true
+}
+
+func (s *shard) expires(off int) int64 {
+	return int64(binary.BigEndian.Uint64(s.buf[off:]))
+}
+
+func (s *shard) key(off int) string {
+	n := int(binary.BigEndian.Uint16(s.buf[off+16:]))
+	return string(s.buf[off+headerSize : off+headerSize+n])
+}
+
+func (s *shard) value(off int) []byte {
+	n := int(binary.BigEndian.Uint16(s.buf[off+16:]))
+	m := int(binary.BigEndian.Uint32(s.buf[off+18:]))
+	start := off + headerSize + n
+	return s.buf[start : start+m]
+}
+
+func (s *shard) size(off int) int {
+	n := int(binary.BigEndian.Uint16(s.buf[off+16:]))
+	m := int(binary.BigEndian.Uint32(s.buf[off+18:]))
+	return headerSize + n + m
+}
+
+// push reserves size bytes in the ring buffer, overwriting the oldest entries
+// if necessary, and returns their offset. size must not exceed the buffer.
+func (s *shard) push(size int) int {
+	for {
+		if !s.wrapped {
+			if s.tail+size <= len(s.buf) {
+				break
+			}
+			// continue at the start of the buffer
+			s.wrapped = true
+			s.end = s.tail
+			s.tail = 0
+			continue
+		}
+
+		if s.tail+size <= s.head {
+			break
+		}
+		s.evict()
+	}
+
+	off := s.tail
+	s.tail += size
+	s.entries++
+
+	return off
+}
+
+// evict drops the oldest entry of the ring buffer.
+func (s *shard) evict() {
+	h := binary.BigEndian.Uint64(s.buf[s.head+8:])
+	if o, ok := s.index[h]; ok && int(o) == s.head {
+		delete(s.index, h)
+		s.stats.Evicted++
+	}
+
+	s.head += s.size(s.head)
+	s.entries--
+
+	if s.entries == 0 {
+		s.head, s.tail, s.end, s.wrapped = 0, 0, 0, false
+		return
+	}
+
+	if s.wrapped && s.head >= s.end {
+		s.head, s.end, s.wrapped = 0, 0, false
+	}
+}
+
+// hash returns the 64 bit FNV-1a hash of key without allocating.
+func hash(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	h := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime64
+	}
+	return h
+}
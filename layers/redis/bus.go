@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// idLength is the length of the instance id prefixing published keys.
+const idLength = 16
+
+// Bus is a cache.InvalidationBus using Redis pub/sub. Each message holds the
+// id of the publishing instance followed by the key, so instances ignore
+// their own messages. Messages published while an instance is disconnected
+// from Redis are lost.
+type Bus struct {
+	client  goredis.UniversalClient
+	channel string
+	id      string
+	timeout time.Duration
+
+	pubsub *goredis.PubSub
+	done   chan struct{}
+	sync.Mutex
+}
+
+var _ cache.InvalidationBus = (*Bus)(nil)
+
+// NewBus returns a reference to a new Bus publishing on the given Redis
+// channel.
+func NewBus(client goredis.UniversalClient, channel string) *Bus {
+	id := make([]byte, idLength/2)
+	rand.Read(id)
+
+	return &Bus{
+		client:  client,
+		channel: channel,
+		id:      hex.EncodeToString(id),
+		timeout: time.Second,
+	}
+}
+
+// Publish broadcasts an invalidated key.
+func (b *Bus) Publish(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+
+	return b.client.Publish(ctx, b.channel, b.id+key).Err()
+}
+
+// Subscribe subscribes to the channel and calls invalidate with the keys
+// published by other instances until the Bus is closed.
+func (b *Bus) Subscribe(invalidate func(key string)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// wait for the subscription to be confirmed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	b.Lock()
+	b.pubsub = pubsub
+	b.done = make(chan struct{})
+	b.Unlock()
+
+	go func() {
+		defer close(b.done)
+
+		for msg := range pubsub.Channel() {
+			if len(msg.Payload) < idLength || msg.Payload[:idLength] == b.id {
+				continue
+			}
+			invalidate(msg.Payload[idLength:])
+		}
+	}()
+
+	return nil
+}
+
+// Close ends the subscription.
+func (b *Bus) Close() error {
+	b.Lock()
+	pubsub, done := b.pubsub, b.done
+	b.pubsub = nil
+	b.Unlock()
+
+	if pubsub == nil {
+		return nil
+	}
+
+	err := pubsub.Close()
+	<-done
+
+	return err
+}
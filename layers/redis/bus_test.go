@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mkrull/layercake/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestBus(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	bus1 := NewBus(client, "invalidations")
+	defer bus1.Close()
+	bus2 := NewBus(client, "invalidations")
+	defer bus2.Close()
+
+	c1 := cache.New(cache.WithInvalidationBus(bus1))
+	c2 := cache.New(cache.WithInvalidationBus(bus2))
+
+	c2.Set(key, value)
+	// let the invalidation of c2 reach c1 before modifying the key again
+	time.Sleep(50 * time.Millisecond)
+
+	c1.Remove(key)
+	c1.Set("other", value)
+
+	invalidated := false
+	for i := 0; i < 100 && !invalidated; i++ {
+		_, ok := c2.Get(key)
+		invalidated = !ok
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !invalidated {
+		t.Error("Remove on another instance should have invalidated the key.")
+		t.Fail()
+	}
+
+	if _, ok := c1.Get("other"); !ok {
+		t.Error("Key should not have been invalidated on the instance setting it.")
+		t.Fail()
+	}
+}
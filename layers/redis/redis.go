@@ -0,0 +1,170 @@
+// Package redis provides a cache.Layer backed by Redis, e.g. to use it as a
+// shared lower tier behind an in-memory cache.Cache. It also provides a
+// cache.InvalidationBus over Redis pub/sub.
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Layer is a cache.Layer storing values in Redis.
+type Layer struct {
+	client  goredis.UniversalClient
+	codec   cache.Codec
+	prefix  string
+	timeout time.Duration
+	onError func(error)
+
+	stats cache.Stats
+	sync.Mutex
+}
+
+var _ cache.Layer = (*Layer)(nil)
+
+// Option configures a Layer on creation.
+type Option func(*Layer)
+
+// WithCodec sets the codec used to serialize values. The default is
+// cache.GobCodec.
+func WithCodec(codec cache.Codec) Option {
+	return func(l *Layer) {
+		l.codec = codec
+	}
+}
+
+// WithPrefix prefixes all keys stored in Redis.
+func WithPrefix(prefix string) Option {
+	return func(l *Layer) {
+		l.prefix = prefix
+	}
+}
+
+// WithTimeout sets the timeout of a single Redis command. The default is one
+// second.
+func WithTimeout(timeout time.Duration) Option {
+	return func(l *Layer) {
+		l.timeout = timeout
+	}
+}
+
+// WithErrorHandler sets a function that is called with Redis and codec
+// errors. Failed reads are treated as misses.
+func WithErrorHandler(handler func(error)) Option {
+	return func(l *Layer) {
+		l.onError = handler
+	}
+}
+
+// New returns a reference to a new Layer using the given client.
+func New(client goredis.UniversalClient, opts ...Option) *Layer {
+	l := &Layer{
+		client:  client,
+		codec:   cache.GobCodec{},
+		timeout: time.Second,
+		stats:   cache.Stats{Uptime: time.Now().UTC()},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Get retrieves a value stored with a specific key. If no value is available
+// nil and false will be returned.
+func (l *Layer) Get(key string) (interface{}, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	data, err := l.client.Get(ctx, l.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			l.handleError(err)
+		}
+		l.count(func(s *cache.Stats) { s.Misses++ })
+		return nil, false
+	}
+
+	v, err := l.codec.Unmarshal(data)
+	if err != nil {
+		l.handleError(err)
+		l.count(func(s *cache.Stats) { s.Misses++ })
+		return nil, false
+	}
+
+	l.count(func(s *cache.Stats) { s.Hits++ })
+
+	return v, true
+}
+
+// Set stores the value with the given key.
+func (l *Layer) Set(key string, value interface{}) {
+	l.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores the value with the given key and lets Redis remove it
+// after ttl. A ttl of zero or less stores the value without expiry.
+func (l *Layer) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	data, err := l.codec.Marshal(value)
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	if err := l.client.Set(ctx, l.prefix+key, data, ttl).Err(); err != nil {
+		l.handleError(err)
+		return
+	}
+
+	l.count(func(s *cache.Stats) { s.Set++ })
+}
+
+// Remove deletes a value stored with the given key.
+func (l *Layer) Remove(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	n, err := l.client.Del(ctx, l.prefix+key).Result()
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	if n > 0 {
+		l.count(func(s *cache.Stats) { s.Removed++ })
+	}
+}
+
+// GetStats returns Stats for the operations performed by this layer.
+func (l *Layer) GetStats() *cache.Stats {
+	l.Lock()
+	defer l.Unlock()
+
+	s := l.stats
+	s.SetHitRatio()
+	return &s
+}
+
+func (l *Layer) count(f func(*cache.Stats)) {
+	l.Lock()
+	f(&l.stats)
+	l.Unlock()
+}
+
+func (l *Layer) handleError(err error) {
+	if l.onError != nil {
+		l.onError(err)
+	}
+}
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mkrull/layercake/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newTestLayer(t *testing.T, opts ...Option) (*Layer, *miniredis.Miniredis) {
+	srv := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return New(client, opts...), srv
+}
+
+func TestSetGet(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l, _ := newTestLayer(t)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should not have been found.")
+		t.Fail()
+	}
+
+	l.Set(key, value)
+
+	v, ok := l.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	l.Remove(key)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+
+	s := l.GetStats()
+	if s.Hits != 1 || s.Misses != 2 || s.Set != 1 || s.Removed != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+		t.Fail()
+	}
+}
+
+func TestSetWithTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l, srv := newTestLayer(t, WithPrefix("test:"))
+
+	l.SetWithTTL(key, value, time.Second)
+
+	if !srv.Exists("test:" + key) {
+		t.Error("Element should have been stored with prefix.")
+		t.Fail()
+	}
+
+	srv.FastForward(2 * time.Second)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should have expired.")
+		t.Fail()
+	}
+}
+
+func TestLayered(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l1 := cache.New()
+	l2, _ := newTestLayer(t)
+
+	l := cache.NewLayered(
+		cache.Tier{Layer: l1},
+		cache.Tier{Layer: l2},
+	)
+
+	l2.Set(key, value)
+
+	v, ok := l.Get(key)
+	if !ok || v != value {
+		t.Error("Expected", value, "got", v)
+		t.Fail()
+	}
+
+	if _, ok := l1.Get(key); !ok {
+		t.Error("Upper tier should have been populated.")
+		t.Fail()
+	}
+}
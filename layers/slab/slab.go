@@ -0,0 +1,344 @@
+// Package slab provides a cache.Layer keeping serialized entries in large
+// byte slabs on the Go heap instead of a map of pointers, so caches with tens
+// of millions of entries do not inflate the time the garbage collector spends
+// scanning. Each shard appends entries to its current slab and indexes them
+// by a 64 bit hash of their key in a map without pointers. Slabs are allocated
+// as needed up to the maximum size of the layer, after that the oldest slab
+// is reused and its entries are evicted. The memory of removed and replaced
+// entries is reclaimed once their slab is reused.
+//
+// Unlike package offheap the slabs are regular heap allocations, which need
+// no platform support and are released by the garbage collector.
+package slab
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// header layout: expires (8 bytes), hash (8 bytes), key length (2 bytes),
+// value length (4 bytes)
+const headerSize = 22
+
+// ErrTooLarge is reported if an entry does not fit into a slab.
+var ErrTooLarge = errors.New("slab: entry too large")
+
+// Layer is a cache.Layer storing serialized values in byte slabs.
+type Layer struct {
+	shards  []*shard
+	codec   cache.Codec
+	onError func(error)
+
+	uptime time.Time
+}
+
+var _ cache.Layer = (*Layer)(nil)
+
+type shard struct {
+	// index holds the location of the entry of each key hash, the index of
+	// the slab in the upper and the offset in the slab in the lower 32 bits
+	index map[uint64]uint64
+	// slabs are allocated when they are first written to
+	slabs [][]byte
+	// used holds the number of bytes written to each slab
+	used []int
+	// cur is the index of the slab entries are appended to
+	cur      int
+	slabSize int
+
+	stats cache.Stats
+	sync.Mutex
+}
+
+// Option configures a Layer on creation.
+type Option func(*config)
+
+type config struct {
+	shards   int
+	slabSize int
+	maxSize  int64
+	codec    cache.Codec
+	onError  func(error)
+}
+
+// WithShards sets the number of shards. The default is 64.
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+// WithSlabSize sets the size of a slab in bytes, which limits the size of an
+// entry. The default is 1 MiB, the maximum 4 GiB.
+func WithSlabSize(size int) Option {
+	return func(c *config) {
+		c.slabSize = size
+	}
+}
+
+// WithMaxSize sets the maximum size of all slabs in bytes. Each shard gets at
+// least one slab. The default is 256 MiB.
+func WithMaxSize(size int64) Option {
+	return func(c *config) {
+		c.maxSize = size
+	}
+}
+
+// WithCodec sets the codec used to serialize values. The default is
+// cache.GobCodec.
+func WithCodec(codec cache.Codec) Option {
+	return func(c *config) {
+		c.codec = codec
+	}
+}
+
+// WithErrorHandler sets a function that is called with codec errors and
+// entries too large to be stored.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// New allocates the first slab of each shard and returns a reference to a new
+// Layer.
+func New(opts ...Option) (*Layer, error) {
+	cfg := config{
+		shards:   64,
+		slabSize: 1 << 20,
+		maxSize:  256 << 20,
+		codec:    cache.GobCodec{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.shards < 1 || cfg.slabSize < headerSize || int64(cfg.slabSize) > 1<<32 {
+		return nil, errors.New("slab: invalid shard configuration")
+	}
+
+	slabs := int(max(cfg.maxSize/int64(cfg.shards)/int64(cfg.slabSize), 1))
+
+	l := &Layer{
+		shards:  make([]*shard, cfg.shards),
+		codec:   cfg.codec,
+		onError: cfg.onError,
+		uptime:  time.Now().UTC(),
+	}
+
+	for i := range l.shards {
+		s := &shard{
+			index:    make(map[uint64]uint64),
+			slabs:    make([][]byte, slabs),
+			used:     make([]int, slabs),
+			slabSize: cfg.slabSize,
+		}
+		s.slabs[0] = make([]byte, cfg.slabSize)
+		l.shards[i] = s
+	}
+
+	return l, nil
+}
+
+// Get retrieves a value stored with a specific key. If no value is available
+// nil and false will be returned.
+func (l *Layer) Get(key string) (interface{}, bool) {
+	h := hash(key)
+	s := l.getShard(h)
+	s.Lock()
+
+	buf, ok := s.lookup(key, h)
+	if !ok {
+		s.stats.Misses++
+		s.Unlock()
+		return nil, false
+	}
+
+	if expires := int64(binary.BigEndian.Uint64(buf)); expires != 0 && time.Now().UnixNano() >= expires {
+		delete(s.index, h)
+		s.stats.Expired++
+		s.stats.Misses++
+		s.Unlock()
+		return nil, false
+	}
+
+	// copy the value so it can be decoded without holding the lock
+	data := append([]byte(nil), value(buf)...)
+	s.stats.Hits++
+	s.Unlock()
+
+	v, err := l.codec.Unmarshal(data)
+	if err != nil {
+		l.handleError(err)
+		return nil, false
+	}
+
+	return v, true
+}
+
+// Set stores the value with the given key.
+func (l *Layer) Set(key string, value interface{}) {
+	l.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores the value with the given key and removes it after ttl. A
+// ttl of zero or less stores the value without expiry.
+func (l *Layer) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	data, err := l.codec.Marshal(value)
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+
+	h := hash(key)
+	s := l.getShard(h)
+	s.Lock()
+	defer s.Unlock()
+
+	size := headerSize + len(key) + len(data)
+	if len(key) > 1<<16-1 || size > s.slabSize {
+		l.handleError(ErrTooLarge)
+		return
+	}
+
+	n, off := s.push(size)
+	buf := s.slabs[n][off:]
+	binary.BigEndian.PutUint64(buf, uint64(expires))
+	binary.BigEndian.PutUint64(buf[8:], h)
+	binary.BigEndian.PutUint16(buf[16:], uint16(len(key)))
+	binary.BigEndian.PutUint32(buf[18:], uint32(len(data)))
+	copy(buf[headerSize:], key)
+	copy(buf[headerSize+len(key):], data)
+
+	s.index[h] = location(n, off)
+	s.stats.Set++
+}
+
+// Remove deletes a value stored with the given key. Its memory is reclaimed
+// once its slab is reused.
+func (l *Layer) Remove(key string) {
+	h := hash(key)
+	s := l.getShard(h)
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.lookup(key, h); ok {
+		delete(s.index, h)
+		s.stats.Removed++
+	}
+}
+
+// GetStats returns Stats for this layer.
+func (l *Layer) GetStats() *cache.Stats {
+	st := cache.Stats{Uptime: l.uptime}
+
+	for _, s := range l.shards {
+		s.Lock()
+		st.Hits += s.stats.Hits
+		st.Misses += s.stats.Misses
+		st.Set += s.stats.Set
+		st.Removed += s.stats.Removed
+		st.Expired += s.stats.Expired
+		st.Evicted += s.stats.Evicted
+		st.Entries += len(s.index)
+		s.Unlock()
+	}
+	st.SetHitRatio()
+
+	return &st
+}
+
+func (l *Layer) getShard(h uint64) *shard {
+	return l.shards[h%uint64(len(l.shards))]
+}
+
+func (l *Layer) handleError(err error) {
+	if l.onError != nil {
+		l.onError(err)
+	}
+}
+
+// location returns the index entry of the entry at offset off of slab n.
+func location(n, off int) uint64 {
+	return uint64(n)<<32 | uint64(off)
+}
+
+// lookup returns the slab holding the entry with the given key, starting at
+// the entry.
+func (s *shard) lookup(key string, h uint64) ([]byte, bool) {
+	loc, ok := s.index[h]
+	if !ok {
+		return nil, false
+	}
+
+	buf := s.slabs[loc>>32][uint32(loc):]
+	// guard against hash collisions
+	n := int(binary.BigEndian.Uint16(buf[16:]))
+	if string(buf[headerSize:headerSize+n]) != key {
+		return nil, false
+	}
+
+	return buf, true
+}
+
+// push reserves size bytes, continuing with the next slab if the current one
+// is full, and returns the index of the slab and their offset in it. size
+// must not exceed the size of a slab.
+func (s *shard) push(size int) (int, int) {
+	if s.used[s.cur]+size > s.slabSize {
+		s.cur = (s.cur + 1) % len(s.slabs)
+		s.reuse(s.cur)
+	}
+
+	off := s.used[s.cur]
+	s.used[s.cur] += size
+
+	return s.cur, off
+}
+
+// reuse prepares slab n to be written from the start, allocating it on first
+// use and otherwise evicting the entries it still holds.
+func (s *shard) reuse(n int) {
+	if s.slabs[n] == nil {
+		s.slabs[n] = make([]byte, s.slabSize)
+		return
+	}
+
+	slab := s.slabs[n]
+	for off := 0; off < s.used[n]; off += size(slab[off:]) {
+		h := binary.BigEndian.Uint64(slab[off+8:])
+		if loc, ok := s.index[h]; ok && loc == location(n, off) {
+			delete(s.index, h)
+			s.stats.Evicted++
+		}
+	}
+	s.used[n] = 0
+}
+
+// value returns the value of the entry at the start of buf.
+func value(buf []byte) []byte {
+	n := int(binary.BigEndian.Uint16(buf[16:]))
+	m := int(binary.BigEndian.Uint32(buf[18:]))
+	return buf[headerSize+n : headerSize+n+m]
+}
+
+// size returns the size of the entry at the start of buf.
+func size(buf []byte) int {
+	n := int(binary.BigEndian.Uint16(buf[16:]))
+	m := int(binary.BigEndian.Uint32(buf[18:]))
+	return headerSize + n + m
+}
+
+// hash returns the 64 bit FNV-1a hash of key without allocating.
+func hash(key string) uint64 {
+	return cache.FNVHasher{}.Hash(key)
+}
@@ -0,0 +1,137 @@
+package slab
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+func newTestLayer(t *testing.T, opts ...Option) *Layer {
+	l, err := New(opts...)
+	if err != nil {
+		t.Fatal("Could not create layer", err)
+	}
+
+	return l
+}
+
+func TestSetGet(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l := newTestLayer(t)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should not have been found.")
+		t.Fail()
+	}
+
+	l.Set(key, value)
+	l.Set(key, value+"New")
+
+	v, ok := l.Get(key)
+	if !ok || v != value+"New" {
+		t.Error("Expected", value+"New", "got", v)
+		t.Fail()
+	}
+
+	l.Remove(key)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should have been removed.")
+		t.Fail()
+	}
+
+	s := l.GetStats()
+	if s.Hits != 1 || s.Misses != 2 || s.Set != 2 || s.Removed != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+		t.Fail()
+	}
+}
+
+func TestSetWithTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	l := newTestLayer(t)
+
+	l.SetWithTTL(key, value, 10*time.Millisecond)
+
+	if _, ok := l.Get(key); !ok {
+		t.Error("Could not find test element in cache.")
+		t.Fail()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := l.Get(key); ok {
+		t.Error("Element should have expired.")
+		t.Fail()
+	}
+}
+
+func TestReuse(t *testing.T) {
+	key := "testKey"
+	value := strings.Repeat("v", 100)
+
+	l := newTestLayer(t, WithShards(1), WithSlabSize(1024), WithMaxSize(4096), WithCodec(cache.JSONCodec{}))
+
+	if n := len(l.shards[0].slabs); n != 4 {
+		t.Errorf("Expected 4 slabs. Got %d", n)
+		t.Fail()
+	}
+
+	for i := 0; i < 1000; i++ {
+		l.Set(key+strconv.Itoa(i), value)
+	}
+
+	if _, ok := l.Get(key + "0"); ok {
+		t.Error("Oldest element should have been evicted.")
+		t.Fail()
+	}
+
+	for i := 990; i < 1000; i++ {
+		v, ok := l.Get(key + strconv.Itoa(i))
+		if !ok || v != value {
+			t.Error("Expected newest elements to be found.")
+			t.Fail()
+		}
+	}
+
+	if s := l.GetStats(); s.Evicted == 0 || s.Evicted+len(l.shards[0].index) != 1000 {
+		t.Errorf("Expected reused elements to be counted as evicted. Got %d", s.Evicted)
+		t.Fail()
+	}
+
+	if len(l.shards[0].index) > 4096/(headerSize+len(value)) {
+		t.Errorf("Index should only contain live entries. Got %d", len(l.shards[0].index))
+		t.Fail()
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	var errs []error
+
+	l := newTestLayer(t, WithShards(1), WithSlabSize(64), WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	l.Set("testKey", strings.Repeat("v", 100))
+
+	if len(errs) != 1 || errs[0] != ErrTooLarge {
+		t.Error("Expected", ErrTooLarge, "got", errs)
+		t.Fail()
+	}
+}
+
+func BenchmarkLayer(b *testing.B) {
+	l, _ := New()
+
+	for i := 0; i < b.N; i++ {
+		l.Set(strconv.Itoa(i), "testValue")
+		l.Get(strconv.Itoa(i))
+	}
+}
@@ -0,0 +1,140 @@
+// Package metrics reports the stats of caches to metric systems without a
+// pull model. A Reporter periodically sends the stats of a cache to a Sink,
+// e.g. the StatsD client of package statsd. For Prometheus use the Collector
+// of package prometheus instead.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// Sink receives metrics. Tags are given in the form "name:value".
+type Sink interface {
+	// Count adds delta to a counter.
+	Count(name string, delta int64, tags []string)
+	// Gauge sets a gauge.
+	Gauge(name string, value float64, tags []string)
+	// Flush sends buffered metrics.
+	Flush() error
+}
+
+// Prefix is the prefix of the names of all metrics.
+const Prefix = "layercake."
+
+// Reporter sends the stats of a cache to a Sink. Hits, misses, sets, removals,
+// expirations and evictions are sent as counters, the hit ratio since the last
+// report and the number of entries as gauges. All metrics are tagged with
+// cache:name.
+type Reporter struct {
+	cache    cache.Layer
+	sink     Sink
+	tags     []string
+	interval time.Duration
+	onError  func(error)
+
+	last cache.Stats
+}
+
+// Option configures a Reporter on creation.
+type Option func(*Reporter)
+
+// WithInterval sets the interval between reports. The default is 10 seconds,
+// which is kept for intervals of zero or less.
+func WithInterval(interval time.Duration) Option {
+	return func(r *Reporter) {
+		if interval > 0 {
+			r.interval = interval
+		}
+	}
+}
+
+// WithTags adds tags in the form "name:value" to all metrics.
+func WithTags(tags ...string) Option {
+	return func(r *Reporter) {
+		r.tags = append(r.tags, tags...)
+	}
+}
+
+// WithErrorHandler sets a function that is called with errors flushing the
+// sink.
+func WithErrorHandler(handler func(error)) Option {
+	return func(r *Reporter) {
+		r.onError = handler
+	}
+}
+
+// NewReporter returns a reference to a new Reporter sending the stats of the
+// cache, e.g. a *cache.Cache or a *cache.Layered, to sink.
+func NewReporter(name string, c cache.Layer, sink Sink, opts ...Option) *Reporter {
+	r := &Reporter{
+		cache:    c,
+		sink:     sink,
+		tags:     []string{"cache:" + name},
+		interval: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run reports the stats every interval until the context is cancelled, when
+// they are reported a last time.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Report()
+		case <-ctx.Done():
+			r.Report()
+			return
+		}
+	}
+}
+
+// Report sends the changes of the stats since the last report and flushes
+// the sink. If the stats were reset with ResetStats in between, the counters
+// are reported as changes since the reset. It must not be called
+// concurrently.
+func (r *Reporter) Report() {
+	s := r.cache.GetStats()
+
+	last := r.last
+	if wasReset(s, &last) {
+		last = cache.Stats{}
+	}
+
+	hits := s.Hits - last.Hits
+	misses := s.Misses - last.Misses
+
+	r.sink.Count(Prefix+"hits", int64(hits), r.tags)
+	r.sink.Count(Prefix+"misses", int64(misses), r.tags)
+	r.sink.Count(Prefix+"sets", int64(s.Set-last.Set), r.tags)
+	r.sink.Count(Prefix+"removals", int64(s.Removed-last.Removed), r.tags)
+	r.sink.Count(Prefix+"expirations", int64(s.Expired-last.Expired), r.tags)
+	r.sink.Count(Prefix+"evictions", int64(s.Evicted-last.Evicted), r.tags)
+
+	if hits+misses > 0 {
+		r.sink.Gauge(Prefix+"hit_ratio", float64(hits)/float64(hits+misses), r.tags)
+	}
+	r.sink.Gauge(Prefix+"entries", float64(s.Entries), r.tags)
+
+	r.last = *s
+
+	if err := r.sink.Flush(); err != nil && r.onError != nil {
+		r.onError(err)
+	}
+}
+
+// wasReset reports whether any counter of s is lower than in last, which
+// only happens if the stats were reset since.
+func wasReset(s, last *cache.Stats) bool {
+	return s.Hits < last.Hits || s.Misses < last.Misses || s.Set < last.Set ||
+		s.Removed < last.Removed || s.Expired < last.Expired || s.Evicted < last.Evicted
+}
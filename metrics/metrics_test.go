@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+type testSink struct {
+	counts  map[string]int64
+	gauges  map[string]float64
+	flushed int
+}
+
+func newTestSink() *testSink {
+	return &testSink{
+		counts: make(map[string]int64),
+		gauges: make(map[string]float64),
+	}
+}
+
+func (s *testSink) Count(name string, delta int64, tags []string) {
+	s.counts[name] += delta
+}
+
+func (s *testSink) Gauge(name string, value float64, tags []string) {
+	s.gauges[name] = value
+}
+
+func (s *testSink) Flush() error {
+	s.flushed++
+	return nil
+}
+
+func TestReporter(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	sink := newTestSink()
+	r := NewReporter("test", c, sink)
+
+	c.Set(key, value)
+	c.Get(key)
+	c.Get("testOtherKey")
+	r.Report()
+
+	c.Get(key)
+	r.Report()
+
+	expected := map[string]int64{
+		Prefix + "hits":        2,
+		Prefix + "misses":      1,
+		Prefix + "sets":        1,
+		Prefix + "removals":    0,
+		Prefix + "expirations": 0,
+		Prefix + "evictions":   0,
+	}
+	if !reflect.DeepEqual(sink.counts, expected) {
+		t.Error("Expected", expected, "got", sink.counts)
+		t.Fail()
+	}
+
+	if ratio := sink.gauges[Prefix+"hit_ratio"]; ratio != 1 {
+		t.Errorf("Expected hit ratio 1 since the last report. Got %f", ratio)
+		t.Fail()
+	}
+
+	if entries := sink.gauges[Prefix+"entries"]; entries != 1 {
+		t.Errorf("Expected 1 entry. Got %f", entries)
+		t.Fail()
+	}
+
+	if sink.flushed != 2 {
+		t.Errorf("Expected 2 flushes. Got %d", sink.flushed)
+		t.Fail()
+	}
+}
+
+func TestReporterResetStats(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	sink := newTestSink()
+	r := NewReporter("test", c, sink)
+
+	c.Set(key, value)
+	c.Get(key)
+	c.Get(key)
+	r.Report()
+
+	c.ResetStats()
+	c.Get(key)
+	r.Report()
+
+	if hits := sink.counts[Prefix+"hits"]; hits != 3 {
+		t.Errorf("Expected 3 hits. Got %d", hits)
+		t.Fail()
+	}
+
+	if sets := sink.counts[Prefix+"sets"]; sets != 1 {
+		t.Errorf("Expected 1 set. Got %d", sets)
+		t.Fail()
+	}
+}
+
+func TestWithInterval(t *testing.T) {
+	sink := newTestSink()
+	r := NewReporter("test", cache.New(), sink, WithInterval(0))
+
+	if r.interval != 10*time.Second {
+		t.Error("Expected the default interval. Got", r.interval)
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx)
+
+	if sink.flushed != 1 {
+		t.Errorf("Expected 1 flush. Got %d", sink.flushed)
+		t.Fail()
+	}
+}
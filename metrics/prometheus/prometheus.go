@@ -0,0 +1,89 @@
+// Package prometheus exports the stats of caches as Prometheus metrics.
+//
+// Register a Collector for each cache, labeled with the name of the cache:
+//
+//	prometheus.MustRegister(layercakeprom.NewCollector("sessions", c))
+package prometheus
+
+import (
+	"github.com/mkrull/layercake/cache"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace is the prefix of the names of all metrics.
+const Namespace = "layercake"
+
+// memoryUser is implemented by caches estimating their memory usage.
+type memoryUser interface {
+	MemoryUsage() (int64, bool)
+}
+
+// Collector is a prom.Collector reporting the stats of a cache on every
+// scrape. Counters and the number of entries are read from GetStats, the
+// estimated memory usage is reported if the cache implements MemoryUsage.
+type Collector struct {
+	cache cache.Layer
+
+	hits        *prom.Desc
+	misses      *prom.Desc
+	sets        *prom.Desc
+	removals    *prom.Desc
+	expirations *prom.Desc
+	evictions   *prom.Desc
+	entries     *prom.Desc
+	memory      *prom.Desc
+}
+
+var _ prom.Collector = (*Collector)(nil)
+
+// NewCollector returns a reference to a new Collector for the cache, e.g. a
+// *cache.Cache or a *cache.Layered, labeling its metrics with cache="name".
+func NewCollector(name string, c cache.Layer) *Collector {
+	labels := prom.Labels{"cache": name}
+	desc := func(metric, help string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName(Namespace, "", metric), help, nil, labels)
+	}
+
+	return &Collector{
+		cache:       c,
+		hits:        desc("hits_total", "Number of Get calls finding a value."),
+		misses:      desc("misses_total", "Number of Get calls finding no value."),
+		sets:        desc("sets_total", "Number of values stored."),
+		removals:    desc("removals_total", "Number of values removed explicitly."),
+		expirations: desc("expirations_total", "Number of values removed because their ttl elapsed."),
+		evictions:   desc("evictions_total", "Number of values evicted because the cache was full."),
+		entries:     desc("entries", "Number of values in the cache."),
+		memory:      desc("memory_bytes", "Estimated memory used by the values in the cache."),
+	}
+}
+
+// Describe sends the descriptors of all metrics of the collector.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.sets
+	ch <- c.removals
+	ch <- c.expirations
+	ch <- c.evictions
+	ch <- c.entries
+	if _, ok := c.cache.(memoryUser); ok {
+		ch <- c.memory
+	}
+}
+
+// Collect sends the current values of all metrics of the collector.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	stats := c.cache.GetStats()
+
+	ch <- prom.MustNewConstMetric(c.hits, prom.CounterValue, float64(stats.Hits))
+	ch <- prom.MustNewConstMetric(c.misses, prom.CounterValue, float64(stats.Misses))
+	ch <- prom.MustNewConstMetric(c.sets, prom.CounterValue, float64(stats.Set))
+	ch <- prom.MustNewConstMetric(c.removals, prom.CounterValue, float64(stats.Removed))
+	ch <- prom.MustNewConstMetric(c.expirations, prom.CounterValue, float64(stats.Expired))
+	ch <- prom.MustNewConstMetric(c.evictions, prom.CounterValue, float64(stats.Evicted))
+	ch <- prom.MustNewConstMetric(c.entries, prom.GaugeValue, float64(stats.Entries))
+	if m, ok := c.cache.(memoryUser); ok {
+		bytes, _ := m.MemoryUsage()
+		ch <- prom.MustNewConstMetric(c.memory, prom.GaugeValue, float64(bytes))
+	}
+}
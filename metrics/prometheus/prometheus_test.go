@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mkrull/layercake/cache"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	c.Set(key, value)
+	c.Get(key)
+	c.Get("testOtherKey")
+	c.Remove(key)
+
+	reg := prom.NewPedanticRegistry()
+	reg.MustRegister(NewCollector("test", c))
+
+	expected := `
+# HELP layercake_hits_total Number of Get calls finding a value.
+# TYPE layercake_hits_total counter
+layercake_hits_total{cache="test"} 1
+# HELP layercake_misses_total Number of Get calls finding no value.
+# TYPE layercake_misses_total counter
+layercake_misses_total{cache="test"} 1
+# HELP layercake_sets_total Number of values stored.
+# TYPE layercake_sets_total counter
+layercake_sets_total{cache="test"} 1
+# HELP layercake_removals_total Number of values removed explicitly.
+# TYPE layercake_removals_total counter
+layercake_removals_total{cache="test"} 1
+# HELP layercake_entries Number of values in the cache.
+# TYPE layercake_entries gauge
+layercake_entries{cache="test"} 0
+`
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"layercake_hits_total", "layercake_misses_total", "layercake_sets_total", "layercake_removals_total", "layercake_entries")
+	if err != nil {
+		t.Error(err)
+		t.Fail()
+	}
+}
+
+func TestCollectorLabels(t *testing.T) {
+	reg := prom.NewPedanticRegistry()
+	reg.MustRegister(NewCollector("first", cache.New()))
+	reg.MustRegister(NewCollector("second", cache.New()))
+
+	if n, err := testutil.GatherAndCount(reg, "layercake_hits_total"); err != nil || n != 2 {
+		t.Errorf("Expected 2 series. Got %d, %v", n, err)
+		t.Fail()
+	}
+}
+
+func TestCollectorMemory(t *testing.T) {
+	c := cache.New()
+	c.Set("testKey", "testValue")
+
+	reg := prom.NewPedanticRegistry()
+	reg.MustRegister(NewCollector("test", c))
+
+	if n, err := testutil.GatherAndCount(reg, "layercake_memory_bytes"); err != nil || n != 1 {
+		t.Errorf("Expected memory usage to be reported. Got %d, %v", n, err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,134 @@
+// Package statsd implements a metrics.Sink sending metrics to a StatsD
+// server over UDP. Tags are sent in the format of DogStatsD, which is also
+// understood by the Datadog agent, Telegraf and the statsd_exporter.
+package statsd
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mkrull/layercake/metrics"
+)
+
+// maxPacketSize is the size metrics are split into packets at, small enough
+// to avoid fragmentation on common networks.
+const maxPacketSize = 1432
+
+// Client is a metrics.Sink buffering metrics until they are flushed.
+type Client struct {
+	conn   net.Conn
+	prefix string
+	tags   bool
+
+	buf     bytes.Buffer
+	packets [][]byte
+	sync.Mutex
+}
+
+var _ metrics.Sink = (*Client)(nil)
+
+// Option configures a Client on creation.
+type Option func(*Client)
+
+// WithPrefix is prepended to the names of all metrics.
+func WithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// WithoutTags drops tags for servers not supporting them.
+func WithoutTags() Option {
+	return func(c *Client) {
+		c.tags = false
+	}
+}
+
+// New returns a reference to a new Client sending metrics to the StatsD
+// server at addr, e.g. "localhost:8125".
+func New(addr string, opts ...Option) (*Client, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn: conn,
+		tags: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Count adds delta to a counter.
+func (c *Client) Count(name string, delta int64, tags []string) {
+	c.add(name, strconv.FormatInt(delta, 10), "c", tags)
+}
+
+// Gauge sets a gauge.
+func (c *Client) Gauge(name string, value float64, tags []string) {
+	c.add(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// add buffers a metric, starting a new packet if it does not fit the current
+// one.
+func (c *Client) add(name, value, typ string, tags []string) {
+	var line strings.Builder
+	line.WriteString(c.prefix)
+	line.WriteString(name)
+	line.WriteByte(':')
+	line.WriteString(value)
+	line.WriteByte('|')
+	line.WriteString(typ)
+	if c.tags && len(tags) > 0 {
+		line.WriteString("|#")
+		line.WriteString(strings.Join(tags, ","))
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.buf.Len() > 0 && c.buf.Len()+1+line.Len() > maxPacketSize {
+		c.packets = append(c.packets, append([]byte(nil), c.buf.Bytes()...))
+		c.buf.Reset()
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.WriteString(line.String())
+}
+
+// Flush sends the buffered metrics.
+func (c *Client) Flush() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.buf.Len() > 0 {
+		c.packets = append(c.packets, append([]byte(nil), c.buf.Bytes()...))
+		c.buf.Reset()
+	}
+
+	packets := c.packets
+	c.packets = nil
+
+	for _, p := range packets {
+		if _, err := c.conn.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the buffered metrics and closes the connection.
+func (c *Client) Close() error {
+	err := c.Flush()
+	if e := c.conn.Close(); err == nil {
+		err = e
+	}
+	return err
+}
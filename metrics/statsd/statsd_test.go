@@ -0,0 +1,93 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func receive(t *testing.T, conn *net.UDPConn) string {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 65536)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestClient(t *testing.T) {
+	conn := listen(t)
+
+	c, err := New(conn.LocalAddr().String(), WithPrefix("app."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Count("layercake.hits", 3, []string{"cache:test"})
+	c.Gauge("layercake.hit_ratio", 0.75, nil)
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "app.layercake.hits:3|c|#cache:test\napp.layercake.hit_ratio:0.75|g"
+	if got := receive(t, conn); got != expected {
+		t.Errorf("Expected %q. Got %q", expected, got)
+		t.Fail()
+	}
+}
+
+func TestClientWithoutTags(t *testing.T) {
+	conn := listen(t)
+
+	c, err := New(conn.LocalAddr().String(), WithoutTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Count("layercake.hits", 1, []string{"cache:test"})
+	c.Flush()
+
+	if got := receive(t, conn); got != "layercake.hits:1|c" {
+		t.Errorf("Expected tags to be dropped. Got %q", got)
+		t.Fail()
+	}
+}
+
+func TestClientPackets(t *testing.T) {
+	conn := listen(t)
+
+	c, err := New(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	name := strings.Repeat("x", 100)
+	for i := 0; i < 20; i++ {
+		c.Count(name, 1, nil)
+	}
+	c.Flush()
+
+	lines := 0
+	for lines < 20 {
+		p := receive(t, conn)
+		if len(p) > maxPacketSize {
+			t.Errorf("Expected packets of at most %d bytes. Got %d", maxPacketSize, len(p))
+			t.Fail()
+		}
+		lines += strings.Count(p, "\n") + 1
+	}
+}
@@ -0,0 +1,138 @@
+// Package ratelimit limits the rate of events per key with token buckets
+// stored in a cache, so the limits are enforced by the sharded storage of
+// the cache and the state of idle keys expires with their entries.
+//
+//	l := ratelimit.New(c)
+//	if !l.Allow("api:"+user, ratelimit.Every(100*time.Millisecond), 20) {
+//		// reject the request
+//	}
+package ratelimit
+
+import (
+	"encoding/gob"
+	"math"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// DefaultPrefix is the default prefix of the keys of buckets.
+const DefaultPrefix = "ratelimit:"
+
+// Limit is the rate at which events are allowed in events per second.
+type Limit float64
+
+// Every returns the Limit allowing one event per interval.
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Limit(math.Inf(1))
+	}
+	return Limit(float64(time.Second) / float64(interval))
+}
+
+// Bucket is the state of the token bucket of a key stored in the cache.
+type Bucket struct {
+	Tokens float64
+	// Updated is the time Tokens was computed at in unix nanoseconds
+	Updated int64
+}
+
+func init() {
+	gob.Register(Bucket{})
+}
+
+// Limiter allows events per key at a limited rate.
+type Limiter struct {
+	cache  *cache.Cache
+	prefix string
+	now    func() time.Time
+}
+
+// Option configures a Limiter on creation.
+type Option func(*Limiter)
+
+// WithPrefix sets the prefix of the keys buckets are stored with. The
+// default is DefaultPrefix.
+func WithPrefix(prefix string) Option {
+	return func(l *Limiter) {
+		l.prefix = prefix
+	}
+}
+
+// New returns a reference to a new Limiter storing its buckets in c.
+func New(c *cache.Cache, opts ...Option) *Limiter {
+	l := &Limiter{
+		cache:  c,
+		prefix: DefaultPrefix,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Allow reports whether an event for the given key may happen now. Each key
+// has a bucket of burst tokens refilled at limit tokens per second and every
+// allowed event takes one token. Buckets are stored until they would be full
+// again, so idle keys do not take up space.
+func (l *Limiter) Allow(key string, limit Limit, burst int) bool {
+	if math.IsInf(float64(limit), 1) {
+		return true
+	}
+	if burst < 1 {
+		return false
+	}
+
+	key = l.prefix + key
+	for {
+		v, version, ok := l.cache.GetWithVersion(key)
+		now := l.now()
+
+		b := Bucket{Tokens: float64(burst)}
+		if ok {
+			if stored, isBucket := v.(Bucket); isBucket {
+				b = stored
+				b.Tokens = math.Min(float64(burst), b.Tokens+now.Sub(time.Unix(0, b.Updated)).Seconds()*float64(limit))
+			}
+		}
+
+		if b.Tokens < 1 {
+			return false
+		}
+		b.Tokens--
+		b.Updated = now.UnixNano()
+
+		ttl := refillTime(burst, b.Tokens, limit)
+		if !ok {
+			if l.cache.SetIfAbsentWithTTL(key, b, ttl) {
+				return true
+			}
+			continue
+		}
+
+		if l.cache.CompareAndSwapWithTTL(key, b, version, ttl) {
+			return true
+		}
+	}
+}
+
+// refillTime returns the time until a bucket with the given tokens is full
+// again, the time it has to be stored for. Buckets that are never refilled
+// are stored without expiry.
+func refillTime(burst int, tokens float64, limit Limit) time.Duration {
+	if limit <= 0 {
+		return cache.NoExpiration
+	}
+
+	secs := (float64(burst) - tokens) / float64(limit)
+	if secs >= float64(math.MaxInt64/time.Second) {
+		return cache.NoExpiration
+	}
+
+	ttl := time.Duration(secs * float64(time.Second))
+	if ttl <= 0 {
+		ttl = time.Nanosecond
+	}
+	return ttl
+}
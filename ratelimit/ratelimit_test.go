@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+func TestAllow(t *testing.T) {
+	key := "testKey"
+
+	c := cache.New()
+	l := New(c)
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow(key, Every(time.Second), 3) {
+			t.Error("Event within burst should be allowed.")
+			t.Fail()
+		}
+	}
+
+	if l.Allow(key, Every(time.Second), 3) {
+		t.Error("Event beyond burst should not be allowed.")
+		t.Fail()
+	}
+
+	if !l.Allow("otherKey", Every(time.Second), 3) {
+		t.Error("Keys should have separate buckets.")
+		t.Fail()
+	}
+
+	now = now.Add(time.Second)
+	if !l.Allow(key, Every(time.Second), 3) {
+		t.Error("Event should be allowed after refill.")
+		t.Fail()
+	}
+
+	if l.Allow(key, Every(time.Second), 3) {
+		t.Error("Only one token should have been refilled.")
+		t.Fail()
+	}
+
+	if !c.Has(DefaultPrefix + key) {
+		t.Error("Bucket should be stored with the prefixed key.")
+		t.Fail()
+	}
+}
+
+func TestAllowExpiry(t *testing.T) {
+	key := "testKey"
+
+	c := cache.New()
+	l := New(c, WithPrefix("rl:"))
+
+	l.Allow(key, Every(10*time.Millisecond), 1)
+	time.Sleep(30 * time.Millisecond)
+
+	if c.Has("rl:" + key) {
+		t.Error("Full bucket should have expired.")
+		t.Fail()
+	}
+}
+
+func TestAllowParallel(t *testing.T) {
+	key := "testKey"
+
+	l := New(cache.New())
+
+	var wg sync.WaitGroup
+	var allowed int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if l.Allow(key, Every(time.Hour), 50) {
+					atomic.AddInt32(&allowed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 50 {
+		t.Errorf("Expected 50 allowed events. Got %d", allowed)
+		t.Fail()
+	}
+}
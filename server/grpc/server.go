@@ -0,0 +1,239 @@
+// Package grpc serves a cache over gRPC with the Cache service defined in
+// cachepb/cache.proto, so services in other languages can use it with
+// generated, typed clients.
+//
+// Values are stored as []byte. Values stored by Go code are returned as they
+// are if they are strings or byte slices and encoded as JSON otherwise.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative cachepb/cache.proto
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/server/grpc/cachepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements the Cache service for a cache. Register it with
+// cachepb.RegisterCacheServer.
+type Server struct {
+	cachepb.UnimplementedCacheServer
+
+	cache       *cache.Cache
+	watchBuffer int
+}
+
+var _ cachepb.CacheServer = (*Server)(nil)
+
+// Option configures a Server on creation.
+type Option func(*Server)
+
+// WithWatchBuffer sets the number of events buffered for each Watch call.
+// Watch calls falling further behind, or missing events the cache dropped
+// because the buffer set with cache.WithEventBuffer was full, are ended with
+// the status RESOURCE_EXHAUSTED. The default is 64.
+func WithWatchBuffer(size int) Option {
+	return func(s *Server) {
+		s.watchBuffer = size
+	}
+}
+
+// New returns a reference to a new Server serving the given cache.
+func New(c *cache.Cache, opts ...Option) *Server {
+	s := &Server{
+		cache:       c,
+		watchBuffer: 64,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Get returns the value stored with a key or the status NOT_FOUND.
+func (s *Server) Get(ctx context.Context, req *cachepb.GetRequest) (*cachepb.GetResponse, error) {
+	v, ok := s.cache.Get(req.GetKey())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not found", req.GetKey())
+	}
+
+	data, err := encode(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &cachepb.GetResponse{Value: data}, nil
+}
+
+// Set stores a value with a key.
+func (s *Server) Set(ctx context.Context, req *cachepb.SetRequest) (*cachepb.SetResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is empty")
+	}
+
+	if req.Ttl != nil {
+		if err := req.Ttl.CheckValid(); err != nil || req.Ttl.AsDuration() <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "ttl must be positive")
+		}
+		s.cache.SetWithTTL(req.GetKey(), req.GetValue(), req.Ttl.AsDuration())
+	} else {
+		s.cache.Set(req.GetKey(), req.GetValue())
+	}
+
+	return &cachepb.SetResponse{}, nil
+}
+
+// Remove deletes the value stored with a key.
+func (s *Server) Remove(ctx context.Context, req *cachepb.RemoveRequest) (*cachepb.RemoveResponse, error) {
+	s.cache.Remove(req.GetKey())
+
+	return &cachepb.RemoveResponse{}, nil
+}
+
+// Stats returns the access statistics of the cache.
+func (s *Server) Stats(ctx context.Context, req *cachepb.StatsRequest) (*cachepb.StatsResponse, error) {
+	stats := s.cache.GetStats()
+
+	return &cachepb.StatsResponse{
+		Hits:    int64(stats.Hits),
+		Misses:  int64(stats.Misses),
+		Set:     int64(stats.Set),
+		Removed: int64(stats.Removed),
+		Expired: int64(stats.Expired),
+		Evicted: int64(stats.Evicted),
+		Entries: int64(stats.Entries),
+		Uptime:  timestamppb.New(stats.Uptime),
+	}, nil
+}
+
+// watcher buffers the events of a Watch call.
+type watcher struct {
+	events chan cache.Event
+	// overflow is closed once an event could not be buffered or was dropped
+	// by the cache
+	overflow chan struct{}
+	once     sync.Once
+}
+
+// add buffers an event without blocking.
+func (w *watcher) add(ev cache.Event) {
+	if ev.Dropped == 0 {
+		select {
+		case w.events <- ev:
+			return
+		default:
+		}
+	}
+	w.once.Do(func() { close(w.overflow) })
+}
+
+// Watch streams changes of keys until the call is cancelled. It reports all
+// changes of the cache, also those made by Go code or other servers sharing
+// it. Removals, expirations and evictions are sent as TYPE_REMOVE. The
+// response headers are sent once the watch is registered.
+func (s *Server) Watch(req *cachepb.WatchRequest, stream cachepb.Cache_WatchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	w := &watcher{
+		events:   make(chan cache.Event, s.watchBuffer),
+		overflow: make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	if len(req.GetKeys()) == 0 {
+		events := s.cache.Subscribe("*")
+		go func() {
+			<-ctx.Done()
+			s.cache.Unsubscribe(events)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range events {
+				w.add(ev)
+			}
+		}()
+	}
+
+	watched := make(map[string]struct{}, len(req.GetKeys()))
+	for _, key := range req.GetKeys() {
+		if _, ok := watched[key]; ok {
+			continue
+		}
+		watched[key] = struct{}{}
+
+		events := s.cache.Watch(ctx, key)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range events {
+				w.add(cache.Event{Type: ev.Type, Key: key, Value: ev.Value, Dropped: ev.Dropped})
+			}
+		}()
+	}
+
+	// closed is closed once the cache is closed, or the call returned
+	closed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(closed)
+	}()
+
+	// the headers tell the client that changes are watched from now on
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case ev := <-w.events:
+			msg, err := watchEvent(ev)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		case <-w.overflow:
+			return status.Error(codes.ResourceExhausted, "watch fell behind")
+		case <-closed:
+			return status.Error(codes.Unavailable, "cache closed")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// watchEvent converts an event of the cache to a WatchEvent.
+func watchEvent(ev cache.Event) (*cachepb.WatchEvent, error) {
+	if ev.Type != cache.EventSet {
+		return &cachepb.WatchEvent{Type: cachepb.WatchEvent_TYPE_REMOVE, Key: ev.Key}, nil
+	}
+
+	data, err := encode(ev.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachepb.WatchEvent{Type: cachepb.WatchEvent_TYPE_SET, Key: ev.Key, Value: data}, nil
+}
+
+// encode converts a cached value to bytes.
+func encode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return json.Marshal(v)
+	}
+}
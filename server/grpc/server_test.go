@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"github.com/mkrull/layercake/server/grpc/cachepb"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func newTestClient(t *testing.T, c *cache.Cache) cachepb.CacheClient {
+	l := bufconn.Listen(1 << 20)
+
+	srv := gogrpc.NewServer()
+	cachepb.RegisterCacheServer(srv, New(c))
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	conn, err := gogrpc.NewClient("passthrough:///bufconn",
+		gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return cachepb.NewCacheClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	key := "testKey"
+	value := []byte("testValue")
+	ctx := context.Background()
+
+	c := cache.New()
+	client := newTestClient(t, c)
+
+	if _, err := client.Set(ctx, &cachepb.SetRequest{Key: key, Value: value}); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	resp, err := client.Get(ctx, &cachepb.GetRequest{Key: key})
+	if err != nil || string(resp.GetValue()) != string(value) {
+		t.Error("Expected", value, "got", resp, err)
+		t.Fail()
+	}
+
+	if _, err := client.Remove(ctx, &cachepb.RemoveRequest{Key: key}); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if _, err := client.Get(ctx, &cachepb.GetRequest{Key: key}); status.Code(err) != codes.NotFound {
+		t.Error("Expected", codes.NotFound, "got", err)
+		t.Fail()
+	}
+
+	stats, err := client.Stats(ctx, &cachepb.StatsRequest{})
+	if err != nil || stats.GetHits() != 1 || stats.GetMisses() != 1 || stats.GetSet() != 1 {
+		t.Error("Unexpected stats", stats, err)
+		t.Fail()
+	}
+}
+
+func TestServerTTL(t *testing.T) {
+	key := "testKey"
+	value := []byte("testValue")
+	ctx := context.Background()
+
+	c := cache.New()
+	client := newTestClient(t, c)
+
+	client.Set(ctx, &cachepb.SetRequest{Key: key, Value: value, Ttl: durationpb.New(time.Minute)})
+
+	if ttl, ok := c.TTL(key); !ok || ttl <= 59*time.Second {
+		t.Error("Expected ttl to be set, got", ttl)
+		t.Fail()
+	}
+
+	_, err := client.Set(ctx, &cachepb.SetRequest{Key: key, Value: value, Ttl: durationpb.New(-time.Minute)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("Expected", codes.InvalidArgument, "got", err)
+		t.Fail()
+	}
+}
+
+func TestServerWatch(t *testing.T) {
+	key := "testKey"
+	value := []byte("testValue")
+
+	c := cache.New()
+	client := newTestClient(t, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &cachepb.WatchRequest{Keys: []string{key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// wait for the watch to be registered
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Set(context.Background(), &cachepb.SetRequest{Key: "other", Value: value})
+	client.Set(context.Background(), &cachepb.SetRequest{Key: key, Value: value})
+	client.Remove(context.Background(), &cachepb.RemoveRequest{Key: key})
+
+	ev, err := stream.Recv()
+	if err != nil || ev.GetType() != cachepb.WatchEvent_TYPE_SET || ev.GetKey() != key || string(ev.GetValue()) != string(value) {
+		t.Error("Expected set event, got", ev, err)
+		t.Fail()
+	}
+
+	ev, err = stream.Recv()
+	if err != nil || ev.GetType() != cachepb.WatchEvent_TYPE_REMOVE || ev.GetKey() != key {
+		t.Error("Expected remove event, got", ev, err)
+		t.Fail()
+	}
+}
+
+func TestServerWatchCache(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	client := newTestClient(t, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &cachepb.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes made by Go code and expirations are watched as well
+	c.SetWithTTL(key, value, 10*time.Millisecond)
+
+	ev, err := stream.Recv()
+	if err != nil || ev.GetType() != cachepb.WatchEvent_TYPE_SET || ev.GetKey() != key || string(ev.GetValue()) != value {
+		t.Error("Expected set event, got", ev, err)
+		t.Fail()
+	}
+
+	ev, err = stream.Recv()
+	if err != nil || ev.GetType() != cachepb.WatchEvent_TYPE_REMOVE || ev.GetKey() != key {
+		t.Error("Expected remove event for the expiration, got", ev, err)
+		t.Fail()
+	}
+
+	c.Close()
+
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Error("Expected", codes.Unavailable, "got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,181 @@
+// Package http exposes a cache over HTTP, so services not written in Go and
+// ops tooling can use it directly.
+//
+// Values are read, written and removed with GET, PUT and DELETE requests to
+// /keys/{key}. A ttl can be set on PUT with the X-TTL header or the ttl query
+// parameter, either as a duration like "1m30s" or in seconds. GET /stats
+// returns the cache.Stats of the cache as JSON.
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// TTLHeader is the request header setting the ttl of a value on PUT.
+const TTLHeader = "X-TTL"
+
+const keysPath = "/keys/"
+
+// Server is an http.Handler serving a cache.
+type Server struct {
+	cache        cache.Layer
+	maxValueSize int64
+}
+
+var _ nethttp.Handler = (*Server)(nil)
+
+// Option configures a Server on creation.
+type Option func(*Server)
+
+// WithMaxValueSize limits the size of values written with PUT. Larger request
+// bodies are rejected with 413 Request Entity Too Large. The default is 1 MiB.
+func WithMaxValueSize(size int64) Option {
+	return func(s *Server) {
+		s.maxValueSize = size
+	}
+}
+
+// New returns a reference to a new Server serving the given cache, e.g. a
+// *cache.Cache or a *cache.Layered.
+func New(c cache.Layer, opts ...Option) *Server {
+	s := &Server{
+		cache:        c,
+		maxValueSize: 1 << 20,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ServeHTTP handles a request.
+func (s *Server) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	if r.URL.Path == "/stats" {
+		s.stats(w, r)
+		return
+	}
+
+	// keys may contain escaped slashes
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, keysPath) || len(path) == len(keysPath) {
+		nethttp.NotFound(w, r)
+		return
+	}
+	key, err := url.PathUnescape(path[len(keysPath):])
+	if err != nil {
+		nethttp.Error(w, "invalid key", nethttp.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case nethttp.MethodGet, nethttp.MethodHead:
+		s.get(w, key)
+	case nethttp.MethodPut:
+		s.put(w, r, key)
+	case nethttp.MethodDelete:
+		s.cache.Remove(key)
+		w.WriteHeader(nethttp.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		nethttp.Error(w, "method not allowed", nethttp.StatusMethodNotAllowed)
+	}
+}
+
+// get writes the value stored with key. Byte slices and strings are written
+// as they are, other values are encoded as JSON.
+func (s *Server) get(w nethttp.ResponseWriter, key string) {
+	v, ok := s.cache.Get(key)
+	if !ok {
+		nethttp.Error(w, "not found", nethttp.StatusNotFound)
+		return
+	}
+
+	var data []byte
+	switch v := v.(type) {
+	case []byte:
+		data = v
+		w.Header().Set("Content-Type", "application/octet-stream")
+	case string:
+		data = []byte(v)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			nethttp.Error(w, err.Error(), nethttp.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// put stores the request body as []byte with key.
+func (s *Server) put(w nethttp.ResponseWriter, r *nethttp.Request, key string) {
+	ttl, err := requestTTL(r)
+	if err != nil {
+		nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+		return
+	}
+
+	value, err := io.ReadAll(nethttp.MaxBytesReader(w, r.Body, s.maxValueSize))
+	if err != nil {
+		var tooLarge *nethttp.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			nethttp.Error(w, "value too large", nethttp.StatusRequestEntityTooLarge)
+			return
+		}
+		nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+		return
+	}
+
+	if ttl != 0 {
+		s.cache.SetWithTTL(key, value, ttl)
+	} else {
+		s.cache.Set(key, value)
+	}
+
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// requestTTL returns the ttl set with the X-TTL header or the ttl query
+// parameter, zero if there is none.
+func requestTTL(r *nethttp.Request) (time.Duration, error) {
+	ttl := r.Header.Get(TTLHeader)
+	if ttl == "" {
+		ttl = r.URL.Query().Get("ttl")
+	}
+	if ttl == "" {
+		return 0, nil
+	}
+
+	if secs, err := strconv.ParseInt(ttl, 10, 64); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second, nil
+	}
+	if d, err := time.ParseDuration(ttl); err == nil && d > 0 {
+		return d, nil
+	}
+
+	return 0, errors.New("invalid ttl " + strconv.Quote(ttl))
+}
+
+func (s *Server) stats(w nethttp.ResponseWriter, r *nethttp.Request) {
+	if r.Method != nethttp.MethodGet && r.Method != nethttp.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		nethttp.Error(w, "method not allowed", nethttp.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cache.GetStats())
+}
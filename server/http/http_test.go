@@ -0,0 +1,153 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string, header nethttp.Header) (*nethttp.Response, string) {
+	req, err := nethttp.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := nethttp.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+
+	return resp, string(data)
+}
+
+func TestServer(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	srv := httptest.NewServer(New(c))
+	defer srv.Close()
+
+	resp, _ := do(t, srv, "PUT", "/keys/"+key, value, nil)
+	if resp.StatusCode != nethttp.StatusNoContent {
+		t.Errorf("Expected status 204. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+
+	resp, body := do(t, srv, "GET", "/keys/"+key, "", nil)
+	if resp.StatusCode != nethttp.StatusOK || body != value {
+		t.Error("Expected", value, "got", body)
+		t.Fail()
+	}
+
+	resp, _ = do(t, srv, "DELETE", "/keys/"+key, "", nil)
+	if resp.StatusCode != nethttp.StatusNoContent {
+		t.Errorf("Expected status 204. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+
+	resp, _ = do(t, srv, "GET", "/keys/"+key, "", nil)
+	if resp.StatusCode != nethttp.StatusNotFound {
+		t.Errorf("Expected status 404. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+
+	// keys may contain escaped slashes
+	do(t, srv, "PUT", "/keys/a%2Fb", value, nil)
+	if _, ok := c.Get("a/b"); !ok {
+		t.Error("Key should have been unescaped.")
+		t.Fail()
+	}
+
+	c.Set("struct", map[string]int{"a": 1})
+	resp, body = do(t, srv, "GET", "/keys/struct", "", nil)
+	if resp.Header.Get("Content-Type") != "application/json" || body != `{"a":1}` {
+		t.Error("Expected JSON, got", body)
+		t.Fail()
+	}
+
+	resp, _ = do(t, srv, "POST", "/keys/"+key, value, nil)
+	if resp.StatusCode != nethttp.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+}
+
+func TestServerTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	srv := httptest.NewServer(New(c))
+	defer srv.Close()
+
+	do(t, srv, "PUT", "/keys/"+key, value, nethttp.Header{TTLHeader: {"1"}})
+	do(t, srv, "PUT", "/keys/other?ttl=100ms", value, nil)
+
+	resp, _ := do(t, srv, "PUT", "/keys/invalid?ttl=soon", value, nil)
+	if resp.StatusCode != nethttp.StatusBadRequest {
+		t.Errorf("Expected status 400. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if _, ok := c.Get("other"); ok {
+		t.Error("Value should have expired.")
+		t.Fail()
+	}
+
+	if _, ok := c.Get(key); !ok {
+		t.Error("Value should not have expired yet.")
+		t.Fail()
+	}
+}
+
+func TestServerMaxValueSize(t *testing.T) {
+	srv := httptest.NewServer(New(cache.New(), WithMaxValueSize(4)))
+	defer srv.Close()
+
+	resp, _ := do(t, srv, "PUT", "/keys/testKey", "testValue", nil)
+	if resp.StatusCode != nethttp.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+}
+
+func TestServerStats(t *testing.T) {
+	c := cache.New()
+	srv := httptest.NewServer(New(c))
+	defer srv.Close()
+
+	c.Set("testKey", "testValue")
+	c.Get("testKey")
+
+	resp, body := do(t, srv, "GET", "/stats", "", nil)
+	if resp.StatusCode != nethttp.StatusOK {
+		t.Errorf("Expected status 200. Got %d", resp.StatusCode)
+		t.Fail()
+	}
+
+	var stats cache.Stats
+	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if stats.Hits != 1 || stats.Set != 1 {
+		t.Error("Unexpected stats", body)
+		t.Fail()
+	}
+}
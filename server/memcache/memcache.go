@@ -0,0 +1,487 @@
+// Package memcache serves a cache over the memcached text protocol, so it can
+// replace memcached for existing memcached clients.
+//
+// Supported commands are get, gets, set, add, replace, delete, touch, stats,
+// version and quit. Values are stored as []byte, or as *Item if they are
+// stored with non-zero flags. Values stored by Go code are returned as they
+// are if they are strings or byte slices and encoded as JSON otherwise.
+package memcache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// ErrServerClosed is returned by Serve after Close has been called.
+var ErrServerClosed = errors.New("memcache: server closed")
+
+// Version is reported by the version command.
+const Version = "1.6.0-layercake"
+
+// maxKeyLength is the maximum length of keys in the memcached protocol.
+const maxKeyLength = 250
+
+// relativeExptimeLimit is the largest exptime interpreted as seconds from now.
+// Larger values are unix timestamps.
+const relativeExptimeLimit = 60 * 60 * 24 * 30
+
+// Item is a value stored with memcached flags.
+type Item struct {
+	Value []byte
+	Flags uint32
+}
+
+// Server serves a cache over the memcached text protocol.
+type Server struct {
+	cache        *cache.Cache
+	maxValueSize int
+	onError      func(error)
+
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+	sync.Mutex
+}
+
+// Option configures a Server on creation.
+type Option func(*Server)
+
+// WithMaxValueSize limits the size of values. Larger values are rejected with
+// SERVER_ERROR. The default is 1 MiB like the item size limit of memcached.
+func WithMaxValueSize(size int) Option {
+	return func(s *Server) {
+		s.maxValueSize = size
+	}
+}
+
+// WithErrorHandler sets a function that is called with errors accepting and
+// serving connections.
+func WithErrorHandler(handler func(error)) Option {
+	return func(s *Server) {
+		s.onError = handler
+	}
+}
+
+// New returns a reference to a new Server serving the given cache.
+func New(c *cache.Cache, opts ...Option) *Server {
+	s := &Server{
+		cache:        c,
+		maxValueSize: 1 << 20,
+		listeners:    make(map[net.Listener]struct{}),
+		conns:        make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections. It
+// returns ErrServerClosed once the server is closed.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves each one in its own go routine.
+// It returns ErrServerClosed once the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.wg.Add(1)
+	s.Unlock()
+
+	defer s.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.Lock()
+			closed := s.closed
+			delete(s.listeners, l)
+			s.Unlock()
+
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.Lock()
+		if s.closed {
+			s.Unlock()
+			conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+// Close closes all listeners and connections and waits for them to finish.
+func (s *Server) Close() error {
+	s.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer func() {
+		conn.Close()
+
+		s.Lock()
+		delete(s.conns, conn)
+		s.Unlock()
+
+		s.wg.Done()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			w.WriteString("CLIENT_ERROR line too long\r\n")
+			w.Flush()
+			return
+		}
+		if err != nil {
+			s.closeOrReport(err)
+			return
+		}
+
+		args := strings.Fields(string(line))
+		if len(args) == 0 {
+			w.WriteString("ERROR\r\n")
+		} else if !s.exec(r, w, args) {
+			w.Flush()
+			return
+		}
+
+		// replies to pipelined commands are written at once
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				s.closeOrReport(err)
+				return
+			}
+		}
+	}
+}
+
+// closeOrReport reports errors of a connection unless the server is closed
+// or the client disconnected.
+func (s *Server) closeOrReport(err error) {
+	s.Lock()
+	closed := s.closed
+	s.Unlock()
+
+	if closed || errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		return
+	}
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// exec runs a command and writes its reply. It returns false if the
+// connection should be closed.
+func (s *Server) exec(r *bufio.Reader, w *bufio.Writer, args []string) bool {
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "get", "gets":
+		if len(args) == 0 {
+			w.WriteString("ERROR\r\n")
+			break
+		}
+		for _, key := range args {
+			s.get(w, key, cmd == "gets")
+		}
+		w.WriteString("END\r\n")
+	case "set", "add", "replace":
+		return s.store(r, w, cmd, args)
+	case "delete":
+		if len(args) < 1 || len(args) > 2 {
+			w.WriteString("ERROR\r\n")
+			break
+		}
+		reply := "NOT_FOUND\r\n"
+		if _, ok := s.cache.GetAndDelete(args[0]); ok {
+			reply = "DELETED\r\n"
+		}
+		if !noreply(args[1:]) {
+			w.WriteString(reply)
+		}
+	case "touch":
+		if len(args) < 2 || len(args) > 3 {
+			w.WriteString("ERROR\r\n")
+			break
+		}
+		ttl, err := parseExptime(args[1])
+		if err != nil {
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			break
+		}
+		reply := "NOT_FOUND\r\n"
+		if s.touch(args[0], ttl) {
+			reply = "TOUCHED\r\n"
+		}
+		if !noreply(args[2:]) {
+			w.WriteString(reply)
+		}
+	case "stats":
+		s.stats(w)
+	case "version":
+		w.WriteString("VERSION " + Version + "\r\n")
+	case "quit":
+		return false
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+
+	return true
+}
+
+func noreply(args []string) bool {
+	return len(args) > 0 && args[len(args)-1] == "noreply"
+}
+
+func (s *Server) get(w *bufio.Writer, key string, cas bool) {
+	var v interface{}
+	var version uint64
+	var ok bool
+	if cas {
+		// unlike Get, GetWithVersion does not invoke the Loader
+		v, version, ok = s.cache.GetWithVersion(key)
+	} else {
+		v, ok = s.cache.Get(key)
+	}
+	if !ok {
+		return
+	}
+
+	var data []byte
+	var flags uint32
+	switch v := v.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case *Item:
+		data, flags = v.Value, v.Flags
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return
+		}
+	}
+
+	fmt.Fprintf(w, "VALUE %s %d %d", key, flags, len(data))
+	if cas {
+		fmt.Fprintf(w, " %d", version)
+	}
+	w.WriteString("\r\n")
+	w.Write(data)
+	w.WriteString("\r\n")
+}
+
+// store handles set, add and replace with the arguments
+// <key> <flags> <exptime> <bytes> [noreply] followed by a data block.
+func (s *Server) store(r *bufio.Reader, w *bufio.Writer, cmd string, args []string) bool {
+	if len(args) < 4 || len(args) > 5 {
+		w.WriteString("ERROR\r\n")
+		return true
+	}
+
+	key := args[0]
+	flags, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+	ttl, err := parseExptime(args[2])
+	if err != nil {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+	size, err := strconv.Atoi(args[3])
+	if err != nil || size < 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return true
+	}
+
+	if size > s.maxValueSize {
+		// skip the data block to stay in sync with the client
+		if _, err := r.Discard(size + 2); err != nil {
+			return false
+		}
+		w.WriteString("SERVER_ERROR object too large for cache\r\n")
+		return true
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return false
+	}
+	if data[size] != '\r' || data[size+1] != '\n' {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+	data = data[:size]
+
+	if len(key) > maxKeyLength {
+		w.WriteString("CLIENT_ERROR key too long\r\n")
+		return true
+	}
+
+	var value interface{} = data
+	if flags != 0 {
+		value = &Item{Value: data, Flags: uint32(flags)}
+	}
+
+	stored := true
+	switch cmd {
+	case "add":
+		stored = s.add(key, value, ttl)
+	case "replace":
+		stored = s.replace(key, value, ttl)
+	default:
+		s.set(key, value, ttl)
+	}
+
+	reply := "STORED\r\n"
+	if !stored {
+		reply = "NOT_STORED\r\n"
+	}
+
+	if !noreply(args[4:]) {
+		w.WriteString(reply)
+	}
+
+	return true
+}
+
+// set stores value with the ttl of a memcached exptime. Zero uses the default
+// ttl of the cache, a negative ttl removes the value.
+func (s *Server) set(key string, value interface{}, ttl time.Duration) {
+	switch {
+	case ttl == 0:
+		s.cache.Set(key, value)
+	case ttl < 0:
+		s.cache.Remove(key)
+	default:
+		s.cache.SetWithTTL(key, value, ttl)
+	}
+}
+
+// add stores value like set unless a value is stored with key already. A
+// negative ttl stores nothing, it only reports whether the key is free.
+func (s *Server) add(key string, value interface{}, ttl time.Duration) bool {
+	switch {
+	case ttl == 0:
+		return s.cache.SetIfAbsent(key, value)
+	case ttl < 0:
+		return !s.cache.Has(key)
+	default:
+		return s.cache.SetIfAbsentWithTTL(key, value, ttl)
+	}
+}
+
+// replace stores value like set only if a value is stored with key already.
+func (s *Server) replace(key string, value interface{}, ttl time.Duration) bool {
+	switch {
+	case ttl == 0:
+		return s.cache.ReplaceWithTTL(key, value, s.cache.DefaultTTL())
+	case ttl < 0:
+		_, ok := s.cache.GetAndDelete(key)
+		return ok
+	default:
+		return s.cache.ReplaceWithTTL(key, value, ttl)
+	}
+}
+
+// touch sets the ttl of an existing entry, zero removes its ttl.
+func (s *Server) touch(key string, ttl time.Duration) bool {
+	switch {
+	case ttl == 0:
+		return s.cache.Persist(key)
+	case ttl < 0:
+		_, ok := s.cache.GetAndDelete(key)
+		return ok
+	default:
+		return s.cache.Touch(key, ttl)
+	}
+}
+
+// parseExptime converts a memcached exptime, seconds from now or a unix
+// timestamp if it exceeds 30 days, to a ttl. Zero means no expiry, negative
+// ttls are already expired.
+func parseExptime(s string) (time.Duration, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case n == 0:
+		return 0, nil
+	case n < 0:
+		return -1, nil
+	case n > relativeExptimeLimit:
+		ttl := time.Until(time.Unix(n, 0))
+		if ttl <= 0 {
+			return -1, nil
+		}
+		return ttl, nil
+	default:
+		return time.Duration(n) * time.Second, nil
+	}
+}
+
+func (s *Server) stats(w *bufio.Writer) {
+	stats := s.cache.GetStats()
+
+	fmt.Fprintf(w, "STAT uptime %d\r\n", int64(time.Since(stats.Uptime)/time.Second))
+	fmt.Fprintf(w, "STAT time %d\r\n", time.Now().Unix())
+	fmt.Fprintf(w, "STAT version %s\r\n", Version)
+	fmt.Fprintf(w, "STAT get_hits %d\r\n", stats.Hits)
+	fmt.Fprintf(w, "STAT get_misses %d\r\n", stats.Misses)
+	fmt.Fprintf(w, "STAT cmd_get %d\r\n", stats.Hits+stats.Misses)
+	fmt.Fprintf(w, "STAT cmd_set %d\r\n", stats.Set)
+	fmt.Fprintf(w, "STAT curr_items %d\r\n", stats.Entries)
+	fmt.Fprintf(w, "STAT evictions %d\r\n", stats.Evicted)
+	w.WriteString("END\r\n")
+}
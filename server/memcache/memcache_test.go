@@ -0,0 +1,265 @@
+package memcache
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gomemcache "github.com/bradfitz/gomemcache/memcache"
+	"github.com/mkrull/layercake/cache"
+)
+
+func newTestServer(t *testing.T, c *cache.Cache, opts ...Option) *gomemcache.Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(c, opts...)
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	return gomemcache.New(l.Addr().String())
+}
+
+func TestServer(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	client := newTestServer(t, c)
+
+	if err := client.Set(&gomemcache.Item{Key: key, Value: []byte(value)}); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	it, err := client.Get(key)
+	if err != nil || string(it.Value) != value {
+		t.Error("Expected", value, "got", it, err)
+		t.Fail()
+	}
+
+	client.Set(&gomemcache.Item{Key: "flags", Value: []byte(value), Flags: 42})
+
+	items, err := client.GetMulti([]string{key, "flags", "missing"})
+	if err != nil || len(items) != 2 {
+		t.Error("Expected 2 items, got", items, err)
+		t.Fail()
+	}
+
+	if it := items["flags"]; it == nil || it.Flags != 42 {
+		t.Error("Flags should have been stored.")
+		t.Fail()
+	}
+
+	if err := client.Add(&gomemcache.Item{Key: key, Value: []byte(value)}); err != gomemcache.ErrNotStored {
+		t.Error("Expected", gomemcache.ErrNotStored, "got", err)
+		t.Fail()
+	}
+
+	if err := client.Replace(&gomemcache.Item{Key: "missing", Value: []byte(value)}); err != gomemcache.ErrNotStored {
+		t.Error("Expected", gomemcache.ErrNotStored, "got", err)
+		t.Fail()
+	}
+
+	if err := client.Delete(key); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if err := client.Delete(key); err != gomemcache.ErrCacheMiss {
+		t.Error("Expected", gomemcache.ErrCacheMiss, "got", err)
+		t.Fail()
+	}
+
+	if _, err := client.Get(key); err != gomemcache.ErrCacheMiss {
+		t.Error("Expected", gomemcache.ErrCacheMiss, "got", err)
+		t.Fail()
+	}
+}
+
+func TestServerExptime(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New()
+	client := newTestServer(t, c)
+
+	client.Set(&gomemcache.Item{Key: key, Value: []byte(value), Expiration: 60})
+	client.Set(&gomemcache.Item{Key: "timestamp", Value: []byte(value), Expiration: int32(time.Now().Add(time.Hour).Unix())})
+
+	if ttl, ok := c.TTL(key); !ok || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Error("Expected relative ttl, got", ttl)
+		t.Fail()
+	}
+
+	if ttl, ok := c.TTL("timestamp"); !ok || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Error("Expected ttl from timestamp, got", ttl)
+		t.Fail()
+	}
+
+	if err := client.Touch(key, 0); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if ttl, _ := c.TTL(key); ttl != cache.NoExpiration {
+		t.Error("Touch with 0 should have removed the ttl.")
+		t.Fail()
+	}
+
+	if err := client.Touch("missing", 10); err != gomemcache.ErrCacheMiss {
+		t.Error("Expected", gomemcache.ErrCacheMiss, "got", err)
+		t.Fail()
+	}
+}
+
+func TestServerAddReplace(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	c := cache.New(cache.WithDefaultTTL(time.Hour))
+	client := newTestServer(t, c)
+
+	var stored int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if client.Add(&gomemcache.Item{Key: key, Value: []byte(value + strconv.Itoa(i))}) == nil {
+				atomic.AddInt32(&stored, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stored != 1 {
+		t.Errorf("Expected exactly 1 add to store its value. Got %d", stored)
+		t.Fail()
+	}
+
+	c.SetWithTTL(key, value, time.Minute)
+	if err := client.Replace(&gomemcache.Item{Key: key, Value: []byte(value)}); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if ttl, ok := c.TTL(key); !ok || ttl <= 59*time.Minute {
+		t.Error("Replace with exptime 0 should use the default ttl. Got", ttl)
+		t.Fail()
+	}
+
+	if err := client.Touch(key, -1); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if c.Has(key) {
+		t.Error("Touch with a negative exptime should have removed the value.")
+		t.Fail()
+	}
+}
+
+func TestServerGets(t *testing.T) {
+	c := cache.New()
+	srv := New(c)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c.Set("testKey", "testValue")
+	c.Set("testKey", "testValue")
+	_, version, _ := c.GetWithVersion("testKey")
+	conn.Write([]byte("gets testKey\r\n"))
+
+	expected := fmt.Sprintf("VALUE testKey 0 9 %d\r\ntestValue\r\nEND\r\n", version)
+	buf := make([]byte, 128)
+	n := 0
+	for n < len(expected) {
+		m, err := conn.Read(buf[n:])
+		if err != nil {
+			break
+		}
+		n += m
+	}
+
+	if version == 0 || string(buf[:n]) != expected {
+		t.Errorf("Expected %q. Got %q", expected, buf[:n])
+		t.Fail()
+	}
+}
+
+func TestServerMaxValueSize(t *testing.T) {
+	client := newTestServer(t, cache.New(), WithMaxValueSize(4))
+
+	if err := client.Set(&gomemcache.Item{Key: "testKey", Value: []byte("testValue")}); err == nil {
+		t.Error("Expected error for a value that is too large.")
+		t.Fail()
+	}
+
+	// the connection is still usable
+	if err := client.Set(&gomemcache.Item{Key: "testKey", Value: []byte("test")}); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+}
+
+func TestServerStats(t *testing.T) {
+	c := cache.New()
+	srv := New(c)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c.Set("testKey", "testValue")
+	conn.Write([]byte("get testKey\r\nversion\r\n"))
+
+	expected := "VALUE testKey 0 9\r\ntestValue\r\nEND\r\nVERSION " + Version + "\r\n"
+	buf := make([]byte, 128)
+	n := 0
+	for n < len(expected) {
+		m, err := conn.Read(buf[n:])
+		if err != nil {
+			break
+		}
+		n += m
+	}
+
+	if string(buf[:n]) != expected {
+		t.Errorf("Expected %q. Got %q", expected, buf[:n])
+		t.Fail()
+	}
+
+	conn.Write([]byte("stats\r\n"))
+	n, _ = conn.Read(buf)
+	if n == 0 || string(buf[:5]) != "STAT " {
+		t.Errorf("Unexpected stats %q", buf[:n])
+		t.Fail()
+	}
+}
@@ -0,0 +1,60 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("*3\r\n$3\r\nSET\r\n$7\r\ntestKey\r\n$9\r\ntest\r\nVal\r\nGET testKey\r\n"))
+
+	args, err := readCommand(r, 1024)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if len(args) != 3 || string(args[0]) != "SET" || string(args[2]) != "test\r\nVal" {
+		t.Errorf("Unexpected command %q", args)
+		t.Fail()
+	}
+
+	args, err = readCommand(r, 1024)
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if len(args) != 2 || string(args[0]) != "GET" || string(args[1]) != "testKey" {
+		t.Errorf("Unexpected inline command %q", args)
+		t.Fail()
+	}
+
+	for _, data := range []string{"*1\r\n:1\r\n", "*1\r\n$3\r\nGETX\r\n", "*1\r\n$2048\r\n", "*x\r\n", "*-1\r\n", "*-5\r\n"} {
+		if _, err := readCommand(bufio.NewReader(strings.NewReader(data)), 1024); err != errProtocol {
+			t.Errorf("Expected protocol error for %q. Got %v", data, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	writeSimple(w, "OK")
+	writeError(w, "failed")
+	writeInt(w, -2)
+	writeBulk(w, []byte("testValue"))
+	writeNull(w)
+	writeArrayHeader(w, 0)
+	w.Flush()
+
+	expected := "+OK\r\n-ERR failed\r\n:-2\r\n$9\r\ntestValue\r\n$-1\r\n*0\r\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q. Got %q", expected, buf.String())
+		t.Fail()
+	}
+}
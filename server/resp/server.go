@@ -0,0 +1,391 @@
+// Package resp serves a cache over a subset of the Redis protocol (RESP), so
+// redis-cli and existing Redis client libraries can talk to it.
+//
+// Supported commands are PING, GET, SET with the EX and PX options, DEL,
+// EXISTS, KEYS, TTL, PTTL, INFO and QUIT. Values are stored as []byte.
+// Values stored by Go code are returned as they are if they are strings or
+// byte slices and encoded as JSON otherwise.
+package resp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+)
+
+// ErrServerClosed is returned by Serve after Close has been called.
+var ErrServerClosed = errors.New("resp: server closed")
+
+// Server serves a cache over RESP.
+type Server struct {
+	cache        *cache.Cache
+	maxValueSize int
+	onError      func(error)
+
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+	sync.Mutex
+}
+
+// Option configures a Server on creation.
+type Option func(*Server)
+
+// WithMaxValueSize limits the size of keys and values sent by clients. Clients
+// sending larger arguments are disconnected. The default is 1 MiB.
+func WithMaxValueSize(size int) Option {
+	return func(s *Server) {
+		s.maxValueSize = size
+	}
+}
+
+// WithErrorHandler sets a function that is called with errors accepting and
+// serving connections.
+func WithErrorHandler(handler func(error)) Option {
+	return func(s *Server) {
+		s.onError = handler
+	}
+}
+
+// New returns a reference to a new Server serving the given cache.
+func New(c *cache.Cache, opts ...Option) *Server {
+	s := &Server{
+		cache:        c,
+		maxValueSize: 1 << 20,
+		listeners:    make(map[net.Listener]struct{}),
+		conns:        make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the TCP address addr and serves connections. It
+// returns ErrServerClosed once the server is closed.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves each one in its own go routine.
+// It returns ErrServerClosed once the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.wg.Add(1)
+	s.Unlock()
+
+	defer s.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.Lock()
+			closed := s.closed
+			delete(s.listeners, l)
+			s.Unlock()
+
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.Lock()
+		if s.closed {
+			s.Unlock()
+			conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+// Close closes all listeners and connections and waits for them to finish.
+func (s *Server) Close() error {
+	s.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+func (s *Server) handleError(err error) {
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer func() {
+		conn.Close()
+
+		s.Lock()
+		delete(s.conns, conn)
+		s.Unlock()
+
+		s.wg.Done()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		args, err := readCommand(r, s.maxValueSize)
+		if err == errProtocol {
+			writeError(w, err.Error())
+			w.Flush()
+			return
+		}
+		if err != nil {
+			s.closeOrReport(err)
+			return
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		if !s.exec(w, args) {
+			w.Flush()
+			return
+		}
+
+		// replies to pipelined commands are written at once
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				s.closeOrReport(err)
+				return
+			}
+		}
+	}
+}
+
+// closeOrReport reports errors of a connection unless the server is closed
+// or the client disconnected.
+func (s *Server) closeOrReport(err error) {
+	s.Lock()
+	closed := s.closed
+	s.Unlock()
+
+	if closed || errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		return
+	}
+	s.handleError(err)
+}
+
+// exec runs a command and writes its reply. It returns false if the
+// connection should be closed.
+func (s *Server) exec(w *bufio.Writer, args [][]byte) bool {
+	cmd := strings.ToUpper(string(args[0]))
+	args = args[1:]
+
+	switch cmd {
+	case "PING":
+		if len(args) > 0 {
+			writeBulk(w, args[0])
+		} else {
+			writeSimple(w, "PONG")
+		}
+	case "GET":
+		if len(args) != 1 {
+			writeArgsError(w, cmd)
+			break
+		}
+		s.get(w, string(args[0]))
+	case "SET":
+		if len(args) < 2 {
+			writeArgsError(w, cmd)
+			break
+		}
+		s.set(w, args)
+	case "DEL":
+		if len(args) == 0 {
+			writeArgsError(w, cmd)
+			break
+		}
+		keys := make([]string, len(args))
+		for i, key := range args {
+			keys[i] = string(key)
+		}
+		writeInt(w, int64(s.cache.RemoveMulti(keys)))
+	case "EXISTS":
+		if len(args) == 0 {
+			writeArgsError(w, cmd)
+			break
+		}
+		var n int64
+		for _, key := range args {
+			if _, ok := s.cache.TTL(string(key)); ok {
+				n++
+			}
+		}
+		writeInt(w, n)
+	case "KEYS":
+		if len(args) != 1 {
+			writeArgsError(w, cmd)
+			break
+		}
+		keys := s.cache.Match(string(args[0]))
+		writeArrayHeader(w, len(keys))
+		for _, key := range keys {
+			writeBulk(w, []byte(key))
+		}
+	case "TTL", "PTTL":
+		if len(args) != 1 {
+			writeArgsError(w, cmd)
+			break
+		}
+		s.ttl(w, string(args[0]), cmd == "PTTL")
+	case "INFO":
+		writeBulk(w, s.info())
+	case "COMMAND":
+		// redis-cli asks for command docs on startup
+		writeArrayHeader(w, 0)
+	case "QUIT":
+		writeSimple(w, "OK")
+		return false
+	default:
+		if len(cmd) > 128 {
+			cmd = cmd[:128]
+		}
+		writeError(w, fmt.Sprintf("unknown command '%s'", cmd))
+	}
+
+	return true
+}
+
+func writeArgsError(w *bufio.Writer, cmd string) {
+	writeError(w, fmt.Sprintf("wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
+
+func (s *Server) get(w *bufio.Writer, key string) {
+	v, ok := s.cache.Get(key)
+	if !ok {
+		writeNull(w)
+		return
+	}
+
+	switch v := v.(type) {
+	case []byte:
+		writeBulk(w, v)
+	case string:
+		writeBulk(w, []byte(v))
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			writeError(w, err.Error())
+			return
+		}
+		writeBulk(w, data)
+	}
+}
+
+// set handles SET key value [EX seconds|PX milliseconds].
+func (s *Server) set(w *bufio.Writer, args [][]byte) {
+	key := string(args[0])
+	// the value must not share the read buffer of the connection
+	value := append([]byte(nil), args[1]...)
+
+	var ttl time.Duration
+	for opts := args[2:]; len(opts) > 0; opts = opts[2:] {
+		unit := time.Duration(0)
+		switch strings.ToUpper(string(opts[0])) {
+		case "EX":
+			unit = time.Second
+		case "PX":
+			unit = time.Millisecond
+		default:
+			writeError(w, "syntax error")
+			return
+		}
+		if len(opts) < 2 || ttl != 0 {
+			writeError(w, "syntax error")
+			return
+		}
+
+		n, err := strconv.ParseInt(string(opts[1]), 10, 64)
+		if err != nil || n <= 0 || n > int64(math.MaxInt64/unit) {
+			writeError(w, "invalid expire time in 'set' command")
+			return
+		}
+		ttl = time.Duration(n) * unit
+	}
+
+	if ttl > 0 {
+		s.cache.SetWithTTL(key, value, ttl)
+	} else {
+		s.cache.Set(key, value)
+	}
+
+	writeSimple(w, "OK")
+}
+
+// ttl replies with the remaining ttl of key in seconds or milliseconds, -1 if
+// it does not expire and -2 if it does not exist.
+func (s *Server) ttl(w *bufio.Writer, key string, millis bool) {
+	ttl, ok := s.cache.TTL(key)
+	switch {
+	case !ok:
+		writeInt(w, -2)
+	case ttl == cache.NoExpiration:
+		writeInt(w, -1)
+	case millis:
+		writeInt(w, int64((ttl+time.Millisecond/2)/time.Millisecond))
+	default:
+		writeInt(w, int64((ttl+time.Second/2)/time.Second))
+	}
+}
+
+// info returns the stats of the cache in the format of the Redis INFO
+// command.
+func (s *Server) info() []byte {
+	stats := s.cache.GetStats()
+
+	var b strings.Builder
+	b.WriteString("# Server\r\n")
+	fmt.Fprintf(&b, "uptime_in_seconds:%d\r\n", int64(time.Since(stats.Uptime)/time.Second))
+	b.WriteString("\r\n# Stats\r\n")
+	fmt.Fprintf(&b, "keyspace_hits:%d\r\n", stats.Hits)
+	fmt.Fprintf(&b, "keyspace_misses:%d\r\n", stats.Misses)
+	fmt.Fprintf(&b, "removed_keys:%d\r\n", stats.Removed)
+	fmt.Fprintf(&b, "expired_keys:%d\r\n", stats.Expired)
+	fmt.Fprintf(&b, "evicted_keys:%d\r\n", stats.Evicted)
+	fmt.Fprintf(&b, "set_commands:%d\r\n", stats.Set)
+	b.WriteString("\r\n# Keyspace\r\n")
+	fmt.Fprintf(&b, "db0:keys=%d\r\n", stats.Entries)
+
+	return []byte(b.String())
+}
@@ -0,0 +1,213 @@
+package resp
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newTestServer(t *testing.T, c *cache.Cache) *goredis.Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(c)
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr:     l.Addr().String(),
+		Protocol: 2,
+		// the server does not implement CLIENT SETINFO
+		DisableIdentity: true,
+	})
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestServer(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	ctx := context.Background()
+
+	c := cache.New()
+	client := newTestServer(t, c)
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if err := client.Set(ctx, key, value, 0).Err(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if v, err := client.Get(ctx, key).Result(); err != nil || v != value {
+		t.Error("Expected", value, "got", v, err)
+		t.Fail()
+	}
+
+	if n, _ := client.Exists(ctx, key, "missing").Result(); n != 1 {
+		t.Errorf("Expected 1 existing key. Got %d", n)
+		t.Fail()
+	}
+
+	if n, _ := client.Del(ctx, key, "missing", key).Result(); n != 1 {
+		t.Errorf("Expected 1 removed key. Got %d", n)
+		t.Fail()
+	}
+
+	if err := client.Get(ctx, key).Err(); err != goredis.Nil {
+		t.Error("Expected", goredis.Nil, "got", err)
+		t.Fail()
+	}
+
+	c.Set("struct", map[string]int{"a": 1})
+	if v, _ := client.Get(ctx, "struct").Result(); v != `{"a":1}` {
+		t.Error("Expected JSON, got", v)
+		t.Fail()
+	}
+
+	if err := client.Do(ctx, "FLUSHALL").Err(); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Error("Expected unknown command error, got", err)
+		t.Fail()
+	}
+}
+
+func TestServerTTL(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+	ctx := context.Background()
+
+	client := newTestServer(t, cache.New())
+
+	client.Set(ctx, key, value, time.Minute)
+	client.Set(ctx, "short", value, 100*time.Millisecond)
+	client.Set(ctx, "persistent", value, 0)
+
+	if ttl, _ := client.TTL(ctx, key).Result(); ttl != time.Minute {
+		t.Error("Expected", time.Minute, "got", ttl)
+		t.Fail()
+	}
+
+	if ttl, _ := client.TTL(ctx, "persistent").Result(); ttl != -1 {
+		t.Error("Expected", -1, "got", ttl)
+		t.Fail()
+	}
+
+	if ttl, _ := client.PTTL(ctx, "missing").Result(); ttl != -2 {
+		t.Error("Expected", -2, "got", ttl)
+		t.Fail()
+	}
+
+	if err := client.Do(ctx, "SET", key, value, "EX", "soon").Err(); err == nil {
+		t.Error("Expected error for invalid expire time.")
+		t.Fail()
+	}
+
+	// an overflowing ttl must not wrap around and expire the value at once
+	if err := client.Do(ctx, "SET", key, value, "EX", "9223372036854775807").Err(); err == nil {
+		t.Error("Expected error for an overflowing expire time.")
+		t.Fail()
+	}
+	if ttl, _ := client.TTL(ctx, key).Result(); ttl != time.Minute {
+		t.Error("Expected", time.Minute, "got", ttl)
+		t.Fail()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if err := client.Get(ctx, "short").Err(); err != goredis.Nil {
+		t.Error("Value should have expired.")
+		t.Fail()
+	}
+}
+
+func TestServerKeys(t *testing.T) {
+	value := "testValue"
+	ctx := context.Background()
+
+	c := cache.New()
+	c.Set("user:1", value)
+	c.Set("session:1", value)
+	client := newTestServer(t, c)
+
+	keys, err := client.Keys(ctx, "user:*").Result()
+	if err != nil || len(keys) != 1 || keys[0] != "user:1" {
+		t.Error("Expected [user:1], got", keys, err)
+		t.Fail()
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	ctx := context.Background()
+
+	c := cache.New()
+	client := newTestServer(t, c)
+
+	c.Set("testKey", "testValue")
+	c.Get("testKey")
+
+	info, err := client.Info(ctx).Result()
+	if err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if !strings.Contains(info, "keyspace_hits:1\r\n") {
+		t.Error("Unexpected info", info)
+		t.Fail()
+	}
+}
+
+func TestServerInline(t *testing.T) {
+	c := cache.New()
+	srv := New(c)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("SET testKey testValue\r\nGET testKey\r\n"))
+
+	buf := make([]byte, 64)
+	n := 0
+	for n < 20 {
+		m, err := conn.Read(buf[n:])
+		if err != nil {
+			break
+		}
+		n += m
+	}
+
+	if string(buf[:n]) != "+OK\r\n$9\r\ntestValue\r\n" {
+		t.Errorf("Unexpected reply %q", buf[:n])
+		t.Fail()
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Error("Unexpected error", err)
+		t.Fail()
+	}
+
+	if err := srv.Serve(l); err != ErrServerClosed {
+		t.Error("Expected", ErrServerClosed, "got", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,174 @@
+// Package tracing instruments caches with OpenTelemetry, so cache accesses
+// and loader invocations show up in distributed traces.
+//
+// Wrap a cache with New and use the context aware methods of the returned
+// Cache, and wrap the Loader of a read-through cache with Loader:
+//
+//	c := cache.New(cache.WithLoader(tracing.Loader(loader)))
+//	tc := tracing.New(c)
+//	v, ok := tc.Get(ctx, key)
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer.
+const ScopeName = "github.com/mkrull/layercake/tracing"
+
+// Attributes set on spans.
+const (
+	// HitKey reports whether Get found a value.
+	HitKey = attribute.Key("layercake.hit")
+	// KeyKey holds the key of the operation if enabled with WithKeys.
+	KeyKey = attribute.Key("layercake.key")
+	// TTLKey holds the ttl of SetWithTTL in seconds.
+	TTLKey = attribute.Key("layercake.ttl")
+)
+
+// config holds the settings of a Cache or Loader.
+type config struct {
+	provider trace.TracerProvider
+	keys     bool
+}
+
+// Option configures a Cache or Loader on creation.
+type Option func(*config)
+
+// WithTracerProvider sets the provider of the tracer. The default is the
+// global provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// WithKeys records the keys of operations as span attribute. Keys are not
+// recorded by default as they may contain personal data.
+func WithKeys() Option {
+	return func(c *config) {
+		c.keys = true
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.provider == nil {
+		c.provider = otel.GetTracerProvider()
+	}
+	return c
+}
+
+// Cache records a span for every operation on the wrapped cache.
+type Cache struct {
+	layer  cache.Layer
+	tracer trace.Tracer
+	keys   bool
+}
+
+// New returns a reference to a new Cache wrapping the given cache, e.g. a
+// *cache.Cache or a *cache.Layered.
+func New(layer cache.Layer, opts ...Option) *Cache {
+	cfg := newConfig(opts)
+	return &Cache{
+		layer:  layer,
+		tracer: cfg.provider.Tracer(ScopeName),
+		keys:   cfg.keys,
+	}
+}
+
+func (c *Cache) start(ctx context.Context, name, key string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if c.keys {
+		attrs = append(attrs, KeyKey.String(key))
+	}
+	return c.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+// Get retrieves the value stored with a key in a span recording whether it
+// was found.
+func (c *Cache) Get(ctx context.Context, key string) (interface{}, bool) {
+	_, span := c.start(ctx, "layercake.Get", key)
+	defer span.End()
+
+	v, ok := c.layer.Get(key)
+	span.SetAttributes(HitKey.Bool(ok))
+
+	return v, ok
+}
+
+// Set stores a value with a key in a span.
+func (c *Cache) Set(ctx context.Context, key string, value interface{}) {
+	_, span := c.start(ctx, "layercake.Set", key)
+	defer span.End()
+
+	c.layer.Set(key, value)
+}
+
+// SetWithTTL stores a value with a key and ttl in a span.
+func (c *Cache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	_, span := c.start(ctx, "layercake.SetWithTTL", key, TTLKey.Float64(ttl.Seconds()))
+	defer span.End()
+
+	c.layer.SetWithTTL(key, value, ttl)
+}
+
+// Remove deletes the value stored with a key in a span.
+func (c *Cache) Remove(ctx context.Context, key string) {
+	_, span := c.start(ctx, "layercake.Remove", key)
+	defer span.End()
+
+	c.layer.Remove(key)
+}
+
+// Layer returns the wrapped cache.
+func (c *Cache) Layer() cache.Layer {
+	return c.layer
+}
+
+// loader records a span for every invocation of the wrapped Loader.
+type loader struct {
+	loader cache.Loader
+	tracer trace.Tracer
+	keys   bool
+}
+
+// Loader returns a cache.Loader recording a span for every invocation of l,
+// as a child of the span in the context passed to Fetch. Errors are recorded
+// on the span.
+func Loader(l cache.Loader, opts ...Option) cache.Loader {
+	cfg := newConfig(opts)
+	return &loader{
+		loader: l,
+		tracer: cfg.provider.Tracer(ScopeName),
+		keys:   cfg.keys,
+	}
+}
+
+// Load invokes the wrapped Loader in a span.
+func (l *loader) Load(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var attrs []attribute.KeyValue
+	if l.keys {
+		attrs = append(attrs, KeyKey.String(key))
+	}
+
+	ctx, span := l.tracer.Start(ctx, "layercake.Load", trace.WithAttributes(attrs...))
+	defer span.End()
+
+	v, ttl, err := l.loader.Load(ctx, key)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return v, ttl, err
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mkrull/layercake/cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestCache(t *testing.T) {
+	key := "testKey"
+	value := "testValue"
+
+	tp, rec := newProvider()
+	c := New(cache.New(), WithTracerProvider(tp), WithKeys())
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+
+	c.Set(ctx, key, value)
+	c.Get(ctx, key)
+	c.Remove(ctx, key)
+	c.Get(ctx, key)
+
+	parent.End()
+
+	spans := rec.Ended()
+	names := []string{"layercake.Set", "layercake.Get", "layercake.Remove", "layercake.Get", "parent"}
+	if len(spans) != len(names) {
+		t.Errorf("Expected %d spans. Got %d", len(names), len(spans))
+		t.FailNow()
+	}
+
+	for i, name := range names[:4] {
+		if spans[i].Name() != name {
+			t.Errorf("Expected span %s. Got %s", name, spans[i].Name())
+			t.Fail()
+		}
+		if spans[i].Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Error("Span should be a child of the span in the context.")
+			t.Fail()
+		}
+		if v, _ := attr(spans[i], KeyKey); v.AsString() != key {
+			t.Errorf("Expected key %s. Got %s", key, v.AsString())
+			t.Fail()
+		}
+	}
+
+	if v, _ := attr(spans[1], HitKey); !v.AsBool() {
+		t.Error("First Get should have been a hit.")
+		t.Fail()
+	}
+	if v, ok := attr(spans[3], HitKey); !ok || v.AsBool() {
+		t.Error("Second Get should have been a miss.")
+		t.Fail()
+	}
+}
+
+func TestCacheWithoutKeys(t *testing.T) {
+	tp, rec := newProvider()
+	c := New(cache.New(), WithTracerProvider(tp))
+
+	c.SetWithTTL(context.Background(), "testKey", "testValue", time.Minute)
+
+	span := rec.Ended()[0]
+	if _, ok := attr(span, KeyKey); ok {
+		t.Error("Keys should not be recorded by default.")
+		t.Fail()
+	}
+	if v, _ := attr(span, TTLKey); v.AsFloat64() != 60 {
+		t.Errorf("Expected ttl 60. Got %f", v.AsFloat64())
+		t.Fail()
+	}
+}
+
+func TestLoader(t *testing.T) {
+	tp, rec := newProvider()
+
+	loader := Loader(cache.LoaderFunc(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return nil, 0, errors.New("testError")
+	}), WithTracerProvider(tp))
+
+	c := cache.New(cache.WithLoader(loader))
+	if _, err := c.Fetch(context.Background(), "testKey"); err == nil {
+		t.Error("Fetch should have failed.")
+		t.Fail()
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 || spans[0].Name() != "layercake.Load" {
+		t.Error("Expected a layercake.Load span, got", spans)
+		t.FailNow()
+	}
+
+	if spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 {
+		t.Error("Error should have been recorded.")
+		t.Fail()
+	}
+}